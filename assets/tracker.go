@@ -0,0 +1,68 @@
+package assets
+
+import (
+	"crypto/sha512"
+	"encoding/base64"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+)
+
+//go:generate go run ../cmd/tracker
+
+// TrackerBuild is a minified version of the tracker script.
+type TrackerBuild struct {
+	Version string
+	Content []byte
+	// Integrity is the Subresource Integrity hash of Content.
+	Integrity string
+}
+
+var trackerBuilds, latestTracker = mustLoadTrackerBuilds()
+
+// LatestTracker returns the most recent build of the tracker script.
+func LatestTracker() TrackerBuild {
+	return trackerBuilds[latestTracker]
+}
+
+// FindTracker returns the tracker build with the given version.
+func FindTracker(version string) (TrackerBuild, bool) {
+	build, ok := trackerBuilds[version]
+	return build, ok
+}
+
+func mustLoadTrackerBuilds() (map[string]TrackerBuild, string) {
+	latest, err := Files.ReadFile("tracker/dist/latest")
+	if err != nil {
+		panic(fmt.Errorf("tracker has not been built, run go generate ./assets: %w", err))
+	}
+
+	files, err := fs.Glob(Files, "tracker/dist/*.min.js")
+	if err != nil {
+		panic(err)
+	}
+
+	builds := make(map[string]TrackerBuild, len(files))
+	for _, file := range files {
+		content, err := Files.ReadFile(file)
+		if err != nil {
+			panic(err)
+		}
+
+		sum := sha512.Sum384(content)
+		version := strings.TrimSuffix(path.Base(file), ".min.js")
+		builds[version] = TrackerBuild{
+			Version:   version,
+			Content:   content,
+			Integrity: "sha384-" + base64.StdEncoding.EncodeToString(sum[:]),
+		}
+	}
+
+	version := strings.TrimSpace(string(latest))
+	if _, ok := builds[version]; !ok {
+		panic(fmt.Errorf("latest tracker build %s is missing", version))
+	}
+
+	return builds, version
+}
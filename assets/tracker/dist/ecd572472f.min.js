@@ -0,0 +1 @@
+(function(){var t,s,o,a,l,u,b,_,w,e=document,n=e.currentScript,O=n.dataset.websiteId,v=n.dataset.spa||"",d=v==="hash",p=n.dataset.api?new URL(n.dataset.api,location.href).href:new URL("/api/collect",n.src).href;function y(){return location.pathname+location.search+(d?location.hash:"")}t=y(),u=e.referrer;function c(e,n){var s,o,a,i={website_id:O,type:e,url:t,hostname:location.hostname,referrer:u,screen_width:window.innerWidth,language:navigator.language,webdriver:navigator.webdriver===!0};if(n)for(a in n)i[a]=n[a];o=JSON.stringify(i);try{navigator.sendBeacon(p,new Blob([o],{type:"application/json"}))}catch{s=new XMLHttpRequest,s.open("POST",p),s.setRequestHeader("Content-Type","application/json"),s.send(o)}}w=(n.dataset.extensions||"").split(",").map(function(e){return e.trim()});function i(e){return w.indexOf(e)>-1}function r(e,t){c("event",{event_name:e,event_data:t})}function j(){i("404")&&e.querySelector('meta[name="palantir:404"]')&&r("404",{path:t})}c("pageview"),j(),b=/\.(pdf|zip|rar|7z|gz|tgz|tar|dmg|exe|msi|pkg|deb|rpm|apk|iso|csv|xlsx?|docx?|pptx?|odt|ods|txt|rtf|epub|mp3|wav|mp4|mov|avi|mkv)$/i;function g(e){var t,n,s=e.target&&e.target.closest&&e.target.closest("a[href]");if(!s)return;try{t=new URL(s.href,location.href)}catch{return}if(t.protocol!=="http:"&&t.protocol!=="https:")return;n=t.pathname.match(b),n&&i("downloads")?r("File Download",{url:t.href,extension:n[1].toLowerCase()}):t.host!==location.host&&i("outbound")&&r("Outbound Link: Click",{url:t.href})}(i("outbound")||i("downloads"))&&(e.addEventListener("click",g,!0),e.addEventListener("auxclick",g,!0)),i("forms")&&e.addEventListener("submit",function(e){var n=e.target;r("Form: Submission",{form:n.getAttribute("id")||n.getAttribute("name")||n.getAttribute("action")||t})},!0),o=0,s=e.visibilityState==="visible"?Date.now():0,a=0,l=-1;function f(){var s=e.documentElement,t=Math.max(s.scrollHeight,e.body?e.body.scrollHeight:0),n=t>0?Math.min(100,Math.round((window.scrollY+window.innerHeight)/t*100)):100;n>a&&(a=n)}function m(){if(s&&(o+=Date.now()-s,s=0),o===l)return;l=o,c("engagement",{engaged_ms:o,scroll_depth:a})}f(),window.addEventListener("scroll",f,{passive:!0}),e.addEventListener("visibilitychange",function(){e.visibilityState==="hidden"?m():s=Date.now()}),window.addEventListener("pagehide",m);function h(){clearTimeout(_),_=setTimeout(function(){var n=y();if(n===t)return;m(),u=location.origin+t,t=n,o=0,a=0,l=-1,s=e.visibilityState==="visible"?Date.now():0,f(),c("pageview"),j()},100)}(v==="history"||d)&&(["pushState","replaceState"].forEach(function(e){var t=history[e];history[e]=function(){var e=t.apply(this,arguments);return h(),e}}),window.addEventListener("popstate",h),d&&window.addEventListener("hashchange",h)),window.palantir={track:r}})()
@@ -0,0 +1,211 @@
+// Palantir tracker. Sends a pageview on load and engagement when the page is
+// left.
+//
+// Options are read from data attributes on the script tag:
+//
+//   data-website-id  the website hits belong to (required)
+//   data-spa         "history" tracks client-side navigation in single page
+//                    apps, "hash" also counts hash changes
+//   data-extensions  comma separated automatic events: outbound, downloads,
+//                    404 and forms. 404 pages are recognised by a
+//                    <meta name="palantir:404"> tag, as the script can't see
+//                    the status code
+//   data-api         where hits are sent, relative to the page, for when the
+//                    script is proxied through the website's own domain
+//
+// Edit this file, then run `go generate ./assets` to build a new version.
+(function () {
+  var d = document;
+  var script = d.currentScript;
+  var websiteId = script.dataset.websiteId;
+  var spa = script.dataset.spa || '';
+  var hash = spa === 'hash';
+  var endpoint = script.dataset.api
+    ? new URL(script.dataset.api, location.href).href
+    : new URL('/api/collect', script.src).href;
+
+  function path() {
+    return location.pathname + location.search + (hash ? location.hash : '');
+  }
+
+  var current = path();
+  var referrer = d.referrer;
+
+  function send(type, extra) {
+    var data = {
+      website_id: websiteId,
+      type: type,
+      url: current,
+      hostname: location.hostname,
+      referrer: referrer,
+      screen_width: screen.width,
+      viewport_width: window.innerWidth,
+      pixel_ratio: window.devicePixelRatio,
+      language: navigator.language,
+      webdriver: navigator.webdriver === true
+    };
+    if (extra) {
+      for (var key in extra) {
+        data[key] = extra[key];
+      }
+    }
+
+    var body = JSON.stringify(data);
+    try {
+      navigator.sendBeacon(endpoint, new Blob([body], { type: 'application/json' }));
+    } catch (e) {
+      var xhr = new XMLHttpRequest();
+      xhr.open('POST', endpoint);
+      xhr.setRequestHeader('Content-Type', 'application/json');
+      xhr.send(body);
+    }
+  }
+
+  // Extensions
+
+  var extensions = (script.dataset.extensions || '').split(',').map(function (x) {
+    return x.trim();
+  });
+
+  function enabled(extension) {
+    return extensions.indexOf(extension) > -1;
+  }
+
+  function track(name, properties) {
+    send('event', { event_name: name, event_data: properties });
+  }
+
+  function notFound() {
+    if (enabled('404') && d.querySelector('meta[name="palantir:404"]')) {
+      track('404', { path: current });
+    }
+  }
+
+  send('pageview');
+  notFound();
+
+  var files = /\.(pdf|zip|rar|7z|gz|tgz|tar|dmg|exe|msi|pkg|deb|rpm|apk|iso|csv|xlsx?|docx?|pptx?|odt|ods|txt|rtf|epub|mp3|wav|mp4|mov|avi|mkv)$/i;
+
+  function clicked(e) {
+    var link = e.target && e.target.closest && e.target.closest('a[href]');
+    if (!link) {
+      return;
+    }
+
+    var url;
+    try {
+      url = new URL(link.href, location.href);
+    } catch (err) {
+      return;
+    }
+    if (url.protocol !== 'http:' && url.protocol !== 'https:') {
+      return;
+    }
+
+    var file = url.pathname.match(files);
+    if (file && enabled('downloads')) {
+      track('File Download', { url: url.href, extension: file[1].toLowerCase() });
+    } else if (url.host !== location.host && enabled('outbound')) {
+      track('Outbound Link: Click', { url: url.href });
+    }
+  }
+
+  if (enabled('outbound') || enabled('downloads')) {
+    d.addEventListener('click', clicked, true);
+    d.addEventListener('auxclick', clicked, true);
+  }
+
+  if (enabled('forms')) {
+    d.addEventListener('submit', function (e) {
+      var form = e.target;
+      track('Form: Submission', {
+        form: form.getAttribute('id') || form.getAttribute('name') || form.getAttribute('action') || current
+      });
+    }, true);
+  }
+
+  // Engagement
+
+  var engaged = 0;
+  var visibleSince = d.visibilityState === 'visible' ? Date.now() : 0;
+  var depth = 0;
+  var sentMs = -1;
+
+  function scrolled() {
+    var root = d.documentElement;
+    var total = Math.max(root.scrollHeight, d.body ? d.body.scrollHeight : 0);
+    var percent = total > 0 ? Math.min(100, Math.round((window.scrollY + window.innerHeight) / total * 100)) : 100;
+    if (percent > depth) {
+      depth = percent;
+    }
+  }
+
+  function engage() {
+    if (visibleSince) {
+      engaged += Date.now() - visibleSince;
+      visibleSince = 0;
+    }
+    if (engaged === sentMs) {
+      return;
+    }
+
+    sentMs = engaged;
+    send('engagement', { engaged_ms: engaged, scroll_depth: depth });
+  }
+
+  scrolled();
+  window.addEventListener('scroll', scrolled, { passive: true });
+  d.addEventListener('visibilitychange', function () {
+    if (d.visibilityState === 'hidden') {
+      engage();
+    } else {
+      visibleSince = Date.now();
+    }
+  });
+  window.addEventListener('pagehide', engage);
+
+  // Single page apps
+
+  var timer;
+
+  // navigated sends a pageview once the URL has settled, so rapid
+  // navigations are counted once, with the previous page as referrer.
+  function navigated() {
+    clearTimeout(timer);
+    timer = setTimeout(function () {
+      var next = path();
+      if (next === current) {
+        return;
+      }
+
+      engage();
+      referrer = location.origin + current;
+      current = next;
+      engaged = 0;
+      depth = 0;
+      sentMs = -1;
+      visibleSince = d.visibilityState === 'visible' ? Date.now() : 0;
+      scrolled();
+
+      send('pageview');
+      notFound();
+    }, 100);
+  }
+
+  if (spa === 'history' || hash) {
+    ['pushState', 'replaceState'].forEach(function (method) {
+      var original = history[method];
+      history[method] = function () {
+        var result = original.apply(this, arguments);
+        navigated();
+        return result;
+      };
+    });
+    window.addEventListener('popstate', navigated);
+    if (hash) {
+      window.addEventListener('hashchange', navigated);
+    }
+  }
+
+  window.palantir = { track: track };
+})();
@@ -0,0 +1,127 @@
+// Package clientip works out the address of the client behind a chain of
+// proxies. It is shared by the app, which reads the forwarding headers of
+// its own load balancers, and the proxy handler apps mount to forward hits.
+package clientip
+
+import (
+	"fmt"
+	"net/netip"
+	"strings"
+)
+
+// TrustedProxies are the proxies whose forwarding headers are honoured.
+type TrustedProxies []netip.Prefix
+
+// ParseTrustedProxies reads proxies given as CIDR ranges or single
+// addresses. Blank entries are skipped.
+func ParseTrustedProxies(proxies []string) (TrustedProxies, error) {
+	var trusted TrustedProxies
+	for _, proxy := range proxies {
+		proxy = strings.TrimSpace(proxy)
+		if proxy == "" {
+			continue
+		}
+
+		prefix, err := parseTrustedProxy(proxy)
+		if err != nil {
+			return nil, err
+		}
+		trusted = append(trusted, prefix)
+	}
+
+	return trusted, nil
+}
+
+// Contains reports whether addr is one of the trusted proxies.
+func (t TrustedProxies) Contains(addr netip.Addr) bool {
+	for _, prefix := range t {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// FromChain returns the nearest untrusted address in a list of hops ordered
+// from the client to the last proxy, remote being the peer that sent the
+// list. The chain is walked from the right so a client can't spoof its
+// address by prepending entries. If every hop is trusted the client is the
+// first one. An unparsable hop ends the walk, as anything left of it can't
+// be relied on.
+func (t TrustedProxies) FromChain(remote netip.Addr, chain []string) netip.Addr {
+	client := remote
+	for i := len(chain) - 1; i >= 0; i-- {
+		addr, ok := ParseHostAddr(chain[i])
+		if !ok {
+			break
+		}
+
+		client = addr
+		if !t.Contains(addr) {
+			break
+		}
+	}
+
+	return client
+}
+
+func parseTrustedProxy(proxy string) (netip.Prefix, error) {
+	if strings.Contains(proxy, "/") {
+		prefix, err := netip.ParsePrefix(proxy)
+		if err != nil {
+			return netip.Prefix{}, fmt.Errorf("invalid trusted proxy %q: %w", proxy, err)
+		}
+		return prefix.Masked(), nil
+	}
+
+	addr, err := netip.ParseAddr(proxy)
+	if err != nil {
+		return netip.Prefix{}, fmt.Errorf("invalid trusted proxy %q: %w", proxy, err)
+	}
+	addr = addr.Unmap()
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
+
+// ParseHostAddr parses an address that may carry a port and, for IPv6,
+// brackets.
+func ParseHostAddr(value string) (netip.Addr, bool) {
+	if addrPort, err := netip.ParseAddrPort(value); err == nil {
+		return addrPort.Addr().Unmap(), true
+	}
+
+	value = strings.TrimSuffix(strings.TrimPrefix(value, "["), "]")
+	addr, err := netip.ParseAddr(value)
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	return addr.Unmap(), true
+}
+
+// SplitChain splits X-Forwarded-For values into their hops.
+func SplitChain(values []string) []string {
+	var chain []string
+	for _, value := range values {
+		for hop := range strings.SplitSeq(value, ",") {
+			chain = append(chain, strings.TrimSpace(hop))
+		}
+	}
+	return chain
+}
+
+// ParseForwarded returns the for= parameter of every element of RFC 7239
+// Forwarded headers. Elements without one are kept as empty hops so they
+// stop the walk like any other unusable entry.
+func ParseForwarded(values []string) []string {
+	var chain []string
+	for _, element := range SplitChain(values) {
+		var forValue string
+		for pair := range strings.SplitSeq(element, ";") {
+			key, value, ok := strings.Cut(strings.TrimSpace(pair), "=")
+			if ok && strings.EqualFold(key, "for") {
+				forValue = strings.Trim(value, `"`)
+			}
+		}
+		chain = append(chain, forValue)
+	}
+	return chain
+}
@@ -0,0 +1,34 @@
+package clientip
+
+import (
+	"net/netip"
+	"testing"
+)
+
+func TestTrustedProxies(t *testing.T) {
+	trusted, err := ParseTrustedProxies([]string{" 10.1.2.3/8 ", "", "::ffff:192.0.2.1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		addr string
+		want bool
+	}{
+		{addr: "10.200.0.1", want: true},
+		{addr: "192.0.2.1", want: true},
+		{addr: "192.0.2.2", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.addr, func(t *testing.T) {
+			if got := trusted.Contains(netip.MustParseAddr(tt.addr)); got != tt.want {
+				t.Errorf("Contains(%s) = %v, want %v", tt.addr, got, tt.want)
+			}
+		})
+	}
+
+	if _, err := ParseTrustedProxies([]string{"10.0.0.0/33"}); err == nil {
+		t.Error("invalid range was accepted")
+	}
+}
@@ -9,10 +9,12 @@ import (
 	"os/signal"
 	"strings"
 	"time"
+	_ "time/tzdata"
 
 	"palantir/config"
 	"palantir/controllers"
 	"palantir/database"
+	"palantir/models"
 	"palantir/services"
 	"palantir/internal/server"
 	"palantir/internal/storage"
@@ -40,6 +42,7 @@ func setupControllers(
 	r *router.Router,
 	riverHandler *riverui.Handler,
 	mw middleware.Middleware,
+	pipeline *services.IngestionPipeline,
 ) error {
 	pagesCache, err := controllers.NewCacheBuilder[templ.Component]().Build()
 	if err != nil {
@@ -86,18 +89,48 @@ func setupControllers(
 		return err
 	}
 
-	geo := services.NewIPAPIGeoResolver()
-	collect := controllers.NewCollect(db, geo)
+	websitesCache, err := controllers.NewCacheBuilder[models.Website]().
+		WithSize(1000).
+		WithDefaultTTL(time.Minute).
+		Build()
+	if err != nil {
+		return err
+	}
+	apiKeysCache, err := controllers.NewCacheBuilder[models.WebsiteAPIKey]().
+		WithSize(1000).
+		WithDefaultTTL(time.Minute).
+		Build()
+	if err != nil {
+		return err
+	}
+	bots, err := services.NewBotDetector()
+	if err != nil {
+		return err
+	}
+	limiter := services.NewHitLimiter(services.HitLimiterOptions{
+		RatePerSecond: cfg.Ingestion.RatePerSecond,
+		Burst:         cfg.Ingestion.RateBurst,
+	})
+	collect := controllers.NewCollect(
+		db,
+		cfg,
+		websitesCache,
+		apiKeysCache,
+		pipeline,
+		bots,
+		limiter,
+		services.NewVisitorSalts(db),
+	)
 	if err := r.RegisterCollectRoutes(collect); err != nil {
 		return err
 	}
 
-	tracking := controllers.NewTracking()
+	tracking := controllers.NewTracking(assets)
 	if err := r.RegisterTrackingRoutes(tracking); err != nil {
 		return err
 	}
 
-	websites := controllers.NewWebsites(db)
+	websites := controllers.NewWebsites(db, cfg, websitesCache, apiKeysCache)
 	if err := r.RegisterWebsitesRoutes(websites); err != nil {
 		return err
 	}
@@ -136,8 +169,14 @@ func setupRouter(
 		return nil, err
 	}
 
+	ipExtractor, err := router.NewIPExtractor(cfg.App.TrustedProxies, cfg.App.ClientIPHeaders)
+	if err != nil {
+		return nil, err
+	}
+
 	r, err := router.New(
 		true,
+		ipExtractor,
 		globalMiddleware,
 	)
 	if err != nil {
@@ -188,6 +227,44 @@ func buildTelemetry(ctx context.Context, cfg config.Config) (*telemetry.Telemetr
 	return telemetry.New(ctx, opts...)
 }
 
+// buildGeoResolver returns the configured resolver wrapped in the lookup
+// cache. The MMDB resolver holds open database files, so it is also returned
+// as a shutdowner.
+func buildGeoResolver(cfg config.Config) (services.GeoResolver, []server.Shutdowner, error) {
+	var resolver services.GeoResolver
+	var shutdowners []server.Shutdowner
+
+	switch cfg.Geolocation.Resolver {
+	case config.GeoResolverIPAPI:
+		resolver = services.NewIPAPIGeoResolver()
+	case config.GeoResolverMMDB:
+		mmdb, err := services.NewMMDBGeoResolver(services.MMDBOptions{
+			CityDatabasePath: cfg.Geolocation.CityDatabasePath,
+			ASNDatabasePath:  cfg.Geolocation.ASNDatabasePath,
+			ReloadInterval:   time.Duration(cfg.Geolocation.ReloadIntervalSec) * time.Second,
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+
+		resolver = mmdb
+		shutdowners = append(shutdowners, mmdb)
+	default:
+		return nil, nil, fmt.Errorf("unknown geo resolver %q", cfg.Geolocation.Resolver)
+	}
+
+	cached := services.NewCachedGeoResolver(resolver, services.CachedGeoOptions{
+		Size:             cfg.Geolocation.CacheSize,
+		TTL:              time.Duration(cfg.Geolocation.CacheTTLMinutes) * time.Minute,
+		GroupByPrefix:    cfg.Geolocation.CacheByPrefix,
+		Timeout:          time.Duration(cfg.Geolocation.TimeoutMs) * time.Millisecond,
+		FailureThreshold: cfg.Geolocation.BreakerThreshold,
+		OpenDuration:     time.Duration(cfg.Geolocation.BreakerOpenSeconds) * time.Second,
+	})
+
+	return cached, shutdowners, nil
+}
+
 func run(ctx context.Context) error {
 	ctx, cancel := signal.NotifyContext(ctx, os.Interrupt)
 	defer cancel()
@@ -217,7 +294,7 @@ func run(ctx context.Context) error {
 	}
 	emailClient := mailclients.NewMailpit(cfg.Email.MailpitHost, cfg.Email.MailpitPort)
 
-	wrks, err := workers.Register(emailClient, emailClient)
+	wrks, err := workers.Register(db, emailClient, emailClient)
 	if err != nil {
 		return err
 	}
@@ -248,6 +325,29 @@ func run(ctx context.Context) error {
 
 	mw := middleware.New(db)
 
+	geo, geoShutdowners, err := buildGeoResolver(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to initialize geo resolver: %w", err)
+	}
+
+	pipeline := services.NewIngestionPipeline(
+		db,
+		geo,
+		services.IngestionOptions{
+			BufferSize:    cfg.Ingestion.BufferSize,
+			BatchSize:     cfg.Ingestion.BatchSize,
+			FlushInterval: time.Duration(cfg.Ingestion.FlushIntervalMs) * time.Millisecond,
+		},
+	)
+	pipeline.Start(ctx)
+	// The server only calls its shutdowners in production, and stops at the
+	// first one that fails, so the buffer is drained here as well.
+	defer func() {
+		if err := pipeline.Shutdown(context.Background()); err != nil {
+			slog.Error("ingestion pipeline shutdown error", "error", err)
+		}
+	}()
+
 	endpoints := riverui.NewEndpoints(processor.Client, nil)
 	opts := &riverui.HandlerOpts{
 		Endpoints: endpoints,
@@ -273,18 +373,25 @@ func run(ctx context.Context) error {
 		r,
 		riverHandler,
 		mw,
+		pipeline,
 	)
 	if err != nil {
 		return err
 	}
 
+	// The pipeline resolves geolocation while draining, so it has to shut
+	// down before the resolver.
+	shutdowners := []server.Shutdowner{pipeline}
+	shutdowners = append(shutdowners, geoShutdowners...)
+	shutdowners = append(shutdowners, processor)
+
 	server := server.New(
 		ctx,
 		cfg.App.Host,
 		cfg.App.Port,
 		config.Env,
 		r.Handler,
-		[]server.Shutdowner{processor},
+		shutdowners,
 	)
 
 	slog.InfoContext(ctx, "starting server", "host", cfg.App.Host, "port", cfg.App.Port)
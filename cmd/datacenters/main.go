@@ -0,0 +1,358 @@
+// Command datacenters regenerates services/botlists/datacenter_ranges.txt
+// from the address ranges hosting providers publish. Providers without a
+// feed of their own are covered by the prefixes their networks announce, as
+// reported by RIPEstat. Run it with go generate ./services.
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/netip"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+type provider struct {
+	name   string
+	source string
+	// resolve finds the URL of the current feed on the source page, for
+	// providers that publish it under a new name with every release.
+	resolve func(page []byte) (string, error)
+	parse   func(data []byte) ([]netip.Prefix, error)
+}
+
+var providers = []provider{
+	{
+		name:   "Amazon Web Services",
+		source: "https://ip-ranges.amazonaws.com/ip-ranges.json",
+		parse:  parseAWS,
+	},
+	{
+		name:   "Google Cloud",
+		source: "https://www.gstatic.com/ipranges/cloud.json",
+		parse:  parseGoogleCloud,
+	},
+	{
+		name:    "Microsoft Azure",
+		source:  "https://www.microsoft.com/en-us/download/details.aspx?id=56519",
+		resolve: azureServiceTagsURL,
+		parse:   parseAzureServiceTags,
+	},
+	{
+		name:   "DigitalOcean",
+		source: "https://digitalocean.com/geo/google.csv",
+		parse:  parseGeofeed,
+	},
+	{
+		name:   "Hetzner",
+		source: announcedPrefixesURL("AS24940"),
+		parse:  parseAnnouncedPrefixes,
+	},
+	{
+		name:   "OVHcloud",
+		source: announcedPrefixesURL("AS16276"),
+		parse:  parseAnnouncedPrefixes,
+	},
+	{
+		name:   "Linode (Akamai)",
+		source: "https://geoip.linode.com/",
+		parse:  parseGeofeed,
+	},
+	{
+		name:   "Vultr",
+		source: announcedPrefixesURL("AS20473"),
+		parse:  parseAnnouncedPrefixes,
+	},
+}
+
+// section is the ranges of one provider as written to the list.
+type section struct {
+	name     string
+	source   string
+	prefixes []netip.Prefix
+}
+
+func main() {
+	out := flag.String("out", "botlists/datacenter_ranges.txt", "file to write the ranges to")
+	flag.Parse()
+
+	count, err := generate(&http.Client{Timeout: time.Minute}, *out, time.Now())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("wrote %d datacenter ranges\n", count)
+}
+
+func generate(client *http.Client, out string, now time.Time) (int, error) {
+	sections := make([]section, 0, len(providers))
+	count := 0
+	for _, p := range providers {
+		s, err := fetch(client, p)
+		if err != nil {
+			return 0, fmt.Errorf("%s: %w", p.name, err)
+		}
+		sections = append(sections, s)
+		count += len(s.prefixes)
+	}
+
+	var buf bytes.Buffer
+	write(&buf, sections, now)
+
+	return count, os.WriteFile(out, buf.Bytes(), 0o644)
+}
+
+func fetch(client *http.Client, p provider) (section, error) {
+	source := p.source
+	data, err := get(client, source)
+	if err != nil {
+		return section{}, err
+	}
+
+	if p.resolve != nil {
+		source, err = p.resolve(data)
+		if err != nil {
+			return section{}, err
+		}
+		if data, err = get(client, source); err != nil {
+			return section{}, err
+		}
+	}
+
+	prefixes, err := p.parse(data)
+	if err != nil {
+		return section{}, fmt.Errorf("parse %s: %w", source, err)
+	}
+	if len(prefixes) == 0 {
+		return section{}, fmt.Errorf("no ranges in %s", source)
+	}
+
+	return section{name: p.name, source: source, prefixes: collapse(prefixes)}, nil
+}
+
+func get(client *http.Client, url string) ([]byte, error) {
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("get %s: %s", url, resp.Status)
+	}
+
+	return io.ReadAll(resp.Body)
+}
+
+func write(w io.Writer, sections []section, now time.Time) {
+	fmt.Fprintf(w, `# Networks of large hosting and cloud providers. Real visitors rarely browse
+# from these, so hits originating here are treated as automated.
+#
+# Generated by cmd/datacenters on %s from the sources listed with each
+# provider. Run go generate ./services to refresh it.
+`, now.UTC().Format(time.DateOnly))
+
+	for _, s := range sections {
+		fmt.Fprintf(w, "\n# %s\n# Source: %s\n", s.name, s.source)
+		for _, prefix := range s.prefixes {
+			fmt.Fprintln(w, prefix)
+		}
+	}
+}
+
+// collapse sorts the prefixes and removes those covered by another, then
+// merges adjacent halves of the same network so the list stays short.
+func collapse(prefixes []netip.Prefix) []netip.Prefix {
+	sorted := make([]netip.Prefix, len(prefixes))
+	for i, prefix := range prefixes {
+		sorted[i] = prefix.Masked()
+	}
+	slices.SortFunc(sorted, func(a, b netip.Prefix) int {
+		if c := a.Addr().Compare(b.Addr()); c != 0 {
+			return c
+		}
+		return a.Bits() - b.Bits()
+	})
+
+	var collapsed []netip.Prefix
+	for _, prefix := range sorted {
+		if n := len(collapsed); n > 0 && collapsed[n-1].Overlaps(prefix) {
+			// Sorting puts the wider of two overlapping prefixes first.
+			continue
+		}
+		collapsed = append(collapsed, prefix)
+
+		for n := len(collapsed); n >= 2; n = len(collapsed) {
+			parent, ok := mergeSiblings(collapsed[n-2], collapsed[n-1])
+			if !ok {
+				break
+			}
+			collapsed = append(collapsed[:n-2], parent)
+		}
+	}
+
+	return collapsed
+}
+
+// mergeSiblings returns the network a and b are the two halves of.
+func mergeSiblings(a, b netip.Prefix) (netip.Prefix, bool) {
+	if a.Bits() != b.Bits() || a.Bits() == 0 || a.Addr().Is4() != b.Addr().Is4() {
+		return netip.Prefix{}, false
+	}
+
+	parent, err := a.Addr().Prefix(a.Bits() - 1)
+	if err != nil || parent.Addr() != a.Addr() || !parent.Contains(b.Addr()) {
+		return netip.Prefix{}, false
+	}
+
+	return parent, true
+}
+
+func parsePrefixes(values []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(values))
+	for _, value := range values {
+		prefix, err := netip.ParsePrefix(strings.TrimSpace(value))
+		if err != nil {
+			return nil, err
+		}
+		prefixes = append(prefixes, prefix)
+	}
+
+	return prefixes, nil
+}
+
+func parseAWS(data []byte) ([]netip.Prefix, error) {
+	var doc struct {
+		Prefixes []struct {
+			Prefix string `json:"ip_prefix"`
+		} `json:"prefixes"`
+		IPv6Prefixes []struct {
+			Prefix string `json:"ipv6_prefix"`
+		} `json:"ipv6_prefixes"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+
+	var values []string
+	for _, p := range doc.Prefixes {
+		values = append(values, p.Prefix)
+	}
+	for _, p := range doc.IPv6Prefixes {
+		values = append(values, p.Prefix)
+	}
+
+	return parsePrefixes(values)
+}
+
+func parseGoogleCloud(data []byte) ([]netip.Prefix, error) {
+	var doc struct {
+		Prefixes []struct {
+			IPv4Prefix string `json:"ipv4Prefix"`
+			IPv6Prefix string `json:"ipv6Prefix"`
+		} `json:"prefixes"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+
+	var values []string
+	for _, p := range doc.Prefixes {
+		values = append(values, p.IPv4Prefix+p.IPv6Prefix)
+	}
+
+	return parsePrefixes(values)
+}
+
+var azureServiceTagsPattern = regexp.MustCompile(`https://download\.microsoft\.com/download/[^"']+/ServiceTags_Public_\d+\.json`)
+
+// azureServiceTagsURL finds the current service tags file on its download
+// page, as Microsoft publishes it under a new name every week.
+func azureServiceTagsURL(page []byte) (string, error) {
+	url := azureServiceTagsPattern.Find(page)
+	if url == nil {
+		return "", errors.New("no service tags file on the download page")
+	}
+
+	return string(url), nil
+}
+
+// parseAzureServiceTags reads the AzureCloud tag, which covers every public
+// address of Azure's regions.
+func parseAzureServiceTags(data []byte) ([]netip.Prefix, error) {
+	var doc struct {
+		Values []struct {
+			Name       string `json:"name"`
+			Properties struct {
+				AddressPrefixes []string `json:"addressPrefixes"`
+			} `json:"properties"`
+		} `json:"values"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+
+	for _, value := range doc.Values {
+		if value.Name == "AzureCloud" {
+			return parsePrefixes(value.Properties.AddressPrefixes)
+		}
+	}
+
+	return nil, errors.New("no AzureCloud tag")
+}
+
+// parseGeofeed reads an RFC 8805 geofeed, a CSV file with the prefix in the
+// first column.
+func parseGeofeed(data []byte) ([]netip.Prefix, error) {
+	r := csv.NewReader(bytes.NewReader(data))
+	r.Comment = '#'
+	r.FieldsPerRecord = -1
+
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	var values []string
+	for _, record := range records {
+		if record[0] != "" {
+			values = append(values, record[0])
+		}
+	}
+
+	return parsePrefixes(values)
+}
+
+func announcedPrefixesURL(asn string) string {
+	return "https://stat.ripe.net/data/announced-prefixes/data.json?resource=" + asn
+}
+
+func parseAnnouncedPrefixes(data []byte) ([]netip.Prefix, error) {
+	var doc struct {
+		Data struct {
+			Prefixes []struct {
+				Prefix string `json:"prefix"`
+			} `json:"prefixes"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+
+	var values []string
+	for _, p := range doc.Data.Prefixes {
+		values = append(values, p.Prefix)
+	}
+
+	return parsePrefixes(values)
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCollapse(t *testing.T) {
+	tests := []struct {
+		name string
+		in   []string
+		want []string
+	}{
+		{name: "sorts", in: []string{"10.1.0.0/16", "10.0.0.0/16"}, want: []string{"10.0.0.0/15"}},
+		{name: "drops covered", in: []string{"10.0.0.0/8", "10.20.0.0/16"}, want: []string{"10.0.0.0/8"}},
+		{name: "merges repeatedly", in: []string{"10.0.0.0/10", "10.64.0.0/10", "10.128.0.0/9"}, want: []string{"10.0.0.0/8"}},
+		{name: "keeps neighbours from different networks", in: []string{"10.1.0.0/16", "10.2.0.0/16"}, want: []string{"10.1.0.0/16", "10.2.0.0/16"}},
+		{name: "masks", in: []string{"10.0.0.1/24"}, want: []string{"10.0.0.0/24"}},
+		{name: "ipv6", in: []string{"2001:db8::/33", "2001:db8:8000::/33", "192.0.2.0/24"}, want: []string{"192.0.2.0/24", "2001:db8::/32"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			in, err := parsePrefixes(tt.in)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got []string
+			for _, prefix := range collapse(in) {
+				got = append(got, prefix.String())
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("collapse() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name  string
+		parse func([]byte) ([]netip.Prefix, error)
+		data  string
+		want  []string
+	}{
+		{
+			name:  "aws",
+			parse: parseAWS,
+			data:  `{"prefixes":[{"ip_prefix":"3.5.140.0/22","region":"ap-northeast-2"}],"ipv6_prefixes":[{"ipv6_prefix":"2600:1f00::/24"}]}`,
+			want:  []string{"3.5.140.0/22", "2600:1f00::/24"},
+		},
+		{
+			name:  "google cloud",
+			parse: parseGoogleCloud,
+			data:  `{"prefixes":[{"ipv4Prefix":"34.1.208.0/20","scope":"africa-south1"},{"ipv6Prefix":"2600:1900:8000::/44"}]}`,
+			want:  []string{"34.1.208.0/20", "2600:1900:8000::/44"},
+		},
+		{
+			name:  "azure service tags",
+			parse: parseAzureServiceTags,
+			data:  `{"values":[{"name":"ActionGroup","properties":{"addressPrefixes":["4.145.74.52/30"]}},{"name":"AzureCloud","properties":{"addressPrefixes":["13.64.0.0/11","2603:1000::/40"]}}]}`,
+			want:  []string{"13.64.0.0/11", "2603:1000::/40"},
+		},
+		{
+			name:  "geofeed",
+			parse: parseGeofeed,
+			data:  "# prefix,country,region,city,postal\n45.55.0.0/19,US,US-NY,New York,10011\n2604:a880::/48,US,US-NY,New York,\n",
+			want:  []string{"45.55.0.0/19", "2604:a880::/48"},
+		},
+		{
+			name:  "announced prefixes",
+			parse: parseAnnouncedPrefixes,
+			data:  `{"data":{"prefixes":[{"prefix":"5.9.0.0/16","timelines":[]},{"prefix":"2a01:4f8::/29"}]}}`,
+			want:  []string{"5.9.0.0/16", "2a01:4f8::/29"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prefixes, err := tt.parse([]byte(tt.data))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got []string
+			for _, prefix := range prefixes {
+				got = append(got, prefix.String())
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("parse() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAzureServiceTagsURL(t *testing.T) {
+	page := []byte(`<a href="https://download.microsoft.com/download/7/1/d/71d86715/ServiceTags_Public_20261012.json" class="mscom-link">Download</a>`)
+
+	got, err := azureServiceTagsURL(page)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "https://download.microsoft.com/download/7/1/d/71d86715/ServiceTags_Public_20261012.json"; got != want {
+		t.Errorf("azureServiceTagsURL() = %q, want %q", got, want)
+	}
+
+	if _, err := azureServiceTagsURL([]byte("<html></html>")); err == nil {
+		t.Error("azureServiceTagsURL() found a file on an empty page")
+	}
+}
+
+func TestFetchResolvesFeed(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/page":
+			w.Write([]byte(`<a href="/feed.csv">`))
+		case "/feed.csv":
+			w.Write([]byte("10.0.0.0/9,US\n10.128.0.0/9,US\n"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	s, err := fetch(server.Client(), provider{
+		name:    "Example",
+		source:  server.URL + "/page",
+		resolve: func([]byte) (string, error) { return server.URL + "/feed.csv", nil },
+		parse:   parseGeofeed,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if s.source != server.URL+"/feed.csv" {
+		t.Errorf("source = %q, want the resolved feed", s.source)
+	}
+	if len(s.prefixes) != 1 || s.prefixes[0].String() != "10.0.0.0/8" {
+		t.Errorf("prefixes = %v, want [10.0.0.0/8]", s.prefixes)
+	}
+}
+
+func TestWrite(t *testing.T) {
+	var buf bytes.Buffer
+	write(&buf, []section{{
+		name:     "Example",
+		source:   "https://example.com/ranges.json",
+		prefixes: []netip.Prefix{netip.MustParsePrefix("192.0.2.0/24")},
+	}}, time.Date(2026, 10, 18, 9, 0, 0, 0, time.UTC))
+
+	out := buf.String()
+	for _, want := range []string{
+		"# Generated by cmd/datacenters on 2026-10-18",
+		"# Example\n# Source: https://example.com/ranges.json\n192.0.2.0/24\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output is missing %q:\n%s", want, out)
+		}
+	}
+
+	// The list has to stay readable by the bot detector, which skips
+	// comments and parses every other line as a prefix.
+	for line := range strings.Lines(out) {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if _, err := netip.ParsePrefix(line); err != nil {
+			t.Errorf("line %q is not a prefix", line)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+// Command tracker builds assets/tracker/tracker.js into a minified, versioned
+// file in assets/tracker/dist. Builds are named after their content hash and
+// never overwritten, so snippets pinned to a version with an integrity hash
+// keep working after the script changes. Run it with go generate ./assets.
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/tdewolff/minify/v2"
+	"github.com/tdewolff/minify/v2/js"
+)
+
+func main() {
+	src := flag.String("src", "tracker/tracker.js", "tracker source file")
+	out := flag.String("out", "tracker/dist", "directory for the builds")
+	flag.Parse()
+
+	version, err := build(*src, *out)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("tracker version %s\n", version)
+}
+
+func build(src, out string) (string, error) {
+	source, err := os.ReadFile(src)
+	if err != nil {
+		return "", err
+	}
+
+	var minified bytes.Buffer
+	if err := js.Minify(minify.New(), &minified, bytes.NewReader(source), nil); err != nil {
+		return "", fmt.Errorf("minify %s: %w", src, err)
+	}
+	minified.WriteByte('\n')
+
+	sum := sha256.Sum256(minified.Bytes())
+	version := hex.EncodeToString(sum[:5])
+
+	if err := os.MkdirAll(out, 0o755); err != nil {
+		return "", err
+	}
+
+	path := filepath.Join(out, version+".min.js")
+	if _, err := os.Stat(path); errors.Is(err, fs.ErrNotExist) {
+		if err := os.WriteFile(path, minified.Bytes(), 0o644); err != nil {
+			return "", err
+		}
+	} else if err != nil {
+		return "", err
+	}
+
+	if err := os.WriteFile(filepath.Join(out, "latest"), []byte(version+"\n"), 0o644); err != nil {
+		return "", err
+	}
+
+	return version, nil
+}
@@ -0,0 +1,20 @@
+package main
+
+import (
+	"testing"
+
+	"palantir/assets"
+)
+
+// TestLatestBuildIsCurrent fails when tracker.js was changed without running
+// go generate ./assets.
+func TestLatestBuildIsCurrent(t *testing.T) {
+	version, err := build("../../assets/tracker/tracker.js", t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if latest := assets.LatestTracker().Version; latest != version {
+		t.Fatalf("latest tracker build is %s, source builds to %s; run go generate ./assets", latest, version)
+	}
+}
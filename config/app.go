@@ -10,6 +10,11 @@ type app struct {
 	TokenSigningKey      string   `env:"TOKEN_SIGNING_KEY"`
 	CSRFStrategy         string   `env:"CSRF_STRATEGY" envDefault:"header_only"`
 	CSRFTrustedOrigins   []string `env:"CSRF_TRUSTED_ORIGINS" envSeparator:","`
+
+	// Forwarding headers are only read from requests sent by these proxies,
+	// given as CIDR ranges or addresses. ClientIPHeaders are tried in order.
+	TrustedProxies  []string `env:"TRUSTED_PROXIES" envSeparator:"," envDefault:""`
+	ClientIPHeaders []string `env:"CLIENT_IP_HEADERS" envSeparator:"," envDefault:"Forwarded,X-Forwarded-For,X-Real-IP"`
 }
 
 func newAppConfig() app {
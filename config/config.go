@@ -63,6 +63,8 @@ type Config struct {
 	Telemetry telemetry
 	Email email
 	Auth auth
+	Ingestion ingestion
+	Geolocation geolocation
 }
 
 func NewConfig() Config {
@@ -72,5 +74,7 @@ func NewConfig() Config {
 		Telemetry: newTelemetryConfig(),
 		Email: newEmailConfig(),
 		Auth: newAuthConfig(),
+		Ingestion: newIngestionConfig(),
+		Geolocation: newGeolocationConfig(),
 	}
 }
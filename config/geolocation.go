@@ -0,0 +1,34 @@
+package config
+
+import "github.com/caarlos0/env/v11"
+
+const (
+	GeoResolverIPAPI = "ipapi"
+	GeoResolverMMDB  = "mmdb"
+)
+
+type geolocation struct {
+	Resolver          string `env:"GEO_RESOLVER" envDefault:"ipapi"`
+	CityDatabasePath  string `env:"GEO_MMDB_CITY_PATH" envDefault:""`
+	ASNDatabasePath   string `env:"GEO_MMDB_ASN_PATH" envDefault:""`
+	ReloadIntervalSec int    `env:"GEO_MMDB_RELOAD_INTERVAL_SECONDS" envDefault:"60"`
+
+	CacheSize          int  `env:"GEO_CACHE_SIZE" envDefault:"10000"`
+	CacheTTLMinutes    int  `env:"GEO_CACHE_TTL_MINUTES" envDefault:"1440"`
+	CacheByPrefix      bool `env:"GEO_CACHE_BY_PREFIX" envDefault:"false"`
+	TimeoutMs          int  `env:"GEO_TIMEOUT_MS" envDefault:"1000"`
+	BreakerThreshold   int  `env:"GEO_BREAKER_THRESHOLD" envDefault:"5"`
+	BreakerOpenSeconds int  `env:"GEO_BREAKER_OPEN_SECONDS" envDefault:"30"`
+}
+
+func newGeolocationConfig() geolocation {
+	geolocationCfg := geolocation{}
+
+	if err := env.ParseWithOptions(&geolocationCfg, env.Options{
+		RequiredIfNoDef: true,
+	}); err != nil {
+		panic(err)
+	}
+
+	return geolocationCfg
+}
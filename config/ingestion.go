@@ -0,0 +1,42 @@
+package config
+
+import "github.com/caarlos0/env/v11"
+
+type ingestion struct {
+	BufferSize      int `env:"INGESTION_BUFFER_SIZE" envDefault:"10000"`
+	BatchSize       int `env:"INGESTION_BATCH_SIZE" envDefault:"500"`
+	FlushIntervalMs int `env:"INGESTION_FLUSH_INTERVAL_MS" envDefault:"1000"`
+
+	// Default per visitor limits, overridable per website.
+	RatePerSecond float64 `env:"INGESTION_RATE_PER_SECOND" envDefault:"5"`
+	RateBurst     int     `env:"INGESTION_RATE_BURST" envDefault:"20"`
+
+	// Batch submissions are capped in items and body size, and client
+	// timestamps on batch and server-side hits may only lag or lead the
+	// server clock by these bounds. Visitor salts are only kept for today and
+	// yesterday, so hits from before yesterday are rejected regardless of
+	// the maximum age.
+	BatchMaxItems         int `env:"INGESTION_BATCH_MAX_ITEMS" envDefault:"500"`
+	BatchMaxBytes         int `env:"INGESTION_BATCH_MAX_BYTES" envDefault:"1048576"`
+	BatchMaxAgeHours      int `env:"INGESTION_BATCH_MAX_AGE_HOURS" envDefault:"24"`
+	BatchMaxFutureSeconds int `env:"INGESTION_BATCH_MAX_FUTURE_SECONDS" envDefault:"300"`
+
+	// Minimum viewport widths, in CSS pixels, of the screen size classes.
+	// Anything narrower than the tablet breakpoint is mobile.
+	ScreenTabletMin    int32 `env:"INGESTION_SCREEN_TABLET_MIN" envDefault:"576"`
+	ScreenLaptopMin    int32 `env:"INGESTION_SCREEN_LAPTOP_MIN" envDefault:"992"`
+	ScreenDesktopMin   int32 `env:"INGESTION_SCREEN_DESKTOP_MIN" envDefault:"1440"`
+	ScreenUltrawideMin int32 `env:"INGESTION_SCREEN_ULTRAWIDE_MIN" envDefault:"2560"`
+}
+
+func newIngestionConfig() ingestion {
+	ingestionCfg := ingestion{}
+
+	if err := env.ParseWithOptions(&ingestionCfg, env.Options{
+		RequiredIfNoDef: true,
+	}); err != nil {
+		panic(err)
+	}
+
+	return ingestionCfg
+}
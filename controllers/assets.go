@@ -28,6 +28,12 @@ func NewAssets(cache *Cache[string]) Assets {
 }
 
 func (a Assets) enableCaching(etx *echo.Context, content []byte) *echo.Context {
+	return a.enableCachingFor(etx, content, fmt.Sprintf("public, max-age=%s, immutable", threeMonthsCache))
+}
+
+// enableCachingFor is enableCaching with the Cache-Control of the caller's
+// choosing, for content that changes under the same URL.
+func (a Assets) enableCachingFor(etx *echo.Context, content []byte, cacheControl string) *echo.Context {
 	if config.Env == server.ProdEnvironment {
 		//nolint:gosec //only needed for browser caching
 		hash := md5.Sum(content)
@@ -36,7 +42,7 @@ func (a Assets) enableCaching(etx *echo.Context, content []byte) *echo.Context {
 		if match := etx.Request().Header.Get("If-None-Match"); match == etag {
 			etx.Response().
 				Header().
-				Set("Cache-Control", fmt.Sprintf("public, max-age=%s, immutable", threeMonthsCache))
+				Set("Cache-Control", cacheControl)
 			etx.Response().
 				Header().
 				Set("ETag", etag)
@@ -46,7 +52,7 @@ func (a Assets) enableCaching(etx *echo.Context, content []byte) *echo.Context {
 
 		etx.Response().
 			Header().
-			Set("Cache-Control", fmt.Sprintf("public, max-age=%s, immutable", threeMonthsCache))
+			Set("Cache-Control", cacheControl)
 		etx.Response().
 			Header().
 			Set("Vary", "Accept-Encoding")
@@ -1,16 +1,22 @@
 package controllers
 
 import (
-	"crypto/sha256"
-	"encoding/hex"
+	"bufio"
+	"context"
 	"encoding/json"
+	"errors"
+	"io"
 	"log/slog"
-	"net"
+	"math"
 	"net/http"
-	"os"
+	"net/netip"
+	"net/url"
+	"strconv"
 	"strings"
 	"time"
+	"unicode"
 
+	"palantir/config"
 	"palantir/internal/storage"
 	"palantir/models"
 	"palantir/services"
@@ -21,25 +27,70 @@ import (
 )
 
 type Collect struct {
-	db  storage.Pool
-	geo services.GeoResolver
+	db       storage.Pool
+	cfg      config.Config
+	websites *Cache[models.Website]
+	apiKeys  *Cache[models.WebsiteAPIKey]
+	pipeline *services.IngestionPipeline
+	bots     *services.BotDetector
+	limiter  *services.HitLimiter
+	salts    *services.VisitorSalts
 }
 
-func NewCollect(db storage.Pool, geo services.GeoResolver) Collect {
-	return Collect{db: db, geo: geo}
+func NewCollect(
+	db storage.Pool,
+	cfg config.Config,
+	websites *Cache[models.Website],
+	apiKeys *Cache[models.WebsiteAPIKey],
+	pipeline *services.IngestionPipeline,
+	bots *services.BotDetector,
+	limiter *services.HitLimiter,
+	salts *services.VisitorSalts,
+) Collect {
+	return Collect{
+		db:       db,
+		cfg:      cfg,
+		websites: websites,
+		apiKeys:  apiKeys,
+		pipeline: pipeline,
+		bots:     bots,
+		limiter:  limiter,
+		salts:    salts,
+	}
 }
 
 type collectPayload struct {
 	WebsiteID   string          `json:"website_id"`
 	Type        string          `json:"type"`
 	URL         string          `json:"url"`
+	Hostname    string          `json:"hostname"`
 	Referrer    string          `json:"referrer"`
 	ScreenWidth int32           `json:"screen_width"`
 	Language    string          `json:"language"`
 	EventName   string          `json:"event_name"`
 	EventData   json.RawMessage `json:"event_data"`
+	Webdriver   bool            `json:"webdriver"`
+	// EngagedMs is the time the page was visible and ScrollDepth the
+	// furthest the visitor scrolled, in percent. Engagement hits only.
+	EngagedMs   int64 `json:"engaged_ms"`
+	ScrollDepth int64 `json:"scroll_depth"`
+	// ViewportWidth is the width of the browser window. Older trackers only
+	// sent that, as screen_width.
+	ViewportWidth int32   `json:"viewport_width"`
+	PixelRatio    float64 `json:"pixel_ratio"`
 }
 
+// maxEngagedMs caps reported time on page at a day; anything longer is a
+// tab left open rather than engagement.
+const maxEngagedMs = int64(24 * time.Hour / time.Millisecond)
+
+// maxPixelRatio bounds the reported device pixel ratio; no real display is
+// denser, so anything higher is made up.
+const maxPixelRatio = 10
+
+// setCollectCORSHeaders reflects the request origin. Preflights carry no
+// website ID, so they are always answered; Create only sets the headers once
+// the origin has been checked against the website's allowed hostnames.
 func setCollectCORSHeaders(etx *echo.Context) {
 	headers := etx.Response().Header()
 	origin := etx.Request().Header.Get("Origin")
@@ -63,114 +114,731 @@ func (c Collect) Preflight(etx *echo.Context) error {
 }
 
 func (c Collect) Create(etx *echo.Context) error {
+	var payload collectPayload
+	if err := etx.Bind(&payload); err != nil {
+		return etx.NoContent(http.StatusBadRequest)
+	}
+
+	websiteID, err := payload.validate()
+	if err != nil {
+		return etx.NoContent(http.StatusBadRequest)
+	}
+
+	ctx := etx.Request().Context()
+
+	website, err := c.findWebsite(ctx, websiteID)
+	if err != nil {
+		return etx.NoContent(http.StatusBadRequest)
+	}
+
+	hostname, ok := allowedHostname(website, etx.Request(), payload)
+	if !ok {
+		c.pipeline.RecordDropped(websiteID, models.DroppedReasonHostname)
+		slog.InfoContext(ctx, "rejected hit from disallowed hostname", "website_id", websiteID, "hostname", hostname)
+		return etx.NoContent(http.StatusForbidden)
+	}
+
 	setCollectCORSHeaders(etx)
 
-	var payload collectPayload
+	ua := useragent.New(etx.Request().UserAgent())
+	ip := etx.RealIP()
+
+	// Bots get the same response as real visitors so there is nothing to
+	// probe; the hit is only counted.
+	if c.isBot(ua, ip, payload.Webdriver) {
+		c.pipeline.RecordDropped(websiteID, models.DroppedReasonBot)
+		return etx.NoContent(http.StatusAccepted)
+	}
+
+	if wait, ok := c.limiter.Allow(website, ip); !ok {
+		c.pipeline.RecordDropped(websiteID, models.DroppedReasonThrottle)
+		etx.Response().Header().Set("Retry-After", retryAfterSeconds(wait))
+		return etx.NoContent(http.StatusTooManyRequests)
+	}
+
+	hints := services.ClientHintsFromHeader(etx.Request().Header)
+	hit, err := c.newHit(ctx, payload, website, hostname, ua, hints, ip, time.Now())
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to hash visitor", "error", err, "website_id", websiteID)
+		etx.Response().Header().Set("Retry-After", "1")
+		return etx.NoContent(http.StatusServiceUnavailable)
+	}
+
+	if err := c.pipeline.Enqueue(hit); err != nil {
+		slog.WarnContext(ctx, "rejected hit", "error", err, "website_id", websiteID)
+		etx.Response().Header().Set("Retry-After", "1")
+		return etx.NoContent(http.StatusServiceUnavailable)
+	}
+
+	return etx.NoContent(http.StatusAccepted)
+}
+
+type batchPayload struct {
+	collectPayload
+	// Timestamp is when the hit happened on the client, in RFC 3339. It
+	// defaults to the time the batch was received.
+	Timestamp time.Time `json:"timestamp"`
+}
+
+type batchItemResult struct {
+	Index  int    `json:"index"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+type batchResponse struct {
+	Accepted int               `json:"accepted"`
+	Rejected int               `json:"rejected"`
+	Results  []batchItemResult `json:"results"`
+}
+
+const (
+	batchStatusAccepted = "accepted"
+	batchStatusRejected = "rejected"
+)
+
+var (
+	errBatchEmpty    = errors.New("batch is empty")
+	errBatchTooLarge = errors.New("batch has too many items")
+)
+
+func (r *batchResponse) accept(i int) {
+	r.Results[i] = batchItemResult{Index: i, Status: batchStatusAccepted}
+	r.Accepted++
+}
+
+func (r *batchResponse) reject(i int, reason string) {
+	r.Results[i] = batchItemResult{Index: i, Status: batchStatusRejected, Error: reason}
+	r.Rejected++
+}
+
+// batchItem is a hit of a batch that passed validation and still has to get
+// past the rate limiter.
+type batchItem struct {
+	index     int
+	payload   collectPayload
+	website   models.Website
+	hostname  string
+	ua        *useragent.UserAgent
+	hints     services.ClientHints
+	ip        string
+	timestamp time.Time
+}
+
+// CreateBatch accepts a JSON array or newline delimited JSON of pageviews and
+// events. Unlike Create it writes synchronously in one transaction and
+// reports an outcome for every item. All items are attributed to the visitor
+// sending the request, as when a tracker flushes hits it queued while
+// offline; backends relaying hits of many visitors use CreateServerBatch.
+func (c Collect) CreateBatch(etx *echo.Context) error {
+	payloads, err := decodeBatch[batchPayload](c.batchBody(etx), c.cfg.Ingestion.BatchMaxItems)
+	if err != nil {
+		return etx.NoContent(batchErrorStatus(err))
+	}
+
+	ctx := etx.Request().Context()
+	now := time.Now()
+
+	ua := useragent.New(etx.Request().UserAgent())
+	hints := services.ClientHintsFromHeader(etx.Request().Header)
+	ip := etx.RealIP()
+
+	response := batchResponse{Results: make([]batchItemResult, len(payloads))}
+	var items []batchItem
+	for i, payload := range payloads {
+		websiteID, err := payload.validate()
+		if err != nil {
+			response.reject(i, "invalid hit")
+			continue
+		}
+
+		website, err := c.findWebsite(ctx, websiteID)
+		if err != nil {
+			response.reject(i, "unknown website")
+			continue
+		}
+
+		hostname, ok := allowedHostname(website, etx.Request(), payload.collectPayload)
+		if !ok {
+			c.pipeline.RecordDropped(websiteID, models.DroppedReasonHostname)
+			response.reject(i, "hostname not allowed")
+			continue
+		}
+
+		timestamp := payload.Timestamp
+		if timestamp.IsZero() {
+			timestamp = now
+		}
+		if !c.timestampInRange(timestamp, now) {
+			response.reject(i, "timestamp out of range")
+			continue
+		}
+
+		if c.isBot(ua, ip, payload.Webdriver) {
+			c.pipeline.RecordDropped(websiteID, models.DroppedReasonBot)
+			response.accept(i)
+			continue
+		}
+
+		items = append(items, batchItem{
+			index:     i,
+			payload:   payload.collectPayload,
+			website:   website,
+			hostname:  hostname,
+			ua:        ua,
+			hints:     hints,
+			ip:        ip,
+			timestamp: timestamp,
+		})
+	}
+
+	if response.Accepted > 0 || len(items) > 0 {
+		setCollectCORSHeaders(etx)
+	}
+
+	return c.storeBatch(etx, items, response)
+}
+
+type serverPayload struct {
+	collectPayload
+	// IP and UserAgent describe the visitor the hit is recorded for, not the
+	// server sending it.
+	IP        string `json:"ip"`
+	UserAgent string `json:"user_agent"`
+	// Timestamp defaults to the time the hit was received.
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// visitor parses the IP and user agent of the visitor the hit is for.
+func (p serverPayload) visitor() (string, *useragent.UserAgent, error) {
+	addr, err := netip.ParseAddr(p.IP)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if p.UserAgent == "" {
+		return "", nil, errors.New("user agent is required")
+	}
+
+	return addr.Unmap().String(), useragent.New(p.UserAgent), nil
+}
+
+// CreateServer records a hit sent by a website's own backend. The caller
+// authenticates with one of the website's API keys as a bearer token and
+// passes the visitor's IP and user agent explicitly. There is no browser
+// involved, so hostname rules and CORS don't apply.
+func (c Collect) CreateServer(etx *echo.Context) error {
+	apiKey, ok := c.authenticate(etx)
+	if !ok {
+		return etx.NoContent(http.StatusUnauthorized)
+	}
+
+	ctx := etx.Request().Context()
+
+	var payload serverPayload
 	if err := etx.Bind(&payload); err != nil {
 		return etx.NoContent(http.StatusBadRequest)
 	}
 
-	websiteID, err := uuid.Parse(payload.WebsiteID)
+	websiteID, err := payload.validate()
+	if err != nil {
+		return etx.NoContent(http.StatusBadRequest)
+	}
+
+	if websiteID != apiKey.WebsiteID {
+		return etx.NoContent(http.StatusForbidden)
+	}
+
+	ip, ua, err := payload.visitor()
+	if err != nil {
+		return etx.NoContent(http.StatusBadRequest)
+	}
+
+	now := time.Now()
+	timestamp := payload.Timestamp
+	if timestamp.IsZero() {
+		timestamp = now
+	}
+	if !c.timestampInRange(timestamp, now) {
+		return etx.NoContent(http.StatusBadRequest)
+	}
+
+	website, err := c.findWebsite(ctx, websiteID)
 	if err != nil {
 		return etx.NoContent(http.StatusBadRequest)
 	}
 
-	if payload.URL == "" {
+	if c.isBot(ua, ip, payload.Webdriver) {
+		c.pipeline.RecordDropped(websiteID, models.DroppedReasonBot)
+		return etx.NoContent(http.StatusAccepted)
+	}
+
+	if wait, ok := c.limiter.Allow(website, ip); !ok {
+		c.pipeline.RecordDropped(websiteID, models.DroppedReasonThrottle)
+		etx.Response().Header().Set("Retry-After", retryAfterSeconds(wait))
+		return etx.NoContent(http.StatusTooManyRequests)
+	}
+
+	hit, err := c.newHit(ctx, payload.collectPayload, website, pageHostname(payload.collectPayload), ua, services.ClientHints{}, ip, timestamp)
+	if errors.Is(err, services.ErrVisitorSaltExpired) {
 		return etx.NoContent(http.StatusBadRequest)
 	}
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to hash visitor", "error", err, "website_id", websiteID)
+		etx.Response().Header().Set("Retry-After", "1")
+		return etx.NoContent(http.StatusServiceUnavailable)
+	}
+
+	if err := c.pipeline.Enqueue(hit); err != nil {
+		slog.WarnContext(ctx, "rejected server hit", "error", err, "website_id", websiteID)
+		etx.Response().Header().Set("Retry-After", "1")
+		return etx.NoContent(http.StatusServiceUnavailable)
+	}
+
+	return etx.NoContent(http.StatusAccepted)
+}
+
+// CreateServerBatch is the batch counterpart of CreateServer. Every item
+// names the IP and user agent of its own visitor, so a backend can relay the
+// hits of many visitors at once. All items must belong to the website of the
+// API key.
+func (c Collect) CreateServerBatch(etx *echo.Context) error {
+	apiKey, ok := c.authenticate(etx)
+	if !ok {
+		return etx.NoContent(http.StatusUnauthorized)
+	}
+
+	payloads, err := decodeBatch[serverPayload](c.batchBody(etx), c.cfg.Ingestion.BatchMaxItems)
+	if err != nil {
+		return etx.NoContent(batchErrorStatus(err))
+	}
 
 	ctx := etx.Request().Context()
+	now := time.Now()
 
-	_, err = models.FindWebsite(ctx, c.db.Conn(), websiteID)
+	website, err := c.findWebsite(ctx, apiKey.WebsiteID)
 	if err != nil {
 		return etx.NoContent(http.StatusBadRequest)
 	}
 
-	ua := useragent.New(etx.Request().UserAgent())
-	browserName, _ := ua.Browser()
-	osInfo := ua.OS()
-	device := parseDevice(ua)
-
-	ip := clientIP(etx.Request())
-	visitorHash := computeVisitorHash(websiteID, ip, etx.Request().UserAgent())
-
-	geo, _ := c.geo.Resolve(ip)
-
-	switch payload.Type {
-	case "pageview":
-		_, err = models.CreatePageview(ctx, c.db.Conn(), models.CreatePageviewData{
-			WebsiteID:   websiteID,
-			URL:         payload.URL,
-			Referrer:    payload.Referrer,
-			Browser:     browserName,
-			OS:          osInfo,
-			Device:      device,
-			Language:    payload.Language,
-			ScreenWidth: payload.ScreenWidth,
-			VisitorHash: visitorHash,
-			CountryCode: geo.CountryCode,
-			CountryName: geo.CountryName,
-			City:        geo.City,
-			Region:      geo.Region,
-		})
+	response := batchResponse{Results: make([]batchItemResult, len(payloads))}
+	var items []batchItem
+	for i, payload := range payloads {
+		websiteID, err := payload.validate()
+		if err != nil {
+			response.reject(i, "invalid hit")
+			continue
+		}
+
+		if websiteID != apiKey.WebsiteID {
+			response.reject(i, "website not allowed for api key")
+			continue
+		}
+
+		ip, ua, err := payload.visitor()
 		if err != nil {
-			slog.ErrorContext(ctx, "failed to create pageview", "error", err)
-			return etx.NoContent(http.StatusInternalServerError)
-		}
-
-	case "event":
-		if payload.EventName == "" {
-			return etx.NoContent(http.StatusBadRequest)
-		}
-		_, err = models.CreateEvent(ctx, c.db.Conn(), models.CreateEventData{
-			WebsiteID:   websiteID,
-			URL:         payload.URL,
-			EventName:   payload.EventName,
-			EventData:   payload.EventData,
-			VisitorHash: visitorHash,
-			CountryCode: geo.CountryCode,
-			CountryName: geo.CountryName,
-			City:        geo.City,
-			Region:      geo.Region,
+			response.reject(i, "invalid visitor")
+			continue
+		}
+
+		timestamp := payload.Timestamp
+		if timestamp.IsZero() {
+			timestamp = now
+		}
+		if !c.timestampInRange(timestamp, now) {
+			response.reject(i, "timestamp out of range")
+			continue
+		}
+
+		if c.isBot(ua, ip, payload.Webdriver) {
+			c.pipeline.RecordDropped(websiteID, models.DroppedReasonBot)
+			response.accept(i)
+			continue
+		}
+
+		items = append(items, batchItem{
+			index:     i,
+			payload:   payload.collectPayload,
+			website:   website,
+			hostname:  pageHostname(payload.collectPayload),
+			ua:        ua,
+			ip:        ip,
+			timestamp: timestamp,
 		})
+	}
+
+	return c.storeBatch(etx, items, response)
+}
+
+// storeBatch charges every item a token from its visitor's rate limiter and
+// writes the items that got one in a single transaction. A visitor's items
+// past their remaining tokens are rejected, and Retry-After says when the
+// next token is available.
+func (c Collect) storeBatch(etx *echo.Context, items []batchItem, response batchResponse) error {
+	ctx := etx.Request().Context()
+
+	type visitor struct {
+		websiteID uuid.UUID
+		ip        string
+	}
+
+	counts := make(map[visitor]int)
+	for _, item := range items {
+		counts[visitor{item.website.ID, item.ip}]++
+	}
+
+	var retryAfter time.Duration
+	granted := make(map[visitor]int, len(counts))
+	for _, item := range items {
+		key := visitor{item.website.ID, item.ip}
+		if _, ok := granted[key]; ok {
+			continue
+		}
+
+		allowed, wait := c.limiter.AllowN(item.website, item.ip, counts[key])
+		granted[key] = allowed
+		retryAfter = max(retryAfter, wait)
+	}
+
+	var hits []services.Hit
+	var accepted []int
+	for _, item := range items {
+		key := visitor{item.website.ID, item.ip}
+		if granted[key] == 0 {
+			c.pipeline.RecordDropped(item.website.ID, models.DroppedReasonThrottle)
+			response.reject(item.index, "rate limited")
+			continue
+		}
+		granted[key]--
+
+		hit, err := c.newHit(ctx, item.payload, item.website, item.hostname, item.ua, item.hints, item.ip, item.timestamp)
+		if errors.Is(err, services.ErrVisitorSaltExpired) {
+			response.reject(item.index, "timestamp out of range")
+			continue
+		}
 		if err != nil {
-			slog.ErrorContext(ctx, "failed to create event", "error", err)
-			return etx.NoContent(http.StatusInternalServerError)
+			slog.ErrorContext(ctx, "failed to hash visitor", "error", err, "website_id", item.website.ID)
+			etx.Response().Header().Set("Retry-After", "1")
+			return etx.NoContent(http.StatusServiceUnavailable)
+		}
+
+		hits = append(hits, hit)
+		accepted = append(accepted, item.index)
+	}
+
+	if retryAfter > 0 {
+		etx.Response().Header().Set("Retry-After", retryAfterSeconds(retryAfter))
+	}
+
+	if len(hits) > 0 {
+		if err := c.pipeline.Store(ctx, hits); err != nil {
+			slog.ErrorContext(ctx, "failed to store hit batch", "error", err, "hits", len(hits))
+			etx.Response().Header().Set("Retry-After", "1")
+			return etx.NoContent(http.StatusServiceUnavailable)
 		}
 
+		for _, i := range accepted {
+			response.accept(i)
+		}
+	}
+
+	return etx.JSON(http.StatusOK, response)
+}
+
+// batchBody caps the size of a batch request body.
+func (c Collect) batchBody(etx *echo.Context) io.Reader {
+	return http.MaxBytesReader(etx.Response(), etx.Request().Body, int64(c.cfg.Ingestion.BatchMaxBytes))
+}
+
+// decodeBatch reads either a JSON array of hits or a stream of JSON objects,
+// one per line. It stops as soon as there are more than maxItems hits, so an
+// oversized batch is never held in memory.
+func decodeBatch[T any](body io.Reader, maxItems int) ([]T, error) {
+	reader := bufio.NewReader(body)
+	for {
+		b, err := reader.Peek(1)
+		if err != nil {
+			return nil, err
+		}
+		if !unicode.IsSpace(rune(b[0])) {
+			break
+		}
+		if _, err := reader.ReadByte(); err != nil {
+			return nil, err
+		}
+	}
+
+	decoder := json.NewDecoder(reader)
+
+	array := false
+	if b, _ := reader.Peek(1); b[0] == '[' {
+		if _, err := decoder.Token(); err != nil {
+			return nil, err
+		}
+		array = true
+	}
+
+	var payloads []T
+	for !array || decoder.More() {
+		var payload T
+		err := decoder.Decode(&payload)
+		if !array && errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		payloads = append(payloads, payload)
+		if len(payloads) > maxItems {
+			return nil, errBatchTooLarge
+		}
+	}
+
+	if array {
+		if _, err := decoder.Token(); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(payloads) == 0 {
+		return nil, errBatchEmpty
+	}
+
+	return payloads, nil
+}
+
+// batchErrorStatus is the response to a batch that couldn't be decoded.
+func batchErrorStatus(err error) int {
+	var maxBytesErr *http.MaxBytesError
+	if errors.Is(err, errBatchTooLarge) || errors.As(err, &maxBytesErr) {
+		return http.StatusRequestEntityTooLarge
+	}
+	return http.StatusBadRequest
+}
+
+// validate checks the fields every hit needs and returns its website ID.
+func (p collectPayload) validate() (uuid.UUID, error) {
+	websiteID, err := uuid.Parse(p.WebsiteID)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	if p.URL == "" {
+		return uuid.Nil, errors.New("url is required")
+	}
+
+	if len(p.Hostname) > 255 {
+		return uuid.Nil, errors.New("hostname is too long")
+	}
+
+	switch p.Type {
+	case services.HitTypePageview:
+	case services.HitTypeEvent:
+		if p.EventName == "" {
+			return uuid.Nil, errors.New("event name is required")
+		}
+	case services.HitTypeEngagement:
+		if p.EngagedMs < 0 || p.ScrollDepth < 0 {
+			return uuid.Nil, errors.New("engagement can't be negative")
+		}
 	default:
-		return etx.NoContent(http.StatusBadRequest)
+		return uuid.Nil, errors.New("unknown hit type")
+	}
+
+	return websiteID, nil
+}
+
+func (c Collect) isBot(ua *useragent.UserAgent, ip string, webdriver bool) bool {
+	return c.bots.IsBot(services.BotSignals{
+		UserAgent:       ua.UA(),
+		IP:              ip,
+		FlaggedByParser: ua.Bot(),
+		Webdriver:       webdriver,
+	})
+}
+
+func (c Collect) newHit(
+	ctx context.Context,
+	payload collectPayload,
+	website models.Website,
+	hostname string,
+	ua *useragent.UserAgent,
+	hints services.ClientHints,
+	ip string,
+	receivedAt time.Time,
+) (services.Hit, error) {
+	visitorHash, err := c.salts.Hash(ctx, website.ID, ip, ua.UA(), receivedAt)
+	if err != nil {
+		return services.Hit{}, err
+	}
+
+	hitURL, campaign := services.ParseCampaign(payload.URL)
+	hitURL = website.NormalizeURL(hitURL)
+
+	screenWidth, viewportWidth := payload.ScreenWidth, payload.ViewportWidth
+	if viewportWidth <= 0 {
+		screenWidth, viewportWidth = 0, screenWidth
+	}
+	pixelRatio := payload.PixelRatio
+	if pixelRatio <= 0 || pixelRatio > maxPixelRatio {
+		pixelRatio = 0
+	}
+	engagedMs, scrollDepth := payload.engagement()
+
+	return services.Hit{
+		Type:       payload.Type,
+		ReceivedAt: receivedAt,
+		WebsiteID:  website.ID,
+		URL:        hitURL,
+		PageGroup:  website.PageGroup(hitURL),
+		Hostname:   hostname,
+		Referrer:   payload.Referrer,
+		Campaign:   campaign,
+
+		ParsedReferrer: services.ParseReferrer(payload.Referrer, website.AllowsHostname),
+		Client:         services.ParseClient(ua, hints),
+		Language:       services.NormalizeLanguage(payload.Language),
+		ScreenWidth:    max(screenWidth, 0),
+		ViewportWidth:  max(viewportWidth, 0),
+		PixelRatio:     float32(pixelRatio),
+		ScreenSize:     c.screenBreakpoints().Classify(viewportWidth),
+		EventName:      payload.EventName,
+		EventData:      payload.EventData,
+		VisitorHash:    visitorHash,
+		IP:             ip,
+		EngagedMs:      engagedMs,
+		ScrollDepth:    scrollDepth,
+	}, nil
+}
+
+// engagement returns the reported time on page, capped at maxEngagedMs, and
+// scroll depth, capped at 100 percent.
+func (p collectPayload) engagement() (int32, int16) {
+	return int32(min(p.EngagedMs, maxEngagedMs)), int16(min(p.ScrollDepth, 100))
+}
+
+func (c Collect) screenBreakpoints() services.ScreenBreakpoints {
+	return services.ScreenBreakpoints{
+		Tablet:    c.cfg.Ingestion.ScreenTabletMin,
+		Laptop:    c.cfg.Ingestion.ScreenLaptopMin,
+		Desktop:   c.cfg.Ingestion.ScreenDesktopMin,
+		Ultrawide: c.cfg.Ingestion.ScreenUltrawideMin,
+	}
+}
+
+// findWebsite serves website lookups from the cache so bursts of hits for the
+// same site don't each cost a database round trip.
+func (c Collect) findWebsite(ctx context.Context, id uuid.UUID) (models.Website, error) {
+	return c.websites.Get(id.String(), func() (models.Website, error) {
+		return models.FindWebsite(ctx, c.db.Conn(), id)
+	})
+}
+
+// findAPIKey looks up an API key by the hash of the plain key. Keys are
+// cached like websites, so last_used_at is only refreshed when a key is
+// loaded from the database.
+func (c Collect) findAPIKey(ctx context.Context, plain string) (models.WebsiteAPIKey, error) {
+	hash := models.HashForStorage(plain, c.cfg.Auth.Pepper)
+
+	return c.apiKeys.Get(hash, func() (models.WebsiteAPIKey, error) {
+		apiKey, err := models.FindWebsiteAPIKeyByHash(ctx, c.db.Conn(), hash)
+		if err != nil {
+			return models.WebsiteAPIKey{}, err
+		}
+
+		if err := models.TouchWebsiteAPIKey(ctx, c.db.Conn(), apiKey.ID); err != nil {
+			slog.WarnContext(ctx, "failed to touch api key", "error", err, "api_key_id", apiKey.ID)
+		}
+
+		return apiKey, nil
+	})
+}
+
+// timestampInRange bounds client supplied timestamps to the configured
+// window around now.
+func (c Collect) timestampInRange(timestamp, now time.Time) bool {
+	oldest := now.Add(-time.Duration(c.cfg.Ingestion.BatchMaxAgeHours) * time.Hour)
+	newest := now.Add(time.Duration(c.cfg.Ingestion.BatchMaxFutureSeconds) * time.Second)
+
+	return !timestamp.Before(oldest) && !timestamp.After(newest)
+}
+
+// authenticate checks the bearer API key of a request from a website's
+// backend, setting the challenge header when it is missing or invalid.
+func (c Collect) authenticate(etx *echo.Context) (models.WebsiteAPIKey, bool) {
+	plain, ok := bearerToken(etx.Request())
+	if !ok {
+		etx.Response().Header().Set("WWW-Authenticate", "Bearer")
+		return models.WebsiteAPIKey{}, false
 	}
 
-	return etx.NoContent(http.StatusOK)
+	apiKey, err := c.findAPIKey(etx.Request().Context(), plain)
+	if err != nil {
+		etx.Response().Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+		return models.WebsiteAPIKey{}, false
+	}
+
+	return apiKey, true
 }
 
-func parseDevice(ua *useragent.UserAgent) string {
-	if ua.Mobile() {
-		return "mobile"
+func bearerToken(r *http.Request) (string, bool) {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
 	}
-	if ua.Bot() {
-		return "bot"
+
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
+
+// allowedHostname checks the hostname the hit was sent from, taken from the
+// Origin header or else the Referer, and the hostname of the page when the
+// tracker reported one or sent an absolute URL. It returns the hostname of the
+// page, or the offending hostname on failure.
+func allowedHostname(website models.Website, r *http.Request, payload collectPayload) (string, bool) {
+	source := r.Header.Get("Origin")
+	if source == "" || source == "null" {
+		source = r.Header.Get("Referer")
 	}
-	platform := strings.ToLower(ua.Platform())
-	if strings.Contains(platform, "ipad") || strings.Contains(platform, "tablet") {
-		return "tablet"
+
+	hostname := models.NormalizeHostname(source)
+	if !website.AllowsHostname(hostname) {
+		return hostname, false
 	}
-	return "desktop"
+
+	if u, err := url.Parse(payload.URL); err == nil && u.Host != "" {
+		hostname = models.NormalizeHostname(u.Host)
+		if !website.AllowsHostname(hostname) {
+			return hostname, false
+		}
+	}
+
+	if payload.Hostname != "" {
+		hostname = models.NormalizeHostname(payload.Hostname)
+		if !website.AllowsHostname(hostname) {
+			return hostname, false
+		}
+	}
+
+	return hostname, true
 }
 
-func clientIP(r *http.Request) string {
-	if xff := r.Header.Get("X-Forwarded-For"); xff != "" {
-		parts := strings.SplitN(xff, ",", 2)
-		return strings.TrimSpace(parts[0])
+// pageHostname is the hostname of the page a hit reports, from the hostname
+// field or else an absolute hit URL. It is not checked against the website.
+func pageHostname(payload collectPayload) string {
+	if payload.Hostname != "" {
+		return models.NormalizeHostname(payload.Hostname)
+	}
+
+	if u, err := url.Parse(payload.URL); err == nil {
+		return models.NormalizeHostname(u.Host)
 	}
-	host, _, _ := net.SplitHostPort(r.RemoteAddr)
-	return host
+	return ""
 }
 
-func computeVisitorHash(websiteID uuid.UUID, ip string, userAgent string) string {
-	salt := os.Getenv("VISITOR_HASH_SALT")
-	day := time.Now().UTC().Format("2006-01-02")
-	h := sha256.Sum256([]byte(websiteID.String() + ip + userAgent + day + salt))
-	return hex.EncodeToString(h[:])
+// retryAfterSeconds formats a wait as a Retry-After value, rounding up so
+// clients never retry early.
+func retryAfterSeconds(wait time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(wait.Seconds())))
 }
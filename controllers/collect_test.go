@@ -0,0 +1,431 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"palantir/config"
+	"palantir/models"
+	"palantir/services"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v5"
+)
+
+const testUserAgent = "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.0.0 Safari/537.36"
+
+// newTestCollect builds a collect controller that knows website without a
+// database. Nothing is stored, so hits must be rejected before they reach
+// the visitor salts.
+func newTestCollect(t *testing.T, website models.Website) Collect {
+	t.Helper()
+
+	websites, err := NewCacheBuilder[models.Website]().Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	websites.SetDefault(website.ID.String(), website)
+
+	apiKeys, err := NewCacheBuilder[models.WebsiteAPIKey]().Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bots, err := services.NewBotDetector()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := config.Config{}
+	cfg.Ingestion.BatchMaxItems = 3
+	cfg.Ingestion.BatchMaxBytes = 1024
+	cfg.Ingestion.BatchMaxAgeHours = 24
+	cfg.Ingestion.BatchMaxFutureSeconds = 300
+
+	return NewCollect(
+		nil,
+		cfg,
+		websites,
+		apiKeys,
+		services.NewIngestionPipeline(nil, nil, services.IngestionOptions{}),
+		bots,
+		services.NewHitLimiter(services.HitLimiterOptions{}),
+		nil,
+	)
+}
+
+func newCollectRequest(body string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "/api/collect", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Origin", "https://example.com")
+	req.Header.Set("User-Agent", testUserAgent)
+	req.RemoteAddr = "198.51.100.7:4000"
+	return req
+}
+
+func TestCreateRateLimited(t *testing.T) {
+	website := models.Website{
+		ID:                 uuid.New(),
+		Domain:             "example.com",
+		RateLimitPerSecond: 1,
+		RateLimitBurst:     1,
+	}
+	c := newTestCollect(t, website)
+
+	if _, ok := c.limiter.Allow(website, "198.51.100.7"); !ok {
+		t.Fatal("first hit was throttled")
+	}
+
+	body := `{"website_id":"` + website.ID.String() + `","type":"pageview","url":"https://example.com/"}`
+	rec := httptest.NewRecorder()
+	if err := c.Create(echo.New().NewContext(newCollectRequest(body), rec)); err != nil {
+		t.Fatal(err)
+	}
+
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusTooManyRequests)
+	}
+	if got := rec.Header().Get("Retry-After"); got != "1" {
+		t.Errorf("Retry-After = %q, want %q", got, "1")
+	}
+}
+
+func TestDecodeBatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		want    int
+		wantErr error
+	}{
+		{name: "array", body: ` [{"type":"pageview"},{"type":"event"}]`, want: 2},
+		{name: "newline delimited", body: "{\"type\":\"pageview\"}\n{\"type\":\"event\"}\n", want: 2},
+		{name: "empty array", body: `[]`, wantErr: errBatchEmpty},
+		{name: "too many in array", body: `[{},{},{},{},{},{}]`, wantErr: errBatchTooLarge},
+		{name: "too many delimited", body: "{}\n{}\n{}\n{}\n{", wantErr: errBatchTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payloads, err := decodeBatch[batchPayload](strings.NewReader(tt.body), 3)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("decodeBatch() error = %v, want %v", err, tt.wantErr)
+			}
+			if len(payloads) != tt.want {
+				t.Errorf("decodeBatch() = %d hits, want %d", len(payloads), tt.want)
+			}
+		})
+	}
+}
+
+func TestCreateBatchTooLarge(t *testing.T) {
+	website := models.Website{ID: uuid.New(), Domain: "example.com"}
+	c := newTestCollect(t, website)
+
+	tests := []struct {
+		name string
+		body string
+	}{
+		{name: "too many items", body: `[{},{},{},{}]`},
+		{name: "body too large", body: `[{"url":"` + strings.Repeat("a", 2048) + `"}]`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			if err := c.CreateBatch(echo.New().NewContext(newCollectRequest(tt.body), rec)); err != nil {
+				t.Fatal(err)
+			}
+
+			if rec.Code != http.StatusRequestEntityTooLarge {
+				t.Errorf("status = %d, want %d", rec.Code, http.StatusRequestEntityTooLarge)
+			}
+		})
+	}
+}
+
+func TestCreateBatchRateLimited(t *testing.T) {
+	website := models.Website{
+		ID:                 uuid.New(),
+		Domain:             "example.com",
+		RateLimitPerSecond: 1,
+		RateLimitBurst:     1,
+	}
+	c := newTestCollect(t, website)
+
+	if _, ok := c.limiter.Allow(website, "198.51.100.7"); !ok {
+		t.Fatal("first hit was throttled")
+	}
+
+	item := `{"website_id":"` + website.ID.String() + `","type":"pageview","url":"https://example.com/"}`
+	rec := httptest.NewRecorder()
+	if err := c.CreateBatch(echo.New().NewContext(newCollectRequest(`[`+item+`,`+item+`]`), rec)); err != nil {
+		t.Fatal(err)
+	}
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	if got := rec.Header().Get("Retry-After"); got != "1" {
+		t.Errorf("Retry-After = %q, want %q", got, "1")
+	}
+
+	var response batchResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+		t.Fatal(err)
+	}
+	if response.Rejected != 2 {
+		t.Errorf("rejected %d hits, want 2", response.Rejected)
+	}
+}
+
+func TestCreateServerBatch(t *testing.T) {
+	website := models.Website{
+		ID:                 uuid.New(),
+		Domain:             "example.com",
+		RateLimitPerSecond: 1,
+		RateLimitBurst:     1,
+	}
+	c := newTestCollect(t, website)
+	c.apiKeys.SetDefault(models.HashForStorage("secret", ""), models.WebsiteAPIKey{WebsiteID: website.ID})
+
+	if _, ok := c.limiter.Allow(website, "203.0.113.5"); !ok {
+		t.Fatal("first hit was throttled")
+	}
+
+	body := strings.Join([]string{
+		`{"website_id":"` + uuid.NewString() + `","type":"pageview","url":"/","ip":"203.0.113.5","user_agent":"` + testUserAgent + `"}`,
+		`{"website_id":"` + website.ID.String() + `","type":"pageview","url":"/","ip":"203.0.113.5"}`,
+		`{"website_id":"` + website.ID.String() + `","type":"pageview","url":"/","ip":"203.0.113.5","user_agent":"` + testUserAgent + `"}`,
+	}, "\n")
+
+	tests := []struct {
+		name          string
+		authorization string
+		wantStatus    int
+		wantErrors    []string
+	}{
+		{name: "no key", wantStatus: http.StatusUnauthorized},
+		{
+			name:          "valid key",
+			authorization: "Bearer secret",
+			wantStatus:    http.StatusOK,
+			wantErrors:    []string{"website not allowed for api key", "invalid visitor", "rate limited"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/collect/server/batch", strings.NewReader(body))
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+
+			rec := httptest.NewRecorder()
+			if err := c.CreateServerBatch(echo.New().NewContext(req, rec)); err != nil {
+				t.Fatal(err)
+			}
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if tt.wantErrors == nil {
+				return
+			}
+
+			var response batchResponse
+			if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+				t.Fatal(err)
+			}
+			for i, want := range tt.wantErrors {
+				if got := response.Results[i].Error; got != want {
+					t.Errorf("item %d error = %q, want %q", i, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestCreateServer(t *testing.T) {
+	website := models.Website{
+		ID:                 uuid.New(),
+		Domain:             "example.com",
+		RateLimitPerSecond: 1,
+		RateLimitBurst:     1,
+	}
+	c := newTestCollect(t, website)
+	c.apiKeys.SetDefault(models.HashForStorage("secret", ""), models.WebsiteAPIKey{WebsiteID: website.ID})
+
+	if _, ok := c.limiter.Allow(website, "203.0.113.5"); !ok {
+		t.Fatal("first hit was throttled")
+	}
+
+	tests := []struct {
+		name          string
+		authorization string
+		body          string
+		wantStatus    int
+	}{
+		{
+			name:       "no key",
+			body:       `{"website_id":"` + website.ID.String() + `","type":"pageview","url":"/","ip":"203.0.113.5","user_agent":"` + testUserAgent + `"}`,
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:          "other website",
+			authorization: "Bearer secret",
+			body:          `{"website_id":"` + uuid.NewString() + `","type":"pageview","url":"/","ip":"203.0.113.5","user_agent":"` + testUserAgent + `"}`,
+			wantStatus:    http.StatusForbidden,
+		},
+		{
+			name:          "missing user agent",
+			authorization: "Bearer secret",
+			body:          `{"website_id":"` + website.ID.String() + `","type":"pageview","url":"/","ip":"203.0.113.5"}`,
+			wantStatus:    http.StatusBadRequest,
+		},
+		{
+			name:          "invalid ip",
+			authorization: "Bearer secret",
+			body:          `{"website_id":"` + website.ID.String() + `","type":"pageview","url":"/","ip":"localhost","user_agent":"` + testUserAgent + `"}`,
+			wantStatus:    http.StatusBadRequest,
+		},
+		{
+			name:          "rate limited",
+			authorization: "Bearer secret",
+			body:          `{"website_id":"` + website.ID.String() + `","type":"pageview","url":"/","ip":"203.0.113.5","user_agent":"` + testUserAgent + `"}`,
+			wantStatus:    http.StatusTooManyRequests,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/collect/server", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+
+			rec := httptest.NewRecorder()
+			if err := c.CreateServer(echo.New().NewContext(req, rec)); err != nil {
+				t.Fatal(err)
+			}
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestEngagementPayload(t *testing.T) {
+	tests := []struct {
+		name            string
+		payload         collectPayload
+		wantErr         bool
+		wantEngagedMs   int32
+		wantScrollDepth int16
+	}{
+		{
+			name:            "valid",
+			payload:         collectPayload{EngagedMs: 12_000, ScrollDepth: 75},
+			wantEngagedMs:   12_000,
+			wantScrollDepth: 75,
+		},
+		{
+			name:            "capped",
+			payload:         collectPayload{EngagedMs: maxEngagedMs * 2, ScrollDepth: 250},
+			wantEngagedMs:   int32(maxEngagedMs),
+			wantScrollDepth: 100,
+		},
+		{name: "negative time", payload: collectPayload{EngagedMs: -1}, wantErr: true},
+		{name: "negative scroll depth", payload: collectPayload{ScrollDepth: -1}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.payload.WebsiteID = uuid.NewString()
+			tt.payload.Type = services.HitTypeEngagement
+			tt.payload.URL = "https://example.com/"
+
+			_, err := tt.payload.validate()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			engagedMs, scrollDepth := tt.payload.engagement()
+			if engagedMs != tt.wantEngagedMs || scrollDepth != tt.wantScrollDepth {
+				t.Errorf("engagement() = %d, %d, want %d, %d", engagedMs, scrollDepth, tt.wantEngagedMs, tt.wantScrollDepth)
+			}
+		})
+	}
+}
+
+func TestAllowedHostname(t *testing.T) {
+	website := models.Website{Domain: "example.com"}
+	exact := models.Website{Domain: "example.com", AllowedHostnames: []string{"example.com", "www.example.com"}}
+
+	tests := []struct {
+		name         string
+		website      models.Website
+		origin       string
+		referer      string
+		payload      collectPayload
+		wantHostname string
+		wantOK       bool
+	}{
+		{name: "origin", website: website, origin: "https://example.com", payload: collectPayload{URL: "/"}, wantHostname: "example.com", wantOK: true},
+		{name: "missing origin", website: website, referer: "https://example.com/pricing", payload: collectPayload{URL: "/"}, wantHostname: "example.com", wantOK: true},
+		{name: "null origin", website: website, origin: "null", referer: "https://example.com/", payload: collectPayload{URL: "/"}, wantHostname: "example.com", wantOK: true},
+		{name: "no origin or referer", website: website, payload: collectPayload{URL: "/"}, wantHostname: "", wantOK: false},
+		{name: "www", website: website, origin: "https://www.example.com", payload: collectPayload{URL: "/"}, wantHostname: "www.example.com", wantOK: true},
+		{name: "port", website: website, origin: "http://example.com:8080", payload: collectPayload{URL: "/"}, wantHostname: "example.com", wantOK: true},
+		{name: "subdomain not allowed", website: exact, origin: "https://blog.example.com", payload: collectPayload{URL: "/"}, wantHostname: "blog.example.com", wantOK: false},
+		{name: "url host over origin", website: website, origin: "https://example.com", payload: collectPayload{URL: "https://shop.example.com/cart"}, wantHostname: "shop.example.com", wantOK: true},
+		{name: "hostname field over url", website: website, origin: "https://example.com", payload: collectPayload{URL: "https://shop.example.com/cart", Hostname: "WWW.Example.com"}, wantHostname: "www.example.com", wantOK: true},
+		{name: "url host not allowed", website: website, origin: "https://example.com", payload: collectPayload{URL: "https://example.org/"}, wantHostname: "example.org", wantOK: false},
+		{name: "hostname field not allowed", website: exact, origin: "https://example.com", payload: collectPayload{URL: "/", Hostname: "blog.example.com"}, wantHostname: "blog.example.com", wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/collect", nil)
+			if tt.origin != "" {
+				req.Header.Set("Origin", tt.origin)
+			}
+			if tt.referer != "" {
+				req.Header.Set("Referer", tt.referer)
+			}
+
+			hostname, ok := allowedHostname(tt.website, req, tt.payload)
+			if hostname != tt.wantHostname || ok != tt.wantOK {
+				t.Errorf("allowedHostname() = %q, %v, want %q, %v", hostname, ok, tt.wantHostname, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestPageHostname(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload collectPayload
+		want    string
+	}{
+		{name: "hostname field", payload: collectPayload{URL: "https://example.com/", Hostname: "Shop.Example.com"}, want: "shop.example.com"},
+		{name: "absolute url", payload: collectPayload{URL: "https://www.example.com:8443/cart"}, want: "www.example.com"},
+		{name: "relative url", payload: collectPayload{URL: "/cart"}, want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := pageHostname(tt.payload); got != tt.want {
+				t.Errorf("pageHostname() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"palantir/internal/storage"
 	"palantir/models"
 	"palantir/router/cookies"
+	"palantir/services"
 	"palantir/views"
 
 	"github.com/google/uuid"
@@ -45,17 +46,19 @@ func (d Dashboard) Show(etx *echo.Context) error {
 	period := etx.QueryParam("period")
 	startParam := etx.QueryParam("start")
 	endParam := etx.QueryParam("end")
-	startDate, endDate := parseDateRange(period, startParam, endParam)
+	startDate, endDate := parseDateRange(period, startParam, endParam, website.Location())
 	prevStart, prevEnd := previousPeriodRange(startDate, endDate)
 
 	bucket := chooseBucket(startDate, endDate)
 
-	stats, err := models.GetDashboardStats(ctx, d.db.Conn(), websiteID, startDate, endDate, prevStart, prevEnd, bucket)
+	filters := parseDashboardFilters(etx)
+
+	stats, err := models.GetDashboardStats(ctx, d.db.Conn(), websiteID, startDate, endDate, prevStart, prevEnd, bucket, filters)
 	if err != nil {
 		return render(etx, views.InternalError())
 	}
 
-	return render(etx, views.DashboardShow(website, stats, period, startParam, endParam, bucket))
+	return render(etx, views.DashboardShow(website, stats, period, startParam, endParam, bucket, filters))
 }
 
 func (d Dashboard) Live(etx *echo.Context) error {
@@ -79,47 +82,72 @@ func (d Dashboard) Live(etx *echo.Context) error {
 	period := etx.QueryParam("period")
 	startParam := etx.QueryParam("start")
 	endParam := etx.QueryParam("end")
-	startDate, endDate := parseDateRange(period, startParam, endParam)
+	startDate, endDate := parseDateRange(period, startParam, endParam, website.Location())
 	prevStart, prevEnd := previousPeriodRange(startDate, endDate)
 	bucket := chooseBucket(startDate, endDate)
 
-	stats, err := models.GetDashboardStats(ctx, d.db.Conn(), websiteID, startDate, endDate, prevStart, prevEnd, bucket)
+	stats, err := models.GetDashboardStats(ctx, d.db.Conn(), websiteID, startDate, endDate, prevStart, prevEnd, bucket, parseDashboardFilters(etx))
 	if err != nil {
 		return etx.NoContent(http.StatusInternalServerError)
 	}
 
 	return hypermedia.MarshalAndPatchSignals(etx, map[string]any{
-		"dashboard": dashboardSignalsPayload(stats, bucket),
+		"dashboard": dashboardSignalsPayload(stats, bucket, website.Location()),
 	})
 }
 
-func parseDateRange(period, startParam, endParam string) (time.Time, time.Time) {
-	now := time.Now().UTC()
-	endDate := time.Date(now.Year(), now.Month(), now.Day(), 23, 59, 59, 0, time.UTC)
+// parseDateRange resolves a dashboard period to a time range in loc, the
+// website's timezone, so days start at local midnight.
+func parseDateRange(period, startParam, endParam string, loc *time.Location) (time.Time, time.Time) {
+	now := time.Now().In(loc)
+	endDate := time.Date(now.Year(), now.Month(), now.Day(), 23, 59, 59, 0, loc)
 
 	if period == "custom" && startParam != "" && endParam != "" {
 		start, err1 := time.Parse("2006-01-02", startParam)
 		end, err2 := time.Parse("2006-01-02", endParam)
 		if err1 == nil && err2 == nil {
-			startDate := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, time.UTC)
-			endDate := time.Date(end.Year(), end.Month(), end.Day(), 23, 59, 59, 0, time.UTC)
+			startDate := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, loc)
+			endDate := time.Date(end.Year(), end.Month(), end.Day(), 23, 59, 59, 0, loc)
 			return startDate, endDate
 		}
 	}
 
 	switch period {
 	case "today":
-		return now.Add(-24 * time.Hour), now
+		return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc), endDate
 	case "30d":
 		return endDate.AddDate(0, 0, -30), endDate
 	case "month":
-		startDate := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+		startDate := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, loc)
 		return startDate, endDate
 	default: // "7d" or empty
 		return endDate.AddDate(0, 0, -7), endDate
 	}
 }
 
+// parseDashboardFilters reads the dashboard filters from the query. Filters
+// come as repeated field:op:value parameters, plus one from the add filter
+// form; invalid ones are ignored.
+func parseDashboardFilters(etx *echo.Context) models.DashboardFilters {
+	filters := models.DashboardFilters{
+		Hostname:  models.NormalizeHostname(etx.QueryParam("hostname")),
+		Language:  services.NormalizeLanguage(etx.QueryParam("language")),
+		PageGroup: etx.QueryParam("page_group"),
+	}
+
+	raw := etx.QueryParams()["filter"]
+	if field := etx.QueryParam("filter_field"); field != "" {
+		raw = append(raw, field+":"+etx.QueryParam("filter_op")+":"+etx.QueryParam("filter_value"))
+	}
+	for _, value := range raw {
+		if filter, ok := models.ParseFilter(value); ok {
+			filters = filters.Add(filter)
+		}
+	}
+
+	return filters
+}
+
 func chooseBucket(start, end time.Time) string {
 	if end.Sub(start) <= 48*time.Hour {
 		return "hour"
@@ -167,6 +195,18 @@ func formatRate(v float64) string {
 	return fmt.Sprintf("%.1f%%", v)
 }
 
+// formatDuration renders a visit length in seconds as e.g. "2m 05s".
+func formatDuration(seconds float64) string {
+	d := time.Duration(seconds * float64(time.Second)).Round(time.Second)
+	if d < time.Minute {
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	}
+	if d < time.Hour {
+		return fmt.Sprintf("%dm %02ds", int(d.Minutes()), int(d.Seconds())%60)
+	}
+	return fmt.Sprintf("%dh %02dm", int(d.Hours()), int(d.Minutes())%60)
+}
+
 func formatFloat1(v float64) string {
 	if v == 0 {
 		return "0"
@@ -174,24 +214,29 @@ func formatFloat1(v float64) string {
 	return fmt.Sprintf("%.1f", v)
 }
 
-func dashboardSignalsPayload(stats models.DashboardStats, bucket string) map[string]any {
+func dashboardSignalsPayload(stats models.DashboardStats, bucket string, loc *time.Location) map[string]any {
 	return map[string]any{
 		"totals": map[string]any{
-			"visitors":          formatCompact(stats.TotalUniqueVisitors),
-			"visitorsChange":    math.Round(stats.UniqueVisitorsChange),
-			"pageviews":         formatCompact(stats.TotalPageviews),
-			"pageviewsChange":   math.Round(stats.PageviewsChange),
-			"viewsPerVisitor":   formatFloat1(stats.ViewsPerVisitor),
-			"vpvChange":         math.Round(stats.ViewsPerVisitorChange),
-			"bounceRate":        formatRate(stats.BounceRate),
-			"bounceRateChange":  math.Round(stats.BounceRateChange),
+			"visitors":            formatCompact(stats.TotalUniqueVisitors),
+			"visitorsChange":      math.Round(stats.UniqueVisitorsChange),
+			"visits":              formatCompact(stats.TotalSessions),
+			"visitsChange":        math.Round(stats.SessionsChange),
+			"pageviews":           formatCompact(stats.TotalPageviews),
+			"pageviewsChange":     math.Round(stats.PageviewsChange),
+			"viewsPerVisitor":     formatFloat1(stats.ViewsPerVisitor),
+			"vpvChange":           math.Round(stats.ViewsPerVisitorChange),
+			"bounceRate":          formatRate(stats.BounceRate),
+			"bounceRateChange":    math.Round(stats.BounceRateChange),
+			"visitDuration":       formatDuration(stats.AvgVisitDuration),
+			"visitDurationChange": math.Round(stats.VisitDurationChange),
+			"botHitsExcluded":     formatCompact(stats.BotHitsExcluded),
 		},
 		"series": map[string]any{
 			"pageviews": toSeriesPayload(stats.PageviewsOverTime, bucket),
 			"visitors":  toSeriesPayload(stats.VisitorsOverTime, bucket),
 			"events":    toSeriesPayload(stats.EventsOverTime, bucket),
 		},
-		"lastUpdated": time.Now().UTC().Format("15:04:05 UTC"),
+		"lastUpdated": time.Now().In(loc).Format("15:04:05 MST"),
 	}
 }
 
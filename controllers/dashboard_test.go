@@ -0,0 +1,33 @@
+package controllers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/labstack/echo/v5"
+)
+
+func TestParseDashboardFiltersHostname(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  string
+	}{
+		{name: "none", query: "", want: ""},
+		{name: "hostname", query: "www.example.com", want: "www.example.com"},
+		{name: "case and port", query: "Shop.Example.com:8080", want: "shop.example.com"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/?hostname="+url.QueryEscape(tt.query), nil)
+			filters := parseDashboardFilters(echo.New().NewContext(req, httptest.NewRecorder()))
+
+			if filters.Hostname != tt.want {
+				t.Errorf("Hostname = %q, want %q", filters.Hostname, tt.want)
+			}
+		})
+	}
+}
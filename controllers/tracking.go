@@ -3,31 +3,39 @@ package controllers
 import (
 	"net/http"
 
+	"palantir/assets"
+	"palantir/views"
+
 	"github.com/labstack/echo/v5"
 )
 
-type Tracking struct{}
-
-func NewTracking() Tracking {
-	return Tracking{}
+// Tracking serves the tracker script built from assets/tracker.
+type Tracking struct {
+	assets Assets
 }
 
-const trackingScript = `(function(){
-  var d=document,s=d.currentScript,id=s.dataset.websiteId;
-  var ep=new URL('/api/collect',s.src).href;
-  function send(t,extra){
-    var data={website_id:id,type:t,url:location.pathname+location.search,referrer:document.referrer,screen_width:window.innerWidth,language:navigator.language};
-    if(extra){for(var k in extra){data[k]=extra[k]}}
-    try{navigator.sendBeacon(ep,new Blob([JSON.stringify(data)],{type:'application/json'}))}catch(e){
-      var x=new XMLHttpRequest();x.open('POST',ep);x.setRequestHeader('Content-Type','application/json');x.send(JSON.stringify(data));
-    }
-  }
-  send('pageview');
-  window.palantir={track:function(n,d){send('event',{event_name:n,event_data:d})}};
-})();`
+func NewTracking(assets Assets) Tracking {
+	return Tracking{assets}
+}
 
+// Script serves the latest tracker build under the unversioned URL older
+// snippets use, so it is only cached for a day and then revalidated against
+// its ETag.
 func (t Tracking) Script(etx *echo.Context) error {
-	etx.Response().Header().Set("Content-Type", "application/javascript")
-	etx.Response().Header().Set("Cache-Control", "public, max-age=86400")
-	return etx.String(http.StatusOK, trackingScript)
+	tracker := assets.LatestTracker()
+
+	etx = t.assets.enableCachingFor(etx, tracker.Content, "public, max-age=86400")
+	return etx.Blob(http.StatusOK, "application/javascript", tracker.Content)
+}
+
+// ScriptVersion serves a pinned tracker build. Builds never change, so they
+// are cached like any other asset.
+func (t Tracking) ScriptVersion(etx *echo.Context) error {
+	tracker, ok := assets.FindTracker(etx.Param("id"))
+	if !ok {
+		return render(etx, views.NotFound())
+	}
+
+	etx = t.assets.enableCaching(etx, tracker.Content)
+	return etx.Blob(http.StatusOK, "application/javascript", tracker.Content)
 }
@@ -4,7 +4,12 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
+	"palantir/config"
+	"palantir/internal/hypermedia"
 	"palantir/internal/storage"
 	"palantir/models"
 	"palantir/router/cookies"
@@ -15,12 +20,26 @@ import (
 	"github.com/labstack/echo/v5"
 )
 
+// droppedHitsWindow is how far back rejected hits are summed on the website
+// page.
+const droppedHitsWindow = 30 * 24 * time.Hour
+
 type Websites struct {
-	db storage.Pool
+	db  storage.Pool
+	cfg config.Config
+	// cache and apiKeys are shared with Collect and have to be invalidated on
+	// changes so hostname rules and revoked keys take effect immediately.
+	cache   *Cache[models.Website]
+	apiKeys *Cache[models.WebsiteAPIKey]
 }
 
-func NewWebsites(db storage.Pool) Websites {
-	return Websites{db: db}
+func NewWebsites(
+	db storage.Pool,
+	cfg config.Config,
+	cache *Cache[models.Website],
+	apiKeys *Cache[models.WebsiteAPIKey],
+) Websites {
+	return Websites{db: db, cfg: cfg, cache: cache, apiKeys: apiKeys}
 }
 
 func (w Websites) Index(etx *echo.Context) error {
@@ -88,7 +107,17 @@ func (w Websites) Show(etx *echo.Context) error {
 		return render(etx, views.NotFound())
 	}
 
-	return render(etx, views.WebsitesShow(website))
+	dropped, err := models.FindDroppedHitTotals(ctx, w.db.Conn(), websiteID, time.Now().Add(-droppedHitsWindow))
+	if err != nil {
+		return render(etx, views.InternalError())
+	}
+
+	apiKeys, err := models.FindWebsiteAPIKeysByWebsiteID(ctx, w.db.Conn(), websiteID)
+	if err != nil {
+		return render(etx, views.InternalError())
+	}
+
+	return render(etx, views.WebsitesShow(website, dropped, apiKeys))
 }
 
 func (w Websites) Edit(etx *echo.Context) error {
@@ -113,8 +142,20 @@ func (w Websites) Edit(etx *echo.Context) error {
 }
 
 type updateWebsitePayload struct {
-	Name   string `json:"name"`
-	Domain string `json:"domain"`
+	Name               string  `json:"name"`
+	Domain             string  `json:"domain"`
+	AllowedHostnames   string  `json:"allowed_hostnames"`
+	AllowLocalhost     bool    `json:"allow_localhost"`
+	RateLimitPerSecond formInt `json:"rate_limit_per_second"`
+	RateLimitBurst     formInt `json:"rate_limit_burst"`
+	ProxyScriptPath    string  `json:"proxy_script_path"`
+	ProxyCollectPath   string  `json:"proxy_collect_path"`
+	QueryParamMode     string  `json:"query_param_mode"`
+	QueryParams        string  `json:"query_params"`
+	LowercasePaths     bool    `json:"lowercase_paths"`
+	StripTrailingSlash bool    `json:"strip_trailing_slash"`
+	PathPatterns       string  `json:"path_patterns"`
+	Timezone           string  `json:"timezone"`
 }
 
 func (w Websites) Update(etx *echo.Context) error {
@@ -141,17 +182,33 @@ func (w Websites) Update(etx *echo.Context) error {
 	}
 
 	_, err = models.UpdateWebsite(ctx, w.db.Conn(), models.UpdateWebsiteData{
-		ID:     websiteID,
-		Name:   payload.Name,
-		Domain: payload.Domain,
+		ID:               websiteID,
+		Name:             payload.Name,
+		Domain:           payload.Domain,
+		AllowedHostnames: parseList(payload.AllowedHostnames),
+		AllowLocalhost:   payload.AllowLocalhost,
+
+		RateLimitPerSecond: int32(payload.RateLimitPerSecond),
+		RateLimitBurst:     int32(payload.RateLimitBurst),
+		ProxyScriptPath:    strings.TrimSpace(payload.ProxyScriptPath),
+		ProxyCollectPath:   strings.TrimSpace(payload.ProxyCollectPath),
+
+		QueryParamMode:     payload.QueryParamMode,
+		QueryParams:        parseList(payload.QueryParams),
+		LowercasePaths:     payload.LowercasePaths,
+		StripTrailingSlash: payload.StripTrailingSlash,
+		PathPatterns:       parseList(payload.PathPatterns),
+
+		Timezone: strings.TrimSpace(payload.Timezone),
 	})
 	if err != nil {
 		if errors.Is(err, models.ErrDomainValidation) {
-			cookies.AddFlash(etx, cookies.FlashError, "Please provide a valid name and domain")
+			cookies.AddFlash(etx, cookies.FlashError, "Please provide a valid name, domain, limits, proxy paths, URL rules and timezone")
 			return etx.Redirect(http.StatusSeeOther, routes.WebsiteEdit.URL(websiteID))
 		}
 		return render(etx, views.InternalError())
 	}
+	w.cache.Invalidate(websiteID.String())
 
 	cookies.AddFlash(etx, cookies.FlashSuccess, "Website updated successfully")
 	return etx.Redirect(http.StatusSeeOther, routes.WebsiteShow.URL(websiteID))
@@ -179,7 +236,122 @@ func (w Websites) Destroy(etx *echo.Context) error {
 		cookies.AddFlash(etx, cookies.FlashError, fmt.Sprintf("Failed to delete website: %v", err))
 		return etx.Redirect(http.StatusSeeOther, routes.WebsiteShow.URL(websiteID))
 	}
+	w.cache.Invalidate(websiteID.String())
 
 	cookies.AddFlash(etx, cookies.FlashSuccess, "Website deleted successfully")
 	return etx.Redirect(http.StatusSeeOther, routes.WebsiteIndex.URL())
 }
+
+type createAPIKeyPayload struct {
+	Name string `json:"api_key_name"`
+}
+
+// CreateAPIKey adds a server-side API key and patches the key list, which is
+// the only time the plain key is shown.
+func (w Websites) CreateAPIKey(etx *echo.Context) error {
+	websiteID, err := uuid.Parse(etx.Param("id"))
+	if err != nil {
+		return render(etx, views.BadRequest())
+	}
+
+	app := cookies.GetApp(etx)
+	ctx := etx.Request().Context()
+
+	website, err := models.FindWebsite(ctx, w.db.Conn(), websiteID)
+	if err != nil {
+		return render(etx, views.NotFound())
+	}
+
+	if website.UserID != app.UserID {
+		return render(etx, views.NotFound())
+	}
+
+	var payload createAPIKeyPayload
+	if err := etx.Bind(&payload); err != nil {
+		return render(etx, views.BadRequest())
+	}
+
+	_, plain, err := models.CreateWebsiteAPIKey(ctx, w.db.Conn(), w.cfg.Auth.Pepper, models.CreateWebsiteAPIKeyData{
+		WebsiteID: websiteID,
+		Name:      strings.TrimSpace(payload.Name),
+	})
+	if err != nil {
+		if errors.Is(err, models.ErrDomainValidation) {
+			cookies.AddFlash(etx, cookies.FlashError, "Please give the API key a name")
+			return hypermedia.Redirect(etx, routes.WebsiteShow.URL(websiteID))
+		}
+		return render(etx, views.InternalError())
+	}
+
+	apiKeys, err := models.FindWebsiteAPIKeysByWebsiteID(ctx, w.db.Conn(), websiteID)
+	if err != nil {
+		return render(etx, views.InternalError())
+	}
+
+	return hypermedia.PatchElementTempl(etx, views.WebsiteAPIKeys(website, apiKeys, plain))
+}
+
+func (w Websites) DestroyAPIKey(etx *echo.Context) error {
+	websiteID, err := uuid.Parse(etx.Param("id"))
+	if err != nil {
+		return render(etx, views.BadRequest())
+	}
+
+	keyID, err := uuid.Parse(etx.Param("key_id"))
+	if err != nil {
+		return render(etx, views.BadRequest())
+	}
+
+	app := cookies.GetApp(etx)
+	ctx := etx.Request().Context()
+
+	website, err := models.FindWebsite(ctx, w.db.Conn(), websiteID)
+	if err != nil {
+		return render(etx, views.NotFound())
+	}
+
+	if website.UserID != app.UserID {
+		return render(etx, views.NotFound())
+	}
+
+	apiKey, err := models.DestroyWebsiteAPIKey(ctx, w.db.Conn(), websiteID, keyID)
+	if err != nil {
+		return render(etx, views.NotFound())
+	}
+	w.apiKeys.Invalidate(apiKey.Hash)
+
+	apiKeys, err := models.FindWebsiteAPIKeysByWebsiteID(ctx, w.db.Conn(), websiteID)
+	if err != nil {
+		return render(etx, views.InternalError())
+	}
+
+	return hypermedia.PatchElementTempl(etx, views.WebsiteAPIKeys(website, apiKeys, ""))
+}
+
+// parseList splits list fields such as the allowed hostnames, which accept
+// one entry per line or a comma separated list.
+func parseList(value string) []string {
+	return strings.FieldsFunc(value, func(r rune) bool {
+		return r == ',' || r == '\n' || r == '\r' || r == ' ' || r == '\t'
+	})
+}
+
+// formInt decodes a number input, which Datastar may send either as a JSON
+// number or as a string. An empty field decodes to zero.
+type formInt int
+
+func (i *formInt) UnmarshalJSON(data []byte) error {
+	value := strings.Trim(string(data), `"`)
+	if value == "" || value == "null" {
+		*i = 0
+		return nil
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return err
+	}
+
+	*i = formInt(n)
+	return nil
+}
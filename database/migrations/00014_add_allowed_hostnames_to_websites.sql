@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+ALTER TABLE websites
+    ADD COLUMN allowed_hostnames TEXT[] NOT NULL DEFAULT '{}',
+    ADD COLUMN allow_localhost BOOLEAN NOT NULL DEFAULT FALSE;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+ALTER TABLE websites
+    DROP COLUMN IF EXISTS allowed_hostnames,
+    DROP COLUMN IF EXISTS allow_localhost;
+-- +goose StatementEnd
@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+CREATE TABLE IF NOT EXISTS dropped_hits (
+    website_id uuid NOT NULL REFERENCES websites(id) ON DELETE CASCADE,
+    day DATE NOT NULL,
+    reason VARCHAR(32) NOT NULL,
+    hits BIGINT NOT NULL DEFAULT 0,
+
+    PRIMARY KEY (website_id, day, reason)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+DROP TABLE IF EXISTS dropped_hits;
+-- +goose StatementEnd
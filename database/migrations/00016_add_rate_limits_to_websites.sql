@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+ALTER TABLE websites
+    ADD COLUMN rate_limit_per_second INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN rate_limit_burst INTEGER NOT NULL DEFAULT 0;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+ALTER TABLE websites
+    DROP COLUMN IF EXISTS rate_limit_per_second,
+    DROP COLUMN IF EXISTS rate_limit_burst;
+-- +goose StatementEnd
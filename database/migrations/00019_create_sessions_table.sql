@@ -0,0 +1,89 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+CREATE TABLE IF NOT EXISTS sessions (
+    id uuid not null PRIMARY KEY,
+
+    website_id uuid NOT NULL REFERENCES websites(id) ON DELETE CASCADE,
+    visitor_hash VARCHAR(64) NOT NULL,
+    started_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    ended_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    entry_page TEXT,
+    exit_page TEXT,
+    pageviews INT NOT NULL DEFAULT 0,
+    events INT NOT NULL DEFAULT 0,
+    referrer TEXT,
+    utm_source VARCHAR(255),
+    utm_medium VARCHAR(255),
+    utm_campaign VARCHAR(255),
+    utm_term VARCHAR(255),
+    utm_content VARCHAR(255)
+);
+
+CREATE INDEX idx_sessions_visitor ON sessions(website_id, visitor_hash, ended_at);
+CREATE INDEX idx_sessions_website_started ON sessions(website_id, started_at);
+
+ALTER TABLE pageviews ADD COLUMN session_id uuid;
+ALTER TABLE events ADD COLUMN session_id uuid;
+
+-- Group hits stored before sessions existed into sessions with the same
+-- 30 minute inactivity timeout the ingestion pipeline uses.
+CREATE TEMPORARY TABLE backfill_hits AS
+SELECT
+    id, kind, website_id, visitor_hash, created_at, url, referrer,
+    sum(starts_session) OVER (
+        PARTITION BY website_id, visitor_hash ORDER BY created_at ROWS UNBOUNDED PRECEDING
+    ) AS session_number
+FROM (
+    SELECT
+        *,
+        CASE
+            WHEN created_at - lag(created_at) OVER (PARTITION BY website_id, visitor_hash ORDER BY created_at) <= interval '30 minutes'
+            THEN 0 ELSE 1
+        END AS starts_session
+    FROM (
+        SELECT id, 'pageview' AS kind, website_id, visitor_hash, created_at, url, referrer
+        FROM pageviews WHERE visitor_hash IS NOT NULL
+        UNION ALL
+        SELECT id, 'event' AS kind, website_id, visitor_hash, created_at, url, NULL
+        FROM events WHERE visitor_hash IS NOT NULL
+    ) AS hits
+) AS gaps;
+
+CREATE TEMPORARY TABLE backfill_sessions AS
+SELECT gen_random_uuid() AS id, website_id, visitor_hash, session_number
+FROM backfill_hits
+GROUP BY website_id, visitor_hash, session_number;
+
+INSERT INTO sessions (id, website_id, visitor_hash, started_at, ended_at, entry_page, exit_page, pageviews, events, referrer)
+SELECT
+    s.id, s.website_id, s.visitor_hash, min(h.created_at), max(h.created_at),
+    (array_agg(h.url ORDER BY h.created_at) FILTER (WHERE h.kind = 'pageview'))[1],
+    (array_agg(h.url ORDER BY h.created_at DESC) FILTER (WHERE h.kind = 'pageview'))[1],
+    count(*) FILTER (WHERE h.kind = 'pageview'),
+    count(*) FILTER (WHERE h.kind = 'event'),
+    (array_agg(h.referrer ORDER BY h.created_at) FILTER (WHERE h.kind = 'pageview'))[1]
+FROM backfill_hits h
+JOIN backfill_sessions s USING (website_id, visitor_hash, session_number)
+GROUP BY s.id, s.website_id, s.visitor_hash;
+
+UPDATE pageviews SET session_id = s.id
+FROM backfill_hits h
+JOIN backfill_sessions s USING (website_id, visitor_hash, session_number)
+WHERE h.kind = 'pageview' AND h.id = pageviews.id;
+
+UPDATE events SET session_id = s.id
+FROM backfill_hits h
+JOIN backfill_sessions s USING (website_id, visitor_hash, session_number)
+WHERE h.kind = 'event' AND h.id = events.id;
+
+DROP TABLE backfill_hits, backfill_sessions;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+ALTER TABLE events DROP COLUMN IF EXISTS session_id;
+ALTER TABLE pageviews DROP COLUMN IF EXISTS session_id;
+DROP TABLE IF EXISTS sessions;
+-- +goose StatementEnd
@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+ALTER TABLE pageviews
+    ADD COLUMN engaged_ms INT,
+    ADD COLUMN scroll_depth SMALLINT;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+ALTER TABLE pageviews
+    DROP COLUMN IF EXISTS engaged_ms,
+    DROP COLUMN IF EXISTS scroll_depth;
+-- +goose StatementEnd
@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+ALTER TABLE websites
+    ADD COLUMN proxy_script_path VARCHAR(64) NOT NULL DEFAULT '',
+    ADD COLUMN proxy_collect_path VARCHAR(64) NOT NULL DEFAULT '';
+
+UPDATE websites
+    SET proxy_script_path = '/' || substr(md5(random()::text), 1, 10) || '.js',
+        proxy_collect_path = '/' || substr(md5(random()::text), 1, 10);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+ALTER TABLE websites
+    DROP COLUMN IF EXISTS proxy_script_path,
+    DROP COLUMN IF EXISTS proxy_collect_path;
+-- +goose StatementEnd
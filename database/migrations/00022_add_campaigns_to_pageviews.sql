@@ -0,0 +1,33 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+ALTER TABLE pageviews
+    ADD COLUMN utm_source VARCHAR(255),
+    ADD COLUMN utm_medium VARCHAR(255),
+    ADD COLUMN utm_campaign VARCHAR(255),
+    ADD COLUMN utm_term VARCHAR(255),
+    ADD COLUMN utm_content VARCHAR(255),
+    ADD COLUMN click_id VARCHAR(32);
+
+ALTER TABLE sessions
+    ADD COLUMN click_id VARCHAR(32);
+
+CREATE INDEX idx_sessions_website_utm_source ON sessions (website_id, utm_source, started_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+DROP INDEX IF EXISTS idx_sessions_website_utm_source;
+
+ALTER TABLE sessions
+    DROP COLUMN IF EXISTS click_id;
+
+ALTER TABLE pageviews
+    DROP COLUMN IF EXISTS utm_source,
+    DROP COLUMN IF EXISTS utm_medium,
+    DROP COLUMN IF EXISTS utm_campaign,
+    DROP COLUMN IF EXISTS utm_term,
+    DROP COLUMN IF EXISTS utm_content,
+    DROP COLUMN IF EXISTS click_id;
+-- +goose StatementEnd
@@ -0,0 +1,43 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+ALTER TABLE pageviews
+    ADD COLUMN referrer_source VARCHAR(255);
+
+ALTER TABLE sessions
+    ADD COLUMN referrer_source VARCHAR(255),
+    ADD COLUMN channel VARCHAR(32);
+
+-- Existing referrers are reduced to their host. Known sources and channels
+-- are only assigned at ingestion, so older visits have no channel.
+UPDATE pageviews
+    SET referrer_source = regexp_replace(lower(substring(referrer from '^[a-zA-Z][a-zA-Z0-9+.-]*://([^/:?#]+)')), '^www\.', '')
+    WHERE referrer IS NOT NULL;
+
+UPDATE sessions
+    SET referrer_source = regexp_replace(lower(substring(referrer from '^[a-zA-Z][a-zA-Z0-9+.-]*://([^/:?#]+)')), '^www\.', '')
+    WHERE referrer IS NOT NULL;
+
+UPDATE pageviews p
+    SET referrer_source = NULL
+    FROM websites w
+    WHERE w.id = p.website_id
+      AND (p.referrer_source = lower(w.domain) OR p.referrer_source LIKE '%.' || lower(w.domain));
+
+UPDATE sessions s
+    SET referrer_source = NULL
+    FROM websites w
+    WHERE w.id = s.website_id
+      AND (s.referrer_source = lower(w.domain) OR s.referrer_source LIKE '%.' || lower(w.domain));
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+ALTER TABLE sessions
+    DROP COLUMN IF EXISTS referrer_source,
+    DROP COLUMN IF EXISTS channel;
+
+ALTER TABLE pageviews
+    DROP COLUMN IF EXISTS referrer_source;
+-- +goose StatementEnd
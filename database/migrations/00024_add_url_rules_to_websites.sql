@@ -0,0 +1,27 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+ALTER TABLE websites
+    ADD COLUMN query_param_mode VARCHAR(16) NOT NULL DEFAULT 'keep',
+    ADD COLUMN query_params TEXT[] NOT NULL DEFAULT '{}',
+    ADD COLUMN lowercase_paths BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN strip_trailing_slash BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN path_patterns TEXT[] NOT NULL DEFAULT '{}';
+
+ALTER TABLE pageviews
+    ADD COLUMN page_group VARCHAR(255);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+ALTER TABLE pageviews
+    DROP COLUMN IF EXISTS page_group;
+
+ALTER TABLE websites
+    DROP COLUMN IF EXISTS query_param_mode,
+    DROP COLUMN IF EXISTS query_params,
+    DROP COLUMN IF EXISTS lowercase_paths,
+    DROP COLUMN IF EXISTS strip_trailing_slash,
+    DROP COLUMN IF EXISTS path_patterns;
+-- +goose StatementEnd
@@ -0,0 +1,38 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+ALTER TABLE pageviews
+    ADD COLUMN hostname VARCHAR(255);
+
+ALTER TABLE events
+    ADD COLUMN hostname VARCHAR(255);
+
+ALTER TABLE sessions
+    ADD COLUMN hostname VARCHAR(255);
+
+-- Hits sent with absolute URLs already carry their hostname.
+UPDATE pageviews
+SET hostname = lower(substring(url from '^[a-zA-Z][a-zA-Z0-9+.-]*://([^/:?#]+)'))
+WHERE url ~ '^[a-zA-Z][a-zA-Z0-9+.-]*://';
+
+UPDATE events
+SET hostname = lower(substring(url from '^[a-zA-Z][a-zA-Z0-9+.-]*://([^/:?#]+)'))
+WHERE url ~ '^[a-zA-Z][a-zA-Z0-9+.-]*://';
+
+CREATE INDEX idx_pageviews_website_hostname ON pageviews (website_id, hostname, created_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+DROP INDEX IF EXISTS idx_pageviews_website_hostname;
+
+ALTER TABLE sessions
+    DROP COLUMN IF EXISTS hostname;
+
+ALTER TABLE events
+    DROP COLUMN IF EXISTS hostname;
+
+ALTER TABLE pageviews
+    DROP COLUMN IF EXISTS hostname;
+-- +goose StatementEnd
@@ -0,0 +1,49 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+ALTER TABLE pageviews
+    ADD COLUMN browser_version VARCHAR(64),
+    ADD COLUMN os_version VARCHAR(64),
+    ADD COLUMN device_brand VARCHAR(64),
+    ADD COLUMN device_model VARCHAR(64);
+
+-- Operating systems used to be stored as the user agent spelled them, with
+-- the version attached. Split them like the parser does now.
+UPDATE pageviews
+SET os = 'iOS', os_version = substring(os from '(\d+_\d+)')
+WHERE os LIKE '%iPhone OS%';
+
+UPDATE pageviews
+SET os = 'iPadOS', os_version = substring(os from '(\d+_\d+)')
+WHERE os LIKE 'CPU OS %';
+
+UPDATE pageviews
+SET os = 'macOS', os_version = substring(os from '(\d+[_.]\d+)')
+WHERE os LIKE '%Mac OS X%';
+
+UPDATE pageviews
+SET os = 'Android', os_version = substring(os from 'Android (\d+)')
+WHERE os LIKE 'Android%';
+
+UPDATE pageviews
+SET os = 'Windows', os_version = substring(os from 'Windows (.+)$')
+WHERE os LIKE 'Windows %';
+
+UPDATE pageviews SET os = 'Chrome OS' WHERE os LIKE 'CrOS%';
+
+UPDATE pageviews SET os = 'Linux' WHERE os LIKE 'Linux%';
+
+UPDATE pageviews
+SET os_version = replace(os_version, '_', '.')
+WHERE os_version LIKE '%\_%';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+ALTER TABLE pageviews
+    DROP COLUMN IF EXISTS browser_version,
+    DROP COLUMN IF EXISTS os_version,
+    DROP COLUMN IF EXISTS device_brand,
+    DROP COLUMN IF EXISTS device_model;
+-- +goose StatementEnd
@@ -0,0 +1,31 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+ALTER TABLE pageviews
+    ADD COLUMN viewport_width INT,
+    ADD COLUMN pixel_ratio REAL,
+    ADD COLUMN screen_size VARCHAR(16);
+
+-- Trackers before this change sent the window width as the screen width.
+UPDATE pageviews SET viewport_width = screen_width WHERE screen_width IS NOT NULL;
+
+-- Classified with the default breakpoints.
+UPDATE pageviews
+SET screen_size = CASE
+    WHEN screen_width < 576 THEN 'mobile'
+    WHEN screen_width < 992 THEN 'tablet'
+    WHEN screen_width < 1440 THEN 'laptop'
+    WHEN screen_width < 2560 THEN 'desktop'
+    ELSE 'ultrawide'
+END
+WHERE screen_width > 0;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+ALTER TABLE pageviews
+    DROP COLUMN IF EXISTS viewport_width,
+    DROP COLUMN IF EXISTS pixel_ratio,
+    DROP COLUMN IF EXISTS screen_size;
+-- +goose StatementEnd
@@ -0,0 +1,48 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+ALTER TABLE events
+    ADD COLUMN language VARCHAR(16);
+
+ALTER TABLE sessions
+    ADD COLUMN language VARCHAR(16);
+
+-- Bring stored tags into the form ingestion now writes, mirroring
+-- services.NormalizeLanguage: en-us and en_US become en-US and zh-hant-tw
+-- becomes zh-Hant-TW. Anything that doesn't start with a language code
+-- becomes NULL, as ingestion stores it.
+UPDATE pageviews
+SET language = (
+    SELECT lower(m[1])
+        || coalesce('-' || upper(substr(m[2], 2, 1)) || lower(substr(m[2], 3)), '')
+        || coalesce(upper(m[3]), '')
+    FROM regexp_match(
+        btrim(replace(split_part(split_part(language, ',', 1), ';', 1), '_', '-'), E' \t\r\n'),
+        '^([A-Za-z]{2,3})(?=-|$)(-[A-Za-z]{4}(?=-|$))?(-(?:[A-Za-z]{2}|[0-9]{3})(?=-|$))?'
+    ) AS m
+)
+WHERE language IS NOT NULL;
+
+UPDATE sessions
+SET language = (
+    SELECT p.language FROM pageviews p
+    WHERE p.session_id = sessions.id AND p.language IS NOT NULL
+    ORDER BY p.created_at
+    LIMIT 1
+);
+
+UPDATE events
+SET language = sessions.language
+FROM sessions
+WHERE events.session_id = sessions.id AND sessions.language IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+ALTER TABLE sessions
+    DROP COLUMN IF EXISTS language;
+
+ALTER TABLE events
+    DROP COLUMN IF EXISTS language;
+-- +goose StatementEnd
@@ -0,0 +1,152 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- filter_matches applies a dashboard filter operator to a value. Missing
+-- values compare as empty.
+CREATE FUNCTION filter_matches(value text, op text, expected text) RETURNS boolean
+LANGUAGE sql IMMUTABLE AS $$
+    SELECT CASE op
+        WHEN 'is' THEN coalesce(value, '') = expected
+        WHEN 'is_not' THEN coalesce(value, '') <> expected
+        WHEN 'contains' THEN strpos(lower(coalesce(value, '')), lower(expected)) > 0
+        ELSE false
+    END
+$$;
+
+-- filtered_visits returns the visits of a website overlapping a period that
+-- pass every filter in a JSON array of {field, op, value} objects. A visit
+-- passes a filter when any of its hits matches, and a negated filter when
+-- none has the value. Page filters are skipped unless pages is set, as hits
+-- are filtered on their own page instead. Each filter is matched against
+-- the period's hits in one pass, so callers compute the set once per query
+-- rather than once per row.
+CREATE FUNCTION filtered_visits(website uuid, since timestamptz, until timestamptz, filters jsonb, pages boolean)
+RETURNS SETOF uuid
+LANGUAGE sql STABLE AS $$
+    WITH visits AS (
+        SELECT id, started_at, ended_at, referrer_source, utm_source, utm_medium, utm_campaign, utm_term, utm_content
+        FROM sessions
+        WHERE website_id = website AND ended_at >= since AND started_at <= until
+    ),
+    criteria AS (
+        SELECT
+            t.n,
+            t.f->>'field' AS field,
+            CASE WHEN t.f->>'op' = 'is_not' THEN 'is' ELSE t.f->>'op' END AS op,
+            t.f->>'value' AS expected,
+            t.f->>'op' = 'is_not' AS negated
+        FROM jsonb_array_elements(filters) WITH ORDINALITY AS t(f, n)
+        WHERE pages OR t.f->>'field' <> 'page'
+    ),
+    matches AS (
+        -- Referrers and campaigns are kept on the visit itself.
+        SELECT c.n, c.negated, v.id
+        FROM criteria c
+        JOIN visits v ON filter_matches(CASE c.field
+            WHEN 'referrer' THEN v.referrer_source
+            WHEN 'utm_source' THEN v.utm_source
+            WHEN 'utm_medium' THEN v.utm_medium
+            WHEN 'utm_campaign' THEN v.utm_campaign
+            WHEN 'utm_term' THEN v.utm_term
+            WHEN 'utm_content' THEN v.utm_content
+        END, c.op, c.expected)
+        WHERE c.field IN ('referrer', 'utm_source', 'utm_medium', 'utm_campaign', 'utm_term', 'utm_content')
+        UNION
+        SELECT c.n, c.negated, e.session_id
+        FROM criteria c
+        JOIN events e ON filter_matches(e.event_name, c.op, c.expected)
+        WHERE c.field = 'event'
+          AND e.website_id = website
+          AND e.created_at BETWEEN (SELECT min(started_at) FROM visits) AND (SELECT max(ended_at) FROM visits)
+        UNION
+        SELECT c.n, c.negated, p.session_id
+        FROM criteria c
+        JOIN pageviews p ON filter_matches(CASE c.field
+            WHEN 'page' THEN p.url
+            WHEN 'country' THEN p.country_code
+            WHEN 'city' THEN p.city
+            WHEN 'browser' THEN p.browser
+            WHEN 'os' THEN p.os
+            WHEN 'device' THEN p.device
+        END, c.op, c.expected)
+        WHERE c.field IN ('page', 'country', 'city', 'browser', 'os', 'device')
+          AND p.website_id = website
+          AND p.created_at BETWEEN (SELECT min(started_at) FROM visits) AND (SELECT max(ended_at) FROM visits)
+    )
+    SELECT v.id
+    FROM visits v
+    LEFT JOIN matches m ON m.id = v.id
+    GROUP BY v.id
+    HAVING count(m.n) FILTER (WHERE NOT m.negated) = (SELECT count(*) FROM criteria WHERE NOT negated)
+       AND count(m.n) FILTER (WHERE m.negated) = 0
+$$;
+
+-- page_matches_filters reports whether a page passes the page filters of a
+-- JSON array of filters, ignoring every other field.
+CREATE FUNCTION page_matches_filters(page text, filters jsonb) RETURNS boolean
+LANGUAGE sql IMMUTABLE AS $$
+    SELECT coalesce(bool_and(filter_matches(page, f->>'op', f->>'value')), true)
+    FROM jsonb_array_elements(filters) AS f
+    WHERE f->>'field' = 'page'
+$$;
+
+-- hit_matches_filters reports whether a hit, given as the JSON of its row,
+-- passes every filter on its own values. It is used for hits without a
+-- visit, which are treated as a visit of their own.
+CREATE FUNCTION hit_matches_filters(hit jsonb, filters jsonb) RETURNS boolean
+LANGUAGE sql IMMUTABLE AS $$
+    SELECT coalesce(bool_and(filter_matches(hit->>(CASE f->>'field'
+        WHEN 'page' THEN 'url'
+        WHEN 'referrer' THEN 'referrer_source'
+        WHEN 'country' THEN 'country_code'
+        WHEN 'event' THEN 'event_name'
+        ELSE f->>'field'
+    END), f->>'op', f->>'value')), true)
+    FROM jsonb_array_elements(filters) AS f
+$$;
+
+-- filtered_pageviews returns the pageviews of a website in a period that pass
+-- a JSON array of filters. A pageview in a visit passes when the visit
+-- passes and its own page passes the page filters; one without a visit has
+-- to pass every filter on its own values. Queries filter with
+-- id IN (SELECT filtered_pageviews(...)) so the set is built once.
+CREATE FUNCTION filtered_pageviews(website uuid, since timestamptz, until timestamptz, filters jsonb)
+RETURNS SETOF uuid
+LANGUAGE sql STABLE AS $$
+    SELECT p.id
+    FROM pageviews p
+    WHERE p.website_id = website
+      AND p.created_at BETWEEN since AND until
+      AND CASE
+          WHEN p.session_id IS NULL THEN hit_matches_filters(to_jsonb(p), filters)
+          ELSE page_matches_filters(p.url, filters)
+              AND p.session_id IN (SELECT filtered_visits(website, since, until, filters, false))
+      END
+$$;
+
+-- filtered_events is filtered_pageviews for events.
+CREATE FUNCTION filtered_events(website uuid, since timestamptz, until timestamptz, filters jsonb)
+RETURNS SETOF uuid
+LANGUAGE sql STABLE AS $$
+    SELECT e.id
+    FROM events e
+    WHERE e.website_id = website
+      AND e.created_at BETWEEN since AND until
+      AND CASE
+          WHEN e.session_id IS NULL THEN hit_matches_filters(to_jsonb(e), filters)
+          ELSE page_matches_filters(e.url, filters)
+              AND e.session_id IN (SELECT filtered_visits(website, since, until, filters, false))
+      END
+$$;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+DROP FUNCTION IF EXISTS filtered_events(uuid, timestamptz, timestamptz, jsonb);
+DROP FUNCTION IF EXISTS filtered_pageviews(uuid, timestamptz, timestamptz, jsonb);
+DROP FUNCTION IF EXISTS hit_matches_filters(jsonb, jsonb);
+DROP FUNCTION IF EXISTS page_matches_filters(text, jsonb);
+DROP FUNCTION IF EXISTS filtered_visits(uuid, timestamptz, timestamptz, jsonb, boolean);
+DROP FUNCTION IF EXISTS filter_matches(text, text, text);
+-- +goose StatementEnd
@@ -0,0 +1,28 @@
+-- name: UpsertDroppedHits :exec
+insert into
+    dropped_hits (website_id, day, reason, hits)
+select
+    website_id, day, reason, hits
+from (
+    select
+        unnest(sqlc.arg('website_ids')::uuid[]) as website_id,
+        unnest(sqlc.arg('days')::date[]) as day,
+        unnest(sqlc.arg('reasons')::text[]) as reason,
+        unnest(sqlc.arg('hits')::bigint[]) as hits
+) as batch
+on conflict (website_id, day, reason)
+do update set hits = dropped_hits.hits + excluded.hits;
+
+-- name: QueryDroppedHitTotals :many
+select reason, sum(hits)::bigint as hits
+from dropped_hits
+where website_id = $1
+  and day >= sqlc.arg('since')::date
+group by reason order by hits desc;
+
+-- name: QueryDroppedHitsForReason :one
+select coalesce(sum(hits), 0)::bigint as hits
+from dropped_hits
+where website_id = $1
+  and reason = $2
+  and day between sqlc.arg('start_date')::date and sqlc.arg('end_date')::date;
@@ -5,17 +5,60 @@ values
     ($1, now(), $2, $3, $4, $5, $6, $7, $8, $9, $10)
 returning *;
 
+-- name: InsertEvents :execrows
+insert into
+    events (id, created_at, website_id, url, event_name, event_data, visitor_hash, country_code, country_name, city, region, session_id, hostname, language)
+select
+    id, created_at, website_id, url, event_name, event_data,
+    nullif(visitor_hash, ''), nullif(country_code, ''), nullif(country_name, ''), nullif(city, ''), nullif(region, ''),
+    nullif(session_id, '00000000-0000-0000-0000-000000000000'::uuid), nullif(hostname, ''), nullif(language, '')
+from (
+    select
+        unnest(sqlc.arg('ids')::uuid[]) as id,
+        unnest(sqlc.arg('created_ats')::timestamptz[]) as created_at,
+        unnest(sqlc.arg('website_ids')::uuid[]) as website_id,
+        unnest(sqlc.arg('urls')::text[]) as url,
+        unnest(sqlc.arg('event_names')::text[]) as event_name,
+        unnest(sqlc.arg('event_datas')::jsonb[]) as event_data,
+        unnest(sqlc.arg('visitor_hashes')::text[]) as visitor_hash,
+        unnest(sqlc.arg('country_codes')::text[]) as country_code,
+        unnest(sqlc.arg('country_names')::text[]) as country_name,
+        unnest(sqlc.arg('cities')::text[]) as city,
+        unnest(sqlc.arg('regions')::text[]) as region,
+        unnest(sqlc.arg('session_ids')::uuid[]) as session_id,
+        unnest(sqlc.arg('hostnames')::text[]) as hostname,
+        unnest(sqlc.arg('languages')::text[]) as language
+) as batch;
+
 -- name: QueryTopEvents :many
 select event_name, count(*)::bigint as event_count
 from events
 where website_id = $1
   and created_at between sqlc.arg('start_date')::timestamptz and sqlc.arg('end_date')::timestamptz
+  and (sqlc.narg('hostname')::text is null or hostname = sqlc.narg('hostname'))
+  and (sqlc.narg('language')::text is null or language = sqlc.narg('language') or language like sqlc.narg('language') || '-%')
+  and (sqlc.narg('filters')::jsonb is null or id in (select filtered_events($1, sqlc.arg('start_date')::timestamptz, sqlc.arg('end_date')::timestamptz, sqlc.narg('filters'))))
 group by event_name order by event_count desc limit 10;
 
 -- name: QueryEventsTimeBucketed :many
-select date_trunc(sqlc.arg('bucket')::text, created_at)::timestamptz as bucket_time,
+select date_trunc(sqlc.arg('bucket')::text, created_at, sqlc.arg('timezone')::text)::timestamptz as bucket_time,
        count(*)::bigint as event_count
 from events
 where website_id = $1
   and created_at between sqlc.arg('start_date')::timestamptz and sqlc.arg('end_date')::timestamptz
+  and (sqlc.narg('hostname')::text is null or hostname = sqlc.narg('hostname'))
+  and (sqlc.narg('language')::text is null or language = sqlc.narg('language') or language like sqlc.narg('language') || '-%')
+  and (sqlc.narg('filters')::jsonb is null or id in (select filtered_events($1, sqlc.arg('start_date')::timestamptz, sqlc.arg('end_date')::timestamptz, sqlc.narg('filters'))))
 group by bucket_time order by bucket_time;
+
+-- name: QueryEventPropertyBreakdown :many
+select (event_data->>sqlc.arg('property')::text)::text as value, count(*)::bigint as event_count
+from events
+where website_id = $1
+  and event_name = sqlc.arg('event_name')::text
+  and event_data->>sqlc.arg('property')::text is not null
+  and created_at between sqlc.arg('start_date')::timestamptz and sqlc.arg('end_date')::timestamptz
+  and (sqlc.narg('hostname')::text is null or hostname = sqlc.narg('hostname'))
+  and (sqlc.narg('language')::text is null or language = sqlc.narg('language') or language like sqlc.narg('language') || '-%')
+  and (sqlc.narg('filters')::jsonb is null or id in (select filtered_events($1, sqlc.arg('start_date')::timestamptz, sqlc.arg('end_date')::timestamptz, sqlc.narg('filters'))))
+group by value order by event_count desc limit 10;
@@ -5,6 +5,57 @@ values
     ($1, now(), $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
 returning *;
 
+-- name: InsertPageviews :execrows
+insert into
+    pageviews (id, created_at, website_id, url, referrer, browser, os, device, country, language, screen_width, visitor_hash, country_code, country_name, city, region, session_id,
+               utm_source, utm_medium, utm_campaign, utm_term, utm_content, click_id, referrer_source, page_group, hostname, browser_version, os_version, device_brand, device_model,
+    viewport_width, pixel_ratio, screen_size)
+select
+    id, created_at, website_id, url,
+    nullif(referrer, ''), nullif(browser, ''), nullif(os, ''), nullif(device, ''), nullif(country, ''), nullif(language, ''),
+    nullif(screen_width, 0), nullif(visitor_hash, ''), nullif(country_code, ''), nullif(country_name, ''), nullif(city, ''), nullif(region, ''),
+    nullif(session_id, '00000000-0000-0000-0000-000000000000'::uuid),
+    nullif(utm_source, ''), nullif(utm_medium, ''), nullif(utm_campaign, ''), nullif(utm_term, ''), nullif(utm_content, ''), nullif(click_id, ''),
+    nullif(referrer_source, ''), nullif(page_group, ''), nullif(hostname, ''),
+    nullif(browser_version, ''), nullif(os_version, ''), nullif(device_brand, ''), nullif(device_model, ''),
+    nullif(viewport_width, 0), nullif(pixel_ratio, 0), nullif(screen_size, '')
+from (
+    select
+        unnest(sqlc.arg('ids')::uuid[]) as id,
+        unnest(sqlc.arg('created_ats')::timestamptz[]) as created_at,
+        unnest(sqlc.arg('website_ids')::uuid[]) as website_id,
+        unnest(sqlc.arg('urls')::text[]) as url,
+        unnest(sqlc.arg('referrers')::text[]) as referrer,
+        unnest(sqlc.arg('browsers')::text[]) as browser,
+        unnest(sqlc.arg('oses')::text[]) as os,
+        unnest(sqlc.arg('devices')::text[]) as device,
+        unnest(sqlc.arg('countries')::text[]) as country,
+        unnest(sqlc.arg('languages')::text[]) as language,
+        unnest(sqlc.arg('screen_widths')::int[]) as screen_width,
+        unnest(sqlc.arg('visitor_hashes')::text[]) as visitor_hash,
+        unnest(sqlc.arg('country_codes')::text[]) as country_code,
+        unnest(sqlc.arg('country_names')::text[]) as country_name,
+        unnest(sqlc.arg('cities')::text[]) as city,
+        unnest(sqlc.arg('regions')::text[]) as region,
+        unnest(sqlc.arg('session_ids')::uuid[]) as session_id,
+        unnest(sqlc.arg('utm_sources')::text[]) as utm_source,
+        unnest(sqlc.arg('utm_mediums')::text[]) as utm_medium,
+        unnest(sqlc.arg('utm_campaigns')::text[]) as utm_campaign,
+        unnest(sqlc.arg('utm_terms')::text[]) as utm_term,
+        unnest(sqlc.arg('utm_contents')::text[]) as utm_content,
+        unnest(sqlc.arg('click_ids')::text[]) as click_id,
+        unnest(sqlc.arg('referrer_sources')::text[]) as referrer_source,
+        unnest(sqlc.arg('page_groups')::text[]) as page_group,
+        unnest(sqlc.arg('hostnames')::text[]) as hostname,
+        unnest(sqlc.arg('browser_versions')::text[]) as browser_version,
+        unnest(sqlc.arg('os_versions')::text[]) as os_version,
+        unnest(sqlc.arg('device_brands')::text[]) as device_brand,
+        unnest(sqlc.arg('device_models')::text[]) as device_model,
+        unnest(sqlc.arg('viewport_widths')::int[]) as viewport_width,
+        unnest(sqlc.arg('pixel_ratios')::real[]) as pixel_ratio,
+        unnest(sqlc.arg('screen_sizes')::text[]) as screen_size
+) as batch;
+
 -- name: QueryPageviewsPerDay :many
 select date_trunc('day', created_at)::timestamptz as date, count(*)::bigint as views
 from pageviews
@@ -14,53 +65,149 @@ group by date order by date;
 -- name: QueryTotalPageviews :one
 select count(*)::bigint as total
 from pageviews
-where website_id = $1 and created_at between sqlc.arg('start_date')::timestamptz and sqlc.arg('end_date')::timestamptz;
+where website_id = $1 and created_at between sqlc.arg('start_date')::timestamptz and sqlc.arg('end_date')::timestamptz
+  and (sqlc.narg('hostname')::text is null or hostname = sqlc.narg('hostname'))
+  and (sqlc.narg('language')::text is null or language = sqlc.narg('language') or language like sqlc.narg('language') || '-%')
+  and (sqlc.narg('filters')::jsonb is null or id in (select filtered_pageviews($1, sqlc.arg('start_date')::timestamptz, sqlc.arg('end_date')::timestamptz, sqlc.narg('filters'))));
 
 -- name: QueryTopPages :many
+-- Pages matching one of the website's path patterns are counted under the
+-- pattern.
+select coalesce(page_group, url)::text as page, (page_group is not null)::bool as grouped, count(*)::bigint as views
+from pageviews
+where website_id = $1 and created_at between sqlc.arg('start_date')::timestamptz and sqlc.arg('end_date')::timestamptz
+  and (sqlc.narg('hostname')::text is null or hostname = sqlc.narg('hostname'))
+  and (sqlc.narg('language')::text is null or language = sqlc.narg('language') or language like sqlc.narg('language') || '-%')
+  and (sqlc.narg('filters')::jsonb is null or id in (select filtered_pageviews($1, sqlc.arg('start_date')::timestamptz, sqlc.arg('end_date')::timestamptz, sqlc.narg('filters'))))
+group by page, grouped order by views desc limit 10;
+
+-- name: QueryTopPagesInGroup :many
 select url, count(*)::bigint as views
 from pageviews
 where website_id = $1 and created_at between sqlc.arg('start_date')::timestamptz and sqlc.arg('end_date')::timestamptz
+  and (sqlc.narg('hostname')::text is null or hostname = sqlc.narg('hostname'))
+  and (sqlc.narg('language')::text is null or language = sqlc.narg('language') or language like sqlc.narg('language') || '-%')
+  and (sqlc.narg('filters')::jsonb is null or id in (select filtered_pageviews($1, sqlc.arg('start_date')::timestamptz, sqlc.arg('end_date')::timestamptz, sqlc.narg('filters'))))
+  and page_group = sqlc.arg('page_group')::text
 group by url order by views desc limit 10;
 
--- name: QueryTopReferrers :many
-select referrer, count(*)::bigint as views
+-- name: QueryTopHostnames :many
+select hostname, count(*)::bigint as views
 from pageviews
 where website_id = $1 and created_at between sqlc.arg('start_date')::timestamptz and sqlc.arg('end_date')::timestamptz
-  and referrer is not null and referrer != ''
-group by referrer order by views desc limit 10;
+  and (sqlc.narg('language')::text is null or language = sqlc.narg('language') or language like sqlc.narg('language') || '-%')
+  and (sqlc.narg('filters')::jsonb is null or id in (select filtered_pageviews($1, sqlc.arg('start_date')::timestamptz, sqlc.arg('end_date')::timestamptz, sqlc.narg('filters'))))
+  and hostname is not null
+group by hostname order by views desc limit 10;
 
 -- name: QueryBrowserBreakdown :many
 select browser, count(*)::bigint as views
 from pageviews
 where website_id = $1 and created_at between sqlc.arg('start_date')::timestamptz and sqlc.arg('end_date')::timestamptz
+  and (sqlc.narg('hostname')::text is null or hostname = sqlc.narg('hostname'))
+  and (sqlc.narg('language')::text is null or language = sqlc.narg('language') or language like sqlc.narg('language') || '-%')
+  and (sqlc.narg('filters')::jsonb is null or id in (select filtered_pageviews($1, sqlc.arg('start_date')::timestamptz, sqlc.arg('end_date')::timestamptz, sqlc.narg('filters'))))
 group by browser order by views desc;
 
 -- name: QueryOSBreakdown :many
 select os, count(*)::bigint as views
 from pageviews
 where website_id = $1 and created_at between sqlc.arg('start_date')::timestamptz and sqlc.arg('end_date')::timestamptz
+  and (sqlc.narg('hostname')::text is null or hostname = sqlc.narg('hostname'))
+  and (sqlc.narg('language')::text is null or language = sqlc.narg('language') or language like sqlc.narg('language') || '-%')
+  and (sqlc.narg('filters')::jsonb is null or id in (select filtered_pageviews($1, sqlc.arg('start_date')::timestamptz, sqlc.arg('end_date')::timestamptz, sqlc.narg('filters'))))
 group by os order by views desc;
 
 -- name: QueryDeviceBreakdown :many
 select device, count(*)::bigint as views
 from pageviews
 where website_id = $1 and created_at between sqlc.arg('start_date')::timestamptz and sqlc.arg('end_date')::timestamptz
+  and (sqlc.narg('hostname')::text is null or hostname = sqlc.narg('hostname'))
+  and (sqlc.narg('language')::text is null or language = sqlc.narg('language') or language like sqlc.narg('language') || '-%')
+  and (sqlc.narg('filters')::jsonb is null or id in (select filtered_pageviews($1, sqlc.arg('start_date')::timestamptz, sqlc.arg('end_date')::timestamptz, sqlc.narg('filters'))))
 group by device order by views desc;
 
+-- name: QueryLanguageBreakdown :many
+-- Locales are counted under their language, en-US and en-GB as en.
+select split_part(language, '-', 1)::text as language, count(*)::bigint as views
+from pageviews
+where website_id = $1 and created_at between sqlc.arg('start_date')::timestamptz and sqlc.arg('end_date')::timestamptz
+  and (sqlc.narg('hostname')::text is null or hostname = sqlc.narg('hostname'))
+  and (sqlc.narg('language')::text is null or language = sqlc.narg('language') or language like sqlc.narg('language') || '-%')
+  and (sqlc.narg('filters')::jsonb is null or id in (select filtered_pageviews($1, sqlc.arg('start_date')::timestamptz, sqlc.arg('end_date')::timestamptz, sqlc.narg('filters'))))
+  and language is not null
+group by 1 order by views desc limit 10;
+
+-- name: QueryLocaleBreakdown :many
+select language, count(*)::bigint as views
+from pageviews
+where website_id = $1 and created_at between sqlc.arg('start_date')::timestamptz and sqlc.arg('end_date')::timestamptz
+  and (sqlc.narg('hostname')::text is null or hostname = sqlc.narg('hostname'))
+  and (sqlc.narg('language')::text is null or language = sqlc.narg('language') or language like sqlc.narg('language') || '-%')
+  and (sqlc.narg('filters')::jsonb is null or id in (select filtered_pageviews($1, sqlc.arg('start_date')::timestamptz, sqlc.arg('end_date')::timestamptz, sqlc.narg('filters'))))
+  and language is not null
+group by language order by views desc limit 10;
+
+-- name: QueryScreenSizeBreakdown :many
+select screen_size, count(*)::bigint as views
+from pageviews
+where website_id = $1 and created_at between sqlc.arg('start_date')::timestamptz and sqlc.arg('end_date')::timestamptz
+  and (sqlc.narg('hostname')::text is null or hostname = sqlc.narg('hostname'))
+  and (sqlc.narg('language')::text is null or language = sqlc.narg('language') or language like sqlc.narg('language') || '-%')
+  and (sqlc.narg('filters')::jsonb is null or id in (select filtered_pageviews($1, sqlc.arg('start_date')::timestamptz, sqlc.arg('end_date')::timestamptz, sqlc.narg('filters'))))
+  and screen_size is not null
+group by screen_size order by views desc;
+
+-- name: QueryBrowserVersionBreakdown :many
+select coalesce(browser_version, '')::text as version, count(*)::bigint as views
+from pageviews
+where website_id = $1 and created_at between sqlc.arg('start_date')::timestamptz and sqlc.arg('end_date')::timestamptz
+  and (sqlc.narg('hostname')::text is null or hostname = sqlc.narg('hostname'))
+  and (sqlc.narg('language')::text is null or language = sqlc.narg('language') or language like sqlc.narg('language') || '-%')
+  and (sqlc.narg('filters')::jsonb is null or id in (select filtered_pageviews($1, sqlc.arg('start_date')::timestamptz, sqlc.arg('end_date')::timestamptz, sqlc.narg('filters'))))
+  and browser = sqlc.arg('browser')::text
+group by version order by views desc limit 10;
+
+-- name: QueryOSVersionBreakdown :many
+select coalesce(os_version, '')::text as version, count(*)::bigint as views
+from pageviews
+where website_id = $1 and created_at between sqlc.arg('start_date')::timestamptz and sqlc.arg('end_date')::timestamptz
+  and (sqlc.narg('hostname')::text is null or hostname = sqlc.narg('hostname'))
+  and (sqlc.narg('language')::text is null or language = sqlc.narg('language') or language like sqlc.narg('language') || '-%')
+  and (sqlc.narg('filters')::jsonb is null or id in (select filtered_pageviews($1, sqlc.arg('start_date')::timestamptz, sqlc.arg('end_date')::timestamptz, sqlc.narg('filters'))))
+  and os = sqlc.arg('os')::text
+group by version order by views desc limit 10;
+
+-- name: QueryDeviceModelBreakdown :many
+select concat_ws(' ', device_brand, device_model)::text as model, count(*)::bigint as views
+from pageviews
+where website_id = $1 and created_at between sqlc.arg('start_date')::timestamptz and sqlc.arg('end_date')::timestamptz
+  and (sqlc.narg('hostname')::text is null or hostname = sqlc.narg('hostname'))
+  and (sqlc.narg('language')::text is null or language = sqlc.narg('language') or language like sqlc.narg('language') || '-%')
+  and (sqlc.narg('filters')::jsonb is null or id in (select filtered_pageviews($1, sqlc.arg('start_date')::timestamptz, sqlc.arg('end_date')::timestamptz, sqlc.narg('filters'))))
+  and device = sqlc.arg('device')::text
+group by model order by views desc limit 10;
+
 -- name: QueryPageviewsTimeBucketed :many
-select date_trunc(sqlc.arg('bucket')::text, created_at)::timestamptz as bucket_time,
+select date_trunc(sqlc.arg('bucket')::text, created_at, sqlc.arg('timezone')::text)::timestamptz as bucket_time,
        count(*)::bigint as views
 from pageviews
 where website_id = $1
   and created_at between sqlc.arg('start_date')::timestamptz and sqlc.arg('end_date')::timestamptz
+  and (sqlc.narg('hostname')::text is null or hostname = sqlc.narg('hostname'))
+  and (sqlc.narg('language')::text is null or language = sqlc.narg('language') or language like sqlc.narg('language') || '-%')
+  and (sqlc.narg('filters')::jsonb is null or id in (select filtered_pageviews($1, sqlc.arg('start_date')::timestamptz, sqlc.arg('end_date')::timestamptz, sqlc.narg('filters'))))
 group by bucket_time order by bucket_time;
 
 -- name: QueryUniqueVisitorsTimeBucketed :many
-select date_trunc(sqlc.arg('bucket')::text, created_at)::timestamptz as bucket_time,
+select date_trunc(sqlc.arg('bucket')::text, created_at, sqlc.arg('timezone')::text)::timestamptz as bucket_time,
        count(distinct visitor_hash)::bigint as visitors
 from pageviews
 where website_id = $1
   and created_at between sqlc.arg('start_date')::timestamptz and sqlc.arg('end_date')::timestamptz
+  and (sqlc.narg('hostname')::text is null or hostname = sqlc.narg('hostname'))
+  and (sqlc.narg('language')::text is null or language = sqlc.narg('language') or language like sqlc.narg('language') || '-%')
+  and (sqlc.narg('filters')::jsonb is null or id in (select filtered_pageviews($1, sqlc.arg('start_date')::timestamptz, sqlc.arg('end_date')::timestamptz, sqlc.narg('filters'))))
   and visitor_hash is not null
 group by bucket_time order by bucket_time;
 
@@ -69,6 +216,9 @@ select count(distinct visitor_hash)::bigint as total
 from pageviews
 where website_id = $1
   and created_at between sqlc.arg('start_date')::timestamptz and sqlc.arg('end_date')::timestamptz
+  and (sqlc.narg('hostname')::text is null or hostname = sqlc.narg('hostname'))
+  and (sqlc.narg('language')::text is null or language = sqlc.narg('language') or language like sqlc.narg('language') || '-%')
+  and (sqlc.narg('filters')::jsonb is null or id in (select filtered_pageviews($1, sqlc.arg('start_date')::timestamptz, sqlc.arg('end_date')::timestamptz, sqlc.narg('filters'))))
   and visitor_hash is not null;
 
 -- name: QueryTopCountries :many
@@ -76,6 +226,9 @@ select country_code, country_name, count(*)::bigint as views
 from pageviews
 where website_id = $1
   and created_at between sqlc.arg('start_date')::timestamptz and sqlc.arg('end_date')::timestamptz
+  and (sqlc.narg('hostname')::text is null or hostname = sqlc.narg('hostname'))
+  and (sqlc.narg('language')::text is null or language = sqlc.narg('language') or language like sqlc.narg('language') || '-%')
+  and (sqlc.narg('filters')::jsonb is null or id in (select filtered_pageviews($1, sqlc.arg('start_date')::timestamptz, sqlc.arg('end_date')::timestamptz, sqlc.narg('filters'))))
   and country_code is not null and country_code != ''
 group by country_code, country_name order by views desc limit 10;
 
@@ -84,17 +237,54 @@ select city, country_code, count(*)::bigint as views
 from pageviews
 where website_id = $1
   and created_at between sqlc.arg('start_date')::timestamptz and sqlc.arg('end_date')::timestamptz
+  and (sqlc.narg('hostname')::text is null or hostname = sqlc.narg('hostname'))
+  and (sqlc.narg('language')::text is null or language = sqlc.narg('language') or language like sqlc.narg('language') || '-%')
+  and (sqlc.narg('filters')::jsonb is null or id in (select filtered_pageviews($1, sqlc.arg('start_date')::timestamptz, sqlc.arg('end_date')::timestamptz, sqlc.narg('filters'))))
   and city is not null and city != ''
 group by city, country_code order by views desc limit 10;
 
--- name: QueryBounceCount :one
-SELECT count(*)::bigint AS bounce_visitors
-FROM (
-    SELECT visitor_hash
-    FROM pageviews
-    WHERE website_id = $1
-      AND created_at BETWEEN sqlc.arg('start_date')::timestamptz AND sqlc.arg('end_date')::timestamptz
-      AND visitor_hash IS NOT NULL
-    GROUP BY visitor_hash
-    HAVING count(*) = 1
-) AS single_page_visitors;
+-- name: UpdatePageviewEngagement :execrows
+-- Engagement hits carry running totals for a page, so each one is applied to
+-- the visitor's latest pageview of that URL and only ever raises the values.
+-- Several hits in a batch can resolve to the same pageview; the update takes
+-- the largest of them, as Postgres would otherwise apply an arbitrary one.
+update pageviews p
+set engaged_ms = greatest(coalesce(p.engaged_ms, 0), e.engaged_ms),
+    scroll_depth = greatest(coalesce(p.scroll_depth, 0), e.scroll_depth)
+from (
+    select latest.id, max(latest.engaged_ms)::int as engaged_ms, max(latest.scroll_depth)::smallint as scroll_depth
+    from (
+        select distinct on (batch.position) pv.id, batch.engaged_ms, batch.scroll_depth
+        from (
+            select
+                unnest(sqlc.arg('positions')::int[]) as position,
+                unnest(sqlc.arg('website_ids')::uuid[]) as website_id,
+                unnest(sqlc.arg('visitor_hashes')::text[]) as visitor_hash,
+                unnest(sqlc.arg('urls')::text[]) as url,
+                unnest(sqlc.arg('sent_ats')::timestamptz[]) as sent_at,
+                unnest(sqlc.arg('engaged_ms')::int[]) as engaged_ms,
+                unnest(sqlc.arg('scroll_depths')::smallint[]) as scroll_depth
+        ) as batch
+        join pageviews pv
+          on pv.website_id = batch.website_id
+         and pv.visitor_hash = batch.visitor_hash
+         and pv.url = batch.url
+         and pv.created_at between batch.sent_at - interval '1 day' and batch.sent_at
+        order by batch.position, pv.created_at desc
+    ) as latest
+    group by latest.id
+) as e
+where p.id = e.id;
+
+-- name: QueryPageEngagement :many
+select url,
+       count(*)::bigint as views,
+       coalesce(avg(engaged_ms), 0)::float8 as avg_engaged_ms,
+       coalesce(avg(scroll_depth), 0)::float8 as avg_scroll_depth
+from pageviews
+where website_id = $1
+  and created_at between sqlc.arg('start_date')::timestamptz and sqlc.arg('end_date')::timestamptz
+  and (sqlc.narg('hostname')::text is null or hostname = sqlc.narg('hostname'))
+  and (sqlc.narg('language')::text is null or language = sqlc.narg('language') or language like sqlc.narg('language') || '-%')
+  and (sqlc.narg('filters')::jsonb is null or id in (select filtered_pageviews($1, sqlc.arg('start_date')::timestamptz, sqlc.arg('end_date')::timestamptz, sqlc.narg('filters'))))
+group by url order by views desc limit 10;
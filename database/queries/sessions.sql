@@ -0,0 +1,173 @@
+-- name: LockSessionVisitors :exec
+-- Serialises session assignment per visitor until the transaction ends.
+-- Locks are taken in key order so concurrent batches can't deadlock.
+select pg_advisory_xact_lock(keys.key)
+from (
+    select distinct hashtext(visitors.website_id::text || visitors.visitor_hash) as key
+    from (
+        select
+            unnest(sqlc.arg('website_ids')::uuid[]) as website_id,
+            unnest(sqlc.arg('visitor_hashes')::text[]) as visitor_hash
+    ) as visitors
+    order by key
+) as keys;
+
+-- name: QueryRecentSessions :many
+-- Returns the latest session of each visitor that ended after since.
+select distinct on (s.website_id, s.visitor_hash) s.*
+from sessions s
+join (
+    select
+        unnest(sqlc.arg('website_ids')::uuid[]) as website_id,
+        unnest(sqlc.arg('visitor_hashes')::text[]) as visitor_hash
+) as visitors on visitors.website_id = s.website_id and visitors.visitor_hash = s.visitor_hash
+where s.ended_at >= sqlc.arg('since')::timestamptz
+order by s.website_id, s.visitor_hash, s.ended_at desc;
+
+-- name: UpsertSessions :exec
+-- Counts are added to existing sessions, and the boundaries only move
+-- outwards, so concurrent writers to the same session can't lose each other's
+-- hits. Writers must hold LockSessionVisitors so they agree on which session
+-- a visitor's hits belong to.
+insert into
+    sessions (id, website_id, visitor_hash, started_at, ended_at, entry_page, exit_page, pageviews, events, referrer, utm_source, utm_medium, utm_campaign, utm_term, utm_content, click_id, referrer_source, channel, hostname, language)
+select
+    id, website_id, visitor_hash, started_at, ended_at,
+    nullif(entry_page, ''), nullif(exit_page, ''), pageviews, events,
+    nullif(referrer, ''), nullif(utm_source, ''), nullif(utm_medium, ''), nullif(utm_campaign, ''), nullif(utm_term, ''), nullif(utm_content, ''), nullif(click_id, ''),
+    nullif(referrer_source, ''), nullif(channel, ''), nullif(hostname, ''), nullif(language, '')
+from (
+    select
+        unnest(sqlc.arg('ids')::uuid[]) as id,
+        unnest(sqlc.arg('website_ids')::uuid[]) as website_id,
+        unnest(sqlc.arg('visitor_hashes')::text[]) as visitor_hash,
+        unnest(sqlc.arg('started_ats')::timestamptz[]) as started_at,
+        unnest(sqlc.arg('ended_ats')::timestamptz[]) as ended_at,
+        unnest(sqlc.arg('entry_pages')::text[]) as entry_page,
+        unnest(sqlc.arg('exit_pages')::text[]) as exit_page,
+        unnest(sqlc.arg('pageview_counts')::int[]) as pageviews,
+        unnest(sqlc.arg('event_counts')::int[]) as events,
+        unnest(sqlc.arg('referrers')::text[]) as referrer,
+        unnest(sqlc.arg('utm_sources')::text[]) as utm_source,
+        unnest(sqlc.arg('utm_mediums')::text[]) as utm_medium,
+        unnest(sqlc.arg('utm_campaigns')::text[]) as utm_campaign,
+        unnest(sqlc.arg('utm_terms')::text[]) as utm_term,
+        unnest(sqlc.arg('utm_contents')::text[]) as utm_content,
+        unnest(sqlc.arg('click_ids')::text[]) as click_id,
+        unnest(sqlc.arg('referrer_sources')::text[]) as referrer_source,
+        unnest(sqlc.arg('channels')::text[]) as channel,
+        unnest(sqlc.arg('hostnames')::text[]) as hostname,
+        unnest(sqlc.arg('languages')::text[]) as language
+) as batch
+on conflict (id) do update set
+    entry_page = case
+        when excluded.entry_page is not null and (sessions.entry_page is null or excluded.started_at < sessions.started_at)
+        then excluded.entry_page else sessions.entry_page end,
+    hostname = case
+        when excluded.hostname is not null and (sessions.hostname is null or excluded.started_at < sessions.started_at)
+        then excluded.hostname else sessions.hostname end,
+    language = coalesce(sessions.language, excluded.language),
+    exit_page = case
+        when excluded.exit_page is not null and (sessions.exit_page is null or excluded.ended_at >= sessions.ended_at)
+        then excluded.exit_page else sessions.exit_page end,
+    started_at = least(sessions.started_at, excluded.started_at),
+    ended_at = greatest(sessions.ended_at, excluded.ended_at),
+    pageviews = sessions.pageviews + excluded.pageviews,
+    events = sessions.events + excluded.events;
+
+-- name: QuerySessionTotals :one
+-- Sessions made up of events alone, such as server-side hits, are not
+-- visits and are left out.
+select
+    count(*)::bigint as sessions,
+    count(*) filter (where pageviews = 1)::bigint as bounces,
+    coalesce(avg(extract(epoch from ended_at - started_at)), 0)::float8 as avg_duration_seconds
+from sessions
+where website_id = $1
+  and started_at between sqlc.arg('start_date')::timestamptz and sqlc.arg('end_date')::timestamptz
+  and (sqlc.narg('hostname')::text is null or hostname = sqlc.narg('hostname'))
+  and (sqlc.narg('language')::text is null or language = sqlc.narg('language') or language like sqlc.narg('language') || '-%')
+  and (sqlc.narg('filters')::jsonb is null or id in (select filtered_visits($1, sqlc.arg('start_date')::timestamptz, sqlc.arg('end_date')::timestamptz, sqlc.narg('filters'), true)))
+  and pageviews > 0;
+
+-- name: QueryTopEntryPages :many
+select entry_page, count(*)::bigint as sessions
+from sessions
+where website_id = $1
+  and started_at between sqlc.arg('start_date')::timestamptz and sqlc.arg('end_date')::timestamptz
+  and (sqlc.narg('hostname')::text is null or hostname = sqlc.narg('hostname'))
+  and (sqlc.narg('language')::text is null or language = sqlc.narg('language') or language like sqlc.narg('language') || '-%')
+  and (sqlc.narg('filters')::jsonb is null or id in (select filtered_visits($1, sqlc.arg('start_date')::timestamptz, sqlc.arg('end_date')::timestamptz, sqlc.narg('filters'), true)))
+  and entry_page is not null
+group by entry_page order by sessions desc limit 10;
+
+-- name: QueryTopExitPages :many
+select exit_page, count(*)::bigint as sessions
+from sessions
+where website_id = $1
+  and started_at between sqlc.arg('start_date')::timestamptz and sqlc.arg('end_date')::timestamptz
+  and (sqlc.narg('hostname')::text is null or hostname = sqlc.narg('hostname'))
+  and (sqlc.narg('language')::text is null or language = sqlc.narg('language') or language like sqlc.narg('language') || '-%')
+  and (sqlc.narg('filters')::jsonb is null or id in (select filtered_visits($1, sqlc.arg('start_date')::timestamptz, sqlc.arg('end_date')::timestamptz, sqlc.narg('filters'), true)))
+  and exit_page is not null
+group by exit_page order by sessions desc limit 10;
+
+-- name: QueryTopUTMSources :many
+select utm_source, count(*)::bigint as sessions
+from sessions
+where website_id = $1
+  and started_at between sqlc.arg('start_date')::timestamptz and sqlc.arg('end_date')::timestamptz
+  and (sqlc.narg('hostname')::text is null or hostname = sqlc.narg('hostname'))
+  and (sqlc.narg('language')::text is null or language = sqlc.narg('language') or language like sqlc.narg('language') || '-%')
+  and (sqlc.narg('filters')::jsonb is null or id in (select filtered_visits($1, sqlc.arg('start_date')::timestamptz, sqlc.arg('end_date')::timestamptz, sqlc.narg('filters'), true)))
+  and pageviews > 0
+  and utm_source is not null
+group by utm_source order by sessions desc limit 10;
+
+-- name: QueryTopUTMMediums :many
+select utm_medium, count(*)::bigint as sessions
+from sessions
+where website_id = $1
+  and started_at between sqlc.arg('start_date')::timestamptz and sqlc.arg('end_date')::timestamptz
+  and (sqlc.narg('hostname')::text is null or hostname = sqlc.narg('hostname'))
+  and (sqlc.narg('language')::text is null or language = sqlc.narg('language') or language like sqlc.narg('language') || '-%')
+  and (sqlc.narg('filters')::jsonb is null or id in (select filtered_visits($1, sqlc.arg('start_date')::timestamptz, sqlc.arg('end_date')::timestamptz, sqlc.narg('filters'), true)))
+  and pageviews > 0
+  and utm_medium is not null
+group by utm_medium order by sessions desc limit 10;
+
+-- name: QueryTopUTMCampaigns :many
+select utm_campaign, count(*)::bigint as sessions
+from sessions
+where website_id = $1
+  and started_at between sqlc.arg('start_date')::timestamptz and sqlc.arg('end_date')::timestamptz
+  and (sqlc.narg('hostname')::text is null or hostname = sqlc.narg('hostname'))
+  and (sqlc.narg('language')::text is null or language = sqlc.narg('language') or language like sqlc.narg('language') || '-%')
+  and (sqlc.narg('filters')::jsonb is null or id in (select filtered_visits($1, sqlc.arg('start_date')::timestamptz, sqlc.arg('end_date')::timestamptz, sqlc.narg('filters'), true)))
+  and pageviews > 0
+  and utm_campaign is not null
+group by utm_campaign order by sessions desc limit 10;
+
+-- name: QueryTopReferrerSources :many
+select referrer_source, count(*)::bigint as sessions
+from sessions
+where website_id = $1
+  and started_at between sqlc.arg('start_date')::timestamptz and sqlc.arg('end_date')::timestamptz
+  and (sqlc.narg('hostname')::text is null or hostname = sqlc.narg('hostname'))
+  and (sqlc.narg('language')::text is null or language = sqlc.narg('language') or language like sqlc.narg('language') || '-%')
+  and (sqlc.narg('filters')::jsonb is null or id in (select filtered_visits($1, sqlc.arg('start_date')::timestamptz, sqlc.arg('end_date')::timestamptz, sqlc.narg('filters'), true)))
+  and pageviews > 0
+  and referrer_source is not null
+group by referrer_source order by sessions desc limit 10;
+
+-- name: QueryTopChannels :many
+select channel, count(*)::bigint as sessions
+from sessions
+where website_id = $1
+  and started_at between sqlc.arg('start_date')::timestamptz and sqlc.arg('end_date')::timestamptz
+  and (sqlc.narg('hostname')::text is null or hostname = sqlc.narg('hostname'))
+  and (sqlc.narg('language')::text is null or language = sqlc.narg('language') or language like sqlc.narg('language') || '-%')
+  and (sqlc.narg('filters')::jsonb is null or id in (select filtered_visits($1, sqlc.arg('start_date')::timestamptz, sqlc.arg('end_date')::timestamptz, sqlc.narg('filters'), true)))
+  and pageviews > 0
+  and channel is not null
+group by channel order by sessions desc;
@@ -0,0 +1,12 @@
+-- name: UpsertVisitorSalt :one
+-- Returns the existing salt when another instance already created one for
+-- the day, so every instance hashes with the same salt.
+insert into
+    visitor_salts (day, created_at, salt)
+values
+    ($1, now(), $2)
+on conflict (day) do update set day=excluded.day
+returning *;
+
+-- name: DeleteVisitorSaltsBefore :execrows
+delete from visitor_salts where day < $1;
@@ -0,0 +1,18 @@
+-- name: QueryWebsiteAPIKeysByWebsiteID :many
+select * from website_api_keys where website_id=$1 order by created_at desc;
+
+-- name: QueryWebsiteAPIKeyByHash :one
+select * from website_api_keys where hash=$1;
+
+-- name: InsertWebsiteAPIKey :one
+insert into
+    website_api_keys (id, created_at, updated_at, website_id, name, prefix, hash)
+values
+    ($1, now(), now(), $2, $3, $4, $5)
+returning *;
+
+-- name: TouchWebsiteAPIKey :exec
+update website_api_keys set last_used_at=now() where id=$1;
+
+-- name: DeleteWebsiteAPIKey :one
+delete from website_api_keys where id=$1 and website_id=$2 returning *;
@@ -6,14 +6,17 @@ select * from websites where user_id=$1 order by created_at desc;
 
 -- name: InsertWebsite :one
 insert into
-    websites (id, created_at, updated_at, user_id, name, domain)
+    websites (id, created_at, updated_at, user_id, name, domain, allowed_hostnames, allow_localhost, proxy_script_path, proxy_collect_path)
 values
-    ($1, now(), now(), $2, $3, $4)
+    ($1, now(), now(), $2, $3, $4, $5, $6, $7, $8)
 returning *;
 
 -- name: UpdateWebsite :one
 update websites
-    set updated_at=now(), name=$2, domain=$3
+    set updated_at=now(), name=$2, domain=$3, allowed_hostnames=$4, allow_localhost=$5,
+        rate_limit_per_second=$6, rate_limit_burst=$7, proxy_script_path=$8, proxy_collect_path=$9,
+        query_param_mode=$10, query_params=$11, lowercase_paths=$12, strip_trailing_slash=$13, path_patterns=$14,
+        timezone=$15
 where id = $1
 returning *;
 
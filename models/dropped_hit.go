@@ -0,0 +1,82 @@
+package models
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"palantir/internal/storage"
+	"palantir/models/internal/db"
+)
+
+// Reasons a hit can be dropped before it is stored.
+const (
+	DroppedReasonHostname = "hostname"
+	DroppedReasonBot      = "bot"
+	DroppedReasonThrottle = "rate_limited"
+	// DroppedReasonStoreFailed counts hits that were accepted but couldn't
+	// be written to the database.
+	DroppedReasonStoreFailed = "store_failed"
+)
+
+type DroppedHitCount struct {
+	WebsiteID uuid.UUID
+	Day       time.Time
+	Reason    string
+	Hits      int64
+}
+
+// RecordDroppedHits adds the counts to the per-day totals. Each website, day
+// and reason combination may only appear once.
+func RecordDroppedHits(
+	ctx context.Context,
+	exec storage.Executor,
+	counts []DroppedHitCount,
+) error {
+	if len(counts) == 0 {
+		return nil
+	}
+
+	params := db.UpsertDroppedHitsParams{
+		WebsiteIds: make([]uuid.UUID, len(counts)),
+		Days:       make([]pgtype.Date, len(counts)),
+		Reasons:    make([]string, len(counts)),
+		Hits:       make([]int64, len(counts)),
+	}
+	for i, count := range counts {
+		params.WebsiteIds[i] = count.WebsiteID
+		params.Days[i] = pgtype.Date{Time: count.Day, Valid: true}
+		params.Reasons[i] = count.Reason
+		params.Hits[i] = count.Hits
+	}
+
+	return queries.UpsertDroppedHits(ctx, exec, params)
+}
+
+type DroppedHitTotal struct {
+	Reason string
+	Hits   int64
+}
+
+func FindDroppedHitTotals(
+	ctx context.Context,
+	exec storage.Executor,
+	websiteID uuid.UUID,
+	since time.Time,
+) ([]DroppedHitTotal, error) {
+	rows, err := queries.QueryDroppedHitTotals(ctx, exec, db.QueryDroppedHitTotalsParams{
+		WebsiteID: websiteID,
+		Since:     pgtype.Date{Time: since, Valid: true},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	totals := make([]DroppedHitTotal, len(rows))
+	for i, row := range rows {
+		totals[i] = DroppedHitTotal{Reason: row.Reason, Hits: row.Hits}
+	}
+	return totals, nil
+}
@@ -12,6 +12,15 @@ import (
 	"palantir/models/internal/db"
 )
 
+// Events sent by the tracker extensions. The dashboard breaks them down by the
+// property they carry instead of only counting them.
+const (
+	EventOutboundLink   = "Outbound Link: Click"
+	EventFileDownload   = "File Download"
+	EventNotFound       = "404"
+	EventFormSubmission = "Form: Submission"
+)
+
 type Event struct {
 	ID          uuid.UUID
 	CreatedAt   time.Time
@@ -24,9 +33,17 @@ type Event struct {
 	CountryName string
 	City        string
 	Region      string
+	// SessionID is the visit the hit belongs to, if it was sessionized.
+	SessionID uuid.UUID
+	// Hostname is the host of the page the event was sent from.
+	Hostname string
+	Language string
 }
 
 type CreateEventData struct {
+	// CreatedAt is only honoured by CreateEvents, where a zero value falls
+	// back to the time of the insert.
+	CreatedAt   time.Time
 	WebsiteID   uuid.UUID
 	URL         string
 	EventName   string
@@ -36,6 +53,9 @@ type CreateEventData struct {
 	CountryName string
 	City        string
 	Region      string
+	SessionID   uuid.UUID
+	Hostname    string
+	Language    string
 }
 
 func CreateEvent(
@@ -63,6 +83,59 @@ func CreateEvent(
 	return rowToEvent(row), nil
 }
 
+// CreateEvents inserts all events in a single statement and returns the
+// number of rows written.
+func CreateEvents(
+	ctx context.Context,
+	exec storage.Executor,
+	data []CreateEventData,
+) (int64, error) {
+	if len(data) == 0 {
+		return 0, nil
+	}
+
+	now := time.Now()
+	params := db.InsertEventsParams{
+		Ids:           make([]uuid.UUID, len(data)),
+		CreatedAts:    make([]pgtype.Timestamptz, len(data)),
+		WebsiteIds:    make([]uuid.UUID, len(data)),
+		Urls:          make([]string, len(data)),
+		EventNames:    make([]string, len(data)),
+		EventDatas:    make([][]byte, len(data)),
+		VisitorHashes: make([]string, len(data)),
+		CountryCodes:  make([]string, len(data)),
+		CountryNames:  make([]string, len(data)),
+		Cities:        make([]string, len(data)),
+		Regions:       make([]string, len(data)),
+		SessionIds:    make([]uuid.UUID, len(data)),
+		Hostnames:     make([]string, len(data)),
+		Languages:     make([]string, len(data)),
+	}
+	for i, d := range data {
+		createdAt := d.CreatedAt
+		if createdAt.IsZero() {
+			createdAt = now
+		}
+
+		params.Ids[i] = uuid.New()
+		params.CreatedAts[i] = pgtype.Timestamptz{Time: createdAt, Valid: true}
+		params.WebsiteIds[i] = d.WebsiteID
+		params.Urls[i] = d.URL
+		params.EventNames[i] = d.EventName
+		params.EventDatas[i] = d.EventData
+		params.VisitorHashes[i] = d.VisitorHash
+		params.CountryCodes[i] = d.CountryCode
+		params.CountryNames[i] = d.CountryName
+		params.Cities[i] = d.City
+		params.Regions[i] = d.Region
+		params.SessionIds[i] = d.SessionID
+		params.Hostnames[i] = d.Hostname
+		params.Languages[i] = d.Language
+	}
+
+	return queries.InsertEvents(ctx, exec, params)
+}
+
 func rowToEvent(row db.Event) Event {
 	return Event{
 		ID:          row.ID,
@@ -76,5 +149,8 @@ func rowToEvent(row db.Event) Event {
 		CountryName: row.CountryName.String,
 		City:        row.City.String,
 		Region:      row.Region.String,
+		SessionID:   uuid.UUID(row.SessionID.Bytes),
+		Hostname:    row.Hostname.String,
+		Language:    row.Language.String,
 	}
 }
@@ -0,0 +1,3 @@
+package models
+
+var CalendarDate = calendarDate
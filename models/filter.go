@@ -0,0 +1,154 @@
+package models
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+)
+
+// Dashboard filter fields.
+const (
+	FilterPage        = "page"
+	FilterReferrer    = "referrer"
+	FilterCountry     = "country"
+	FilterCity        = "city"
+	FilterBrowser     = "browser"
+	FilterOS          = "os"
+	FilterDevice      = "device"
+	FilterEvent       = "event"
+	FilterUTMSource   = "utm_source"
+	FilterUTMMedium   = "utm_medium"
+	FilterUTMCampaign = "utm_campaign"
+	FilterUTMTerm     = "utm_term"
+	FilterUTMContent  = "utm_content"
+)
+
+// FilterFields lists the fields a dashboard can be filtered on.
+var FilterFields = []string{
+	FilterPage,
+	FilterReferrer,
+	FilterCountry,
+	FilterCity,
+	FilterBrowser,
+	FilterOS,
+	FilterDevice,
+	FilterEvent,
+	FilterUTMSource,
+	FilterUTMMedium,
+	FilterUTMCampaign,
+	FilterUTMTerm,
+	FilterUTMContent,
+}
+
+// Dashboard filter operators. Contains ignores case.
+const (
+	FilterIs       = "is"
+	FilterIsNot    = "is_not"
+	FilterContains = "contains"
+)
+
+// FilterOps lists the operators a filter can use.
+var FilterOps = []string{FilterIs, FilterIsNot, FilterContains}
+
+// maxFilters bounds the filters a dashboard is viewed with, as every one of
+// them is matched against the hits of the period for each breakdown.
+const maxFilters = 10
+
+// Filter limits the dashboard to hits whose field compares to value. Page
+// filters look at the page of each hit; every other field describes the
+// visit, so a pageview counts when any hit of its visit matches. Hits that
+// were stored without a visit are matched on their own values.
+type Filter struct {
+	Field string `json:"field"`
+	Op    string `json:"op"`
+	Value string `json:"value"`
+}
+
+// ParseFilter reads a filter written as field:op:value, the form it takes
+// in dashboard links.
+func ParseFilter(raw string) (Filter, bool) {
+	field, rest, ok := strings.Cut(raw, ":")
+	if !ok {
+		return Filter{}, false
+	}
+	op, value, ok := strings.Cut(rest, ":")
+	if !ok {
+		return Filter{}, false
+	}
+
+	filter := Filter{Field: field, Op: op, Value: strings.TrimSpace(value)}
+	if !slices.Contains(FilterFields, filter.Field) ||
+		!slices.Contains(FilterOps, filter.Op) ||
+		filter.Value == "" {
+		return Filter{}, false
+	}
+
+	return filter, true
+}
+
+func (f Filter) String() string {
+	return f.Field + ":" + f.Op + ":" + f.Value
+}
+
+// DashboardFilters are what a dashboard is narrowed down by. Hostname and
+// Language have breakdowns of their own to pick them from; Filters holds
+// everything else, all of which must match.
+type DashboardFilters struct {
+	Hostname string
+	Language string
+	Filters  []Filter
+	// PageGroup lists the URLs of a page group in the top pages breakdown
+	// rather than filtering.
+	PageGroup string
+}
+
+// Add returns the filters with filter added, replacing an earlier filter on
+// the same field with the same operator. Too many filters and the oldest is
+// dropped.
+func (d DashboardFilters) Add(filter Filter) DashboardFilters {
+	filters := slices.DeleteFunc(slices.Clone(d.Filters), func(f Filter) bool {
+		return f.Field == filter.Field && f.Op == filter.Op
+	})
+	filters = append(filters, filter)
+	if len(filters) > maxFilters {
+		filters = filters[len(filters)-maxFilters:]
+	}
+
+	d.Filters = filters
+	return d
+}
+
+// Remove returns the filters without the filter at index i.
+func (d DashboardFilters) Remove(i int) DashboardFilters {
+	d.Filters = slices.Delete(slices.Clone(d.Filters), i, i+1)
+	return d
+}
+
+// Is returns the value of the field's "is" filter, if there is one.
+func (d DashboardFilters) Is(field string) string {
+	for _, f := range d.Filters {
+		if f.Field == field && f.Op == FilterIs {
+			return f.Value
+		}
+	}
+	return ""
+}
+
+// RemoveIs returns the filters without the field's "is" filter.
+func (d DashboardFilters) RemoveIs(field string) DashboardFilters {
+	d.Filters = slices.DeleteFunc(slices.Clone(d.Filters), func(f Filter) bool {
+		return f.Field == field && f.Op == FilterIs
+	})
+	return d
+}
+
+// filtersJSON encodes the filters for the queries, nil when there are none
+// so the check is skipped altogether.
+func (d DashboardFilters) filtersJSON() []byte {
+	if len(d.Filters) == 0 {
+		return nil
+	}
+
+	data, _ := json.Marshal(d.Filters)
+	return data
+}
@@ -0,0 +1,56 @@
+package models_test
+
+import (
+	"slices"
+	"testing"
+
+	"palantir/models"
+)
+
+func TestParseFilter(t *testing.T) {
+	tests := []struct {
+		raw      string
+		expected models.Filter
+		ok       bool
+	}{
+		{raw: "country:is:DE", expected: models.Filter{Field: "country", Op: "is", Value: "DE"}, ok: true},
+		{raw: "page:contains:/blog:2024", expected: models.Filter{Field: "page", Op: "contains", Value: "/blog:2024"}, ok: true},
+		{raw: "utm_source:is_not: google ", expected: models.Filter{Field: "utm_source", Op: "is_not", Value: "google"}, ok: true},
+		{raw: "hostname:is:example.com"},
+		{raw: "country:matches:DE"},
+		{raw: "country:is:"},
+		{raw: "country"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			filter, ok := models.ParseFilter(tt.raw)
+			if ok != tt.ok || filter != tt.expected {
+				t.Errorf("ParseFilter(%q) = %+v, %v, want %+v, %v", tt.raw, filter, ok, tt.expected, tt.ok)
+			}
+		})
+	}
+}
+
+func TestDashboardFiltersAdd(t *testing.T) {
+	filters := models.DashboardFilters{Filters: []models.Filter{
+		{Field: models.FilterCountry, Op: models.FilterIs, Value: "DE"},
+		{Field: models.FilterPage, Op: models.FilterContains, Value: "/blog"},
+	}}
+
+	added := filters.Add(models.Filter{Field: models.FilterCountry, Op: models.FilterIs, Value: "FR"})
+	expected := []models.Filter{
+		{Field: models.FilterPage, Op: models.FilterContains, Value: "/blog"},
+		{Field: models.FilterCountry, Op: models.FilterIs, Value: "FR"},
+	}
+	if !slices.Equal(added.Filters, expected) {
+		t.Errorf("Add() = %+v, want %+v", added.Filters, expected)
+	}
+	if filters.Is(models.FilterCountry) != "DE" {
+		t.Errorf("Add() changed the original filters: %+v", filters.Filters)
+	}
+
+	if removed := added.RemoveIs(models.FilterCountry); len(removed.Filters) != 1 || removed.Is(models.FilterCountry) != "" {
+		t.Errorf("RemoveIs() = %+v", removed.Filters)
+	}
+}
@@ -0,0 +1,140 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: dropped_hits.sql
+
+package db
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const queryDroppedHitTotals = `-- name: QueryDroppedHitTotals :many
+select reason, sum(hits)::bigint as hits
+from dropped_hits
+where website_id = $1
+  and day >= $2::date
+group by reason order by hits desc
+`
+
+type QueryDroppedHitTotalsParams struct {
+	WebsiteID uuid.UUID
+	Since     pgtype.Date
+}
+
+type QueryDroppedHitTotalsRow struct {
+	Reason string
+	Hits   int64
+}
+
+// QueryDroppedHitTotals
+//
+//	select reason, sum(hits)::bigint as hits
+//	from dropped_hits
+//	where website_id = $1
+//	  and day >= $2::date
+//	group by reason order by hits desc
+func (q *Queries) QueryDroppedHitTotals(ctx context.Context, db DBTX, arg QueryDroppedHitTotalsParams) ([]QueryDroppedHitTotalsRow, error) {
+	rows, err := db.Query(ctx, queryDroppedHitTotals, arg.WebsiteID, arg.Since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []QueryDroppedHitTotalsRow
+	for rows.Next() {
+		var i QueryDroppedHitTotalsRow
+		if err := rows.Scan(&i.Reason, &i.Hits); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const queryDroppedHitsForReason = `-- name: QueryDroppedHitsForReason :one
+select coalesce(sum(hits), 0)::bigint as hits
+from dropped_hits
+where website_id = $1
+  and reason = $2
+  and day between $3::date and $4::date
+`
+
+type QueryDroppedHitsForReasonParams struct {
+	WebsiteID uuid.UUID
+	Reason    string
+	StartDate pgtype.Date
+	EndDate   pgtype.Date
+}
+
+// QueryDroppedHitsForReason
+//
+//	select coalesce(sum(hits), 0)::bigint as hits
+//	from dropped_hits
+//	where website_id = $1
+//	  and reason = $2
+//	  and day between $3::date and $4::date
+func (q *Queries) QueryDroppedHitsForReason(ctx context.Context, db DBTX, arg QueryDroppedHitsForReasonParams) (int64, error) {
+	row := db.QueryRow(ctx, queryDroppedHitsForReason,
+		arg.WebsiteID,
+		arg.Reason,
+		arg.StartDate,
+		arg.EndDate,
+	)
+	var hits int64
+	err := row.Scan(&hits)
+	return hits, err
+}
+
+const upsertDroppedHits = `-- name: UpsertDroppedHits :exec
+insert into
+    dropped_hits (website_id, day, reason, hits)
+select
+    website_id, day, reason, hits
+from (
+    select
+        unnest($1::uuid[]) as website_id,
+        unnest($2::date[]) as day,
+        unnest($3::text[]) as reason,
+        unnest($4::bigint[]) as hits
+) as batch
+on conflict (website_id, day, reason)
+do update set hits = dropped_hits.hits + excluded.hits
+`
+
+type UpsertDroppedHitsParams struct {
+	WebsiteIds []uuid.UUID
+	Days       []pgtype.Date
+	Reasons    []string
+	Hits       []int64
+}
+
+// UpsertDroppedHits
+//
+//	insert into
+//	    dropped_hits (website_id, day, reason, hits)
+//	select
+//	    website_id, day, reason, hits
+//	from (
+//	    select
+//	        unnest($1::uuid[]) as website_id,
+//	        unnest($2::date[]) as day,
+//	        unnest($3::text[]) as reason,
+//	        unnest($4::bigint[]) as hits
+//	) as batch
+//	on conflict (website_id, day, reason)
+//	do update set hits = dropped_hits.hits + excluded.hits
+func (q *Queries) UpsertDroppedHits(ctx context.Context, db DBTX, arg UpsertDroppedHitsParams) error {
+	_, err := db.Exec(ctx, upsertDroppedHits,
+		arg.WebsiteIds,
+		arg.Days,
+		arg.Reasons,
+		arg.Hits,
+	)
+	return err
+}
@@ -60,6 +60,13 @@ func (ns NullRiverJobState) Value() (driver.Value, error) {
 	return string(ns.RiverJobState), nil
 }
 
+type DroppedHit struct {
+	WebsiteID uuid.UUID
+	Day       pgtype.Date
+	Reason    string
+	Hits      int64
+}
+
 type Event struct {
 	ID          uuid.UUID
 	CreatedAt   pgtype.Timestamptz
@@ -72,25 +79,47 @@ type Event struct {
 	CountryName pgtype.Text
 	City        pgtype.Text
 	Region      pgtype.Text
+	SessionID   pgtype.UUID
+	Hostname    pgtype.Text
+	Language    pgtype.Text
 }
 
 type Pageview struct {
-	ID          uuid.UUID
-	CreatedAt   pgtype.Timestamptz
-	WebsiteID   uuid.UUID
-	Url         string
-	Referrer    pgtype.Text
-	Browser     pgtype.Text
-	Os          pgtype.Text
-	Device      pgtype.Text
-	Country     pgtype.Text
-	Language    pgtype.Text
-	ScreenWidth pgtype.Int4
-	VisitorHash pgtype.Text
-	CountryCode pgtype.Text
-	CountryName pgtype.Text
-	City        pgtype.Text
-	Region      pgtype.Text
+	ID             uuid.UUID
+	CreatedAt      pgtype.Timestamptz
+	WebsiteID      uuid.UUID
+	Url            string
+	Referrer       pgtype.Text
+	Browser        pgtype.Text
+	Os             pgtype.Text
+	Device         pgtype.Text
+	Country        pgtype.Text
+	Language       pgtype.Text
+	ScreenWidth    pgtype.Int4
+	VisitorHash    pgtype.Text
+	CountryCode    pgtype.Text
+	CountryName    pgtype.Text
+	City           pgtype.Text
+	Region         pgtype.Text
+	SessionID      pgtype.UUID
+	EngagedMs      pgtype.Int4
+	ScrollDepth    pgtype.Int2
+	UtmSource      pgtype.Text
+	UtmMedium      pgtype.Text
+	UtmCampaign    pgtype.Text
+	UtmTerm        pgtype.Text
+	UtmContent     pgtype.Text
+	ClickID        pgtype.Text
+	ReferrerSource pgtype.Text
+	PageGroup      pgtype.Text
+	Hostname       pgtype.Text
+	BrowserVersion pgtype.Text
+	OsVersion      pgtype.Text
+	DeviceBrand    pgtype.Text
+	DeviceModel    pgtype.Text
+	ViewportWidth  pgtype.Int4
+	PixelRatio     pgtype.Float4
+	ScreenSize     pgtype.Text
 }
 
 type RiverClient struct {
@@ -154,6 +183,29 @@ type RiverQueue struct {
 	UpdatedAt pgtype.Timestamptz
 }
 
+type Session struct {
+	ID             uuid.UUID
+	WebsiteID      uuid.UUID
+	VisitorHash    string
+	StartedAt      pgtype.Timestamptz
+	EndedAt        pgtype.Timestamptz
+	EntryPage      pgtype.Text
+	ExitPage       pgtype.Text
+	Pageviews      int32
+	Events         int32
+	Referrer       pgtype.Text
+	UtmSource      pgtype.Text
+	UtmMedium      pgtype.Text
+	UtmCampaign    pgtype.Text
+	UtmTerm        pgtype.Text
+	UtmContent     pgtype.Text
+	ClickID        pgtype.Text
+	ReferrerSource pgtype.Text
+	Channel        pgtype.Text
+	Hostname       pgtype.Text
+	Language       pgtype.Text
+}
+
 type Token struct {
 	ID        uuid.UUID
 	CreatedAt pgtype.Timestamptz
@@ -174,11 +226,40 @@ type User struct {
 	IsAdmin          bool
 }
 
-type Website struct {
-	ID        uuid.UUID
+type VisitorSalt struct {
+	Day       pgtype.Date
 	CreatedAt pgtype.Timestamptz
-	UpdatedAt pgtype.Timestamptz
-	UserID    uuid.UUID
-	Name      string
-	Domain    string
+	Salt      []byte
+}
+
+type Website struct {
+	ID                 uuid.UUID
+	CreatedAt          pgtype.Timestamptz
+	UpdatedAt          pgtype.Timestamptz
+	UserID             uuid.UUID
+	Name               string
+	Domain             string
+	AllowedHostnames   []string
+	AllowLocalhost     bool
+	RateLimitPerSecond int32
+	RateLimitBurst     int32
+	ProxyScriptPath    string
+	ProxyCollectPath   string
+	QueryParamMode     string
+	QueryParams        []string
+	LowercasePaths     bool
+	StripTrailingSlash bool
+	PathPatterns       []string
+	Timezone           string
+}
+
+type WebsiteApiKey struct {
+	ID         uuid.UUID
+	CreatedAt  pgtype.Timestamptz
+	UpdatedAt  pgtype.Timestamptz
+	WebsiteID  uuid.UUID
+	Name       string
+	Prefix     string
+	Hash       string
+	LastUsedAt pgtype.Timestamptz
 }
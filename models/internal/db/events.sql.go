@@ -17,7 +17,7 @@ insert into
     events (id, created_at, website_id, url, event_name, event_data, visitor_hash, country_code, country_name, city, region)
 values
     ($1, now(), $2, $3, $4, $5, $6, $7, $8, $9, $10)
-returning id, created_at, website_id, url, event_name, event_data, visitor_hash, country_code, country_name, city, region
+returning id, created_at, website_id, url, event_name, event_data, visitor_hash, country_code, country_name, city, region, session_id, hostname, language
 `
 
 type InsertEventParams struct {
@@ -39,7 +39,7 @@ type InsertEventParams struct {
 //	    events (id, created_at, website_id, url, event_name, event_data, visitor_hash, country_code, country_name, city, region)
 //	values
 //	    ($1, now(), $2, $3, $4, $5, $6, $7, $8, $9, $10)
-//	returning id, created_at, website_id, url, event_name, event_data, visitor_hash, country_code, country_name, city, region
+//	returning id, created_at, website_id, url, event_name, event_data, visitor_hash, country_code, country_name, city, region, session_id, hostname, language
 func (q *Queries) InsertEvent(ctx context.Context, db DBTX, arg InsertEventParams) (Event, error) {
 	row := db.QueryRow(ctx, insertEvent,
 		arg.ID,
@@ -66,24 +66,195 @@ func (q *Queries) InsertEvent(ctx context.Context, db DBTX, arg InsertEventParam
 		&i.CountryName,
 		&i.City,
 		&i.Region,
+		&i.SessionID,
+		&i.Hostname,
+		&i.Language,
 	)
 	return i, err
 }
 
+const insertEvents = `-- name: InsertEvents :execrows
+insert into
+    events (id, created_at, website_id, url, event_name, event_data, visitor_hash, country_code, country_name, city, region, session_id, hostname, language)
+select
+    id, created_at, website_id, url, event_name, event_data,
+    nullif(visitor_hash, ''), nullif(country_code, ''), nullif(country_name, ''), nullif(city, ''), nullif(region, ''),
+    nullif(session_id, '00000000-0000-0000-0000-000000000000'::uuid), nullif(hostname, ''), nullif(language, '')
+from (
+    select
+        unnest($1::uuid[]) as id,
+        unnest($2::timestamptz[]) as created_at,
+        unnest($3::uuid[]) as website_id,
+        unnest($4::text[]) as url,
+        unnest($5::text[]) as event_name,
+        unnest($6::jsonb[]) as event_data,
+        unnest($7::text[]) as visitor_hash,
+        unnest($8::text[]) as country_code,
+        unnest($9::text[]) as country_name,
+        unnest($10::text[]) as city,
+        unnest($11::text[]) as region,
+        unnest($12::uuid[]) as session_id,
+        unnest($13::text[]) as hostname,
+        unnest($14::text[]) as language
+) as batch
+`
+
+type InsertEventsParams struct {
+	Ids           []uuid.UUID
+	CreatedAts    []pgtype.Timestamptz
+	WebsiteIds    []uuid.UUID
+	Urls          []string
+	EventNames    []string
+	EventDatas    [][]byte
+	VisitorHashes []string
+	CountryCodes  []string
+	CountryNames  []string
+	Cities        []string
+	Regions       []string
+	SessionIds    []uuid.UUID
+	Hostnames     []string
+	Languages     []string
+}
+
+// InsertEvents
+//
+//	insert into
+//	    events (id, created_at, website_id, url, event_name, event_data, visitor_hash, country_code, country_name, city, region, session_id, hostname, language)
+//	select
+//	    id, created_at, website_id, url, event_name, event_data,
+//	    nullif(visitor_hash, ''), nullif(country_code, ''), nullif(country_name, ''), nullif(city, ''), nullif(region, ''),
+//	    nullif(session_id, '00000000-0000-0000-0000-000000000000'::uuid), nullif(hostname, ''), nullif(language, '')
+//	from (
+//	    select
+//	        unnest($1::uuid[]) as id,
+//	        unnest($2::timestamptz[]) as created_at,
+//	        unnest($3::uuid[]) as website_id,
+//	        unnest($4::text[]) as url,
+//	        unnest($5::text[]) as event_name,
+//	        unnest($6::jsonb[]) as event_data,
+//	        unnest($7::text[]) as visitor_hash,
+//	        unnest($8::text[]) as country_code,
+//	        unnest($9::text[]) as country_name,
+//	        unnest($10::text[]) as city,
+//	        unnest($11::text[]) as region,
+//	        unnest($12::uuid[]) as session_id,
+//	        unnest($13::text[]) as hostname,
+//	        unnest($14::text[]) as language
+//	) as batch
+func (q *Queries) InsertEvents(ctx context.Context, db DBTX, arg InsertEventsParams) (int64, error) {
+	result, err := db.Exec(ctx, insertEvents,
+		arg.Ids,
+		arg.CreatedAts,
+		arg.WebsiteIds,
+		arg.Urls,
+		arg.EventNames,
+		arg.EventDatas,
+		arg.VisitorHashes,
+		arg.CountryCodes,
+		arg.CountryNames,
+		arg.Cities,
+		arg.Regions,
+		arg.SessionIds,
+		arg.Hostnames,
+		arg.Languages,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const queryEventPropertyBreakdown = `-- name: QueryEventPropertyBreakdown :many
+select (event_data->>$2::text)::text as value, count(*)::bigint as event_count
+from events
+where website_id = $1
+  and event_name = $3::text
+  and event_data->>$2::text is not null
+  and created_at between $4::timestamptz and $5::timestamptz
+  and ($6::text is null or hostname = $6)
+  and ($7::text is null or language = $7 or language like $7 || '-%')
+  and ($8::jsonb is null or id in (select filtered_events($1, $4::timestamptz, $5::timestamptz, $8)))
+group by value order by event_count desc limit 10
+`
+
+type QueryEventPropertyBreakdownParams struct {
+	WebsiteID uuid.UUID
+	Property  string
+	EventName string
+	StartDate pgtype.Timestamptz
+	EndDate   pgtype.Timestamptz
+	Hostname  pgtype.Text
+	Language  pgtype.Text
+	Filters   []byte
+}
+
+type QueryEventPropertyBreakdownRow struct {
+	Value      string
+	EventCount int64
+}
+
+// QueryEventPropertyBreakdown
+//
+//	select (event_data->>$2::text)::text as value, count(*)::bigint as event_count
+//	from events
+//	where website_id = $1
+//	  and event_name = $3::text
+//	  and event_data->>$2::text is not null
+//	  and created_at between $4::timestamptz and $5::timestamptz
+//	  and ($6::text is null or hostname = $6)
+//	  and ($7::text is null or language = $7 or language like $7 || '-%')
+//	  and ($8::jsonb is null or id in (select filtered_events($1, $4::timestamptz, $5::timestamptz, $8)))
+//	group by value order by event_count desc limit 10
+func (q *Queries) QueryEventPropertyBreakdown(ctx context.Context, db DBTX, arg QueryEventPropertyBreakdownParams) ([]QueryEventPropertyBreakdownRow, error) {
+	rows, err := db.Query(ctx, queryEventPropertyBreakdown,
+		arg.WebsiteID,
+		arg.Property,
+		arg.EventName,
+		arg.StartDate,
+		arg.EndDate,
+		arg.Hostname,
+		arg.Language,
+		arg.Filters,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []QueryEventPropertyBreakdownRow
+	for rows.Next() {
+		var i QueryEventPropertyBreakdownRow
+		if err := rows.Scan(&i.Value, &i.EventCount); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const queryEventsTimeBucketed = `-- name: QueryEventsTimeBucketed :many
-select date_trunc($2::text, created_at)::timestamptz as bucket_time,
+select date_trunc($2::text, created_at, $3::text)::timestamptz as bucket_time,
        count(*)::bigint as event_count
 from events
 where website_id = $1
-  and created_at between $3::timestamptz and $4::timestamptz
+  and created_at between $4::timestamptz and $5::timestamptz
+  and ($6::text is null or hostname = $6)
+  and ($7::text is null or language = $7 or language like $7 || '-%')
+  and ($8::jsonb is null or id in (select filtered_events($1, $4::timestamptz, $5::timestamptz, $8)))
 group by bucket_time order by bucket_time
 `
 
 type QueryEventsTimeBucketedParams struct {
 	WebsiteID uuid.UUID
 	Bucket    string
+	Timezone  string
 	StartDate pgtype.Timestamptz
 	EndDate   pgtype.Timestamptz
+	Hostname  pgtype.Text
+	Language  pgtype.Text
+	Filters   []byte
 }
 
 type QueryEventsTimeBucketedRow struct {
@@ -93,18 +264,25 @@ type QueryEventsTimeBucketedRow struct {
 
 // QueryEventsTimeBucketed
 //
-//	select date_trunc($2::text, created_at)::timestamptz as bucket_time,
+//	select date_trunc($2::text, created_at, $3::text)::timestamptz as bucket_time,
 //	       count(*)::bigint as event_count
 //	from events
 //	where website_id = $1
-//	  and created_at between $3::timestamptz and $4::timestamptz
+//	  and created_at between $4::timestamptz and $5::timestamptz
+//	  and ($6::text is null or hostname = $6)
+//	  and ($7::text is null or language = $7 or language like $7 || '-%')
+//	  and ($8::jsonb is null or id in (select filtered_events($1, $4::timestamptz, $5::timestamptz, $8)))
 //	group by bucket_time order by bucket_time
 func (q *Queries) QueryEventsTimeBucketed(ctx context.Context, db DBTX, arg QueryEventsTimeBucketedParams) ([]QueryEventsTimeBucketedRow, error) {
 	rows, err := db.Query(ctx, queryEventsTimeBucketed,
 		arg.WebsiteID,
 		arg.Bucket,
+		arg.Timezone,
 		arg.StartDate,
 		arg.EndDate,
+		arg.Hostname,
+		arg.Language,
+		arg.Filters,
 	)
 	if err != nil {
 		return nil, err
@@ -129,6 +307,9 @@ select event_name, count(*)::bigint as event_count
 from events
 where website_id = $1
   and created_at between $2::timestamptz and $3::timestamptz
+  and ($4::text is null or hostname = $4)
+  and ($5::text is null or language = $5 or language like $5 || '-%')
+  and ($6::jsonb is null or id in (select filtered_events($1, $2::timestamptz, $3::timestamptz, $6)))
 group by event_name order by event_count desc limit 10
 `
 
@@ -136,6 +317,9 @@ type QueryTopEventsParams struct {
 	WebsiteID uuid.UUID
 	StartDate pgtype.Timestamptz
 	EndDate   pgtype.Timestamptz
+	Hostname  pgtype.Text
+	Language  pgtype.Text
+	Filters   []byte
 }
 
 type QueryTopEventsRow struct {
@@ -149,9 +333,19 @@ type QueryTopEventsRow struct {
 //	from events
 //	where website_id = $1
 //	  and created_at between $2::timestamptz and $3::timestamptz
+//	  and ($4::text is null or hostname = $4)
+//	  and ($5::text is null or language = $5 or language like $5 || '-%')
+//	  and ($6::jsonb is null or id in (select filtered_events($1, $2::timestamptz, $3::timestamptz, $6)))
 //	group by event_name order by event_count desc limit 10
 func (q *Queries) QueryTopEvents(ctx context.Context, db DBTX, arg QueryTopEventsParams) ([]QueryTopEventsRow, error) {
-	rows, err := db.Query(ctx, queryTopEvents, arg.WebsiteID, arg.StartDate, arg.EndDate)
+	rows, err := db.Query(ctx, queryTopEvents,
+		arg.WebsiteID,
+		arg.StartDate,
+		arg.EndDate,
+		arg.Hostname,
+		arg.Language,
+		arg.Filters,
+	)
 	if err != nil {
 		return nil, err
 	}
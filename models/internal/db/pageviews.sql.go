@@ -17,7 +17,7 @@ insert into
     pageviews (id, created_at, website_id, url, referrer, browser, os, device, country, language, screen_width, visitor_hash, country_code, country_name, city, region)
 values
     ($1, now(), $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
-returning id, created_at, website_id, url, referrer, browser, os, device, country, language, screen_width, visitor_hash, country_code, country_name, city, region
+returning id, created_at, website_id, url, referrer, browser, os, device, country, language, screen_width, visitor_hash, country_code, country_name, city, region, session_id, engaged_ms, scroll_depth, utm_source, utm_medium, utm_campaign, utm_term, utm_content, click_id, referrer_source, page_group, hostname, browser_version, os_version, device_brand, device_model, viewport_width, pixel_ratio, screen_size
 `
 
 type InsertPageviewParams struct {
@@ -44,7 +44,7 @@ type InsertPageviewParams struct {
 //	    pageviews (id, created_at, website_id, url, referrer, browser, os, device, country, language, screen_width, visitor_hash, country_code, country_name, city, region)
 //	values
 //	    ($1, now(), $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
-//	returning id, created_at, website_id, url, referrer, browser, os, device, country, language, screen_width, visitor_hash, country_code, country_name, city, region
+//	returning id, created_at, website_id, url, referrer, browser, os, device, country, language, screen_width, visitor_hash, country_code, country_name, city, region, session_id, engaged_ms, scroll_depth, utm_source, utm_medium, utm_campaign, utm_term, utm_content, click_id, referrer_source, page_group, hostname, browser_version, os_version, device_brand, device_model, viewport_width, pixel_ratio, screen_size
 func (q *Queries) InsertPageview(ctx context.Context, db DBTX, arg InsertPageviewParams) (Pageview, error) {
 	row := db.QueryRow(ctx, insertPageview,
 		arg.ID,
@@ -81,52 +81,217 @@ func (q *Queries) InsertPageview(ctx context.Context, db DBTX, arg InsertPagevie
 		&i.CountryName,
 		&i.City,
 		&i.Region,
+		&i.SessionID,
+		&i.EngagedMs,
+		&i.ScrollDepth,
+		&i.UtmSource,
+		&i.UtmMedium,
+		&i.UtmCampaign,
+		&i.UtmTerm,
+		&i.UtmContent,
+		&i.ClickID,
+		&i.ReferrerSource,
+		&i.PageGroup,
+		&i.Hostname,
+		&i.BrowserVersion,
+		&i.OsVersion,
+		&i.DeviceBrand,
+		&i.DeviceModel,
+		&i.ViewportWidth,
+		&i.PixelRatio,
+		&i.ScreenSize,
 	)
 	return i, err
 }
 
-const queryBounceCount = `-- name: QueryBounceCount :one
-SELECT count(*)::bigint AS bounce_visitors
-FROM (
-    SELECT visitor_hash
-    FROM pageviews
-    WHERE website_id = $1
-      AND created_at BETWEEN $2::timestamptz AND $3::timestamptz
-      AND visitor_hash IS NOT NULL
-    GROUP BY visitor_hash
-    HAVING count(*) = 1
-) AS single_page_visitors
+const insertPageviews = `-- name: InsertPageviews :execrows
+insert into
+    pageviews (id, created_at, website_id, url, referrer, browser, os, device, country, language, screen_width, visitor_hash, country_code, country_name, city, region, session_id,
+               utm_source, utm_medium, utm_campaign, utm_term, utm_content, click_id, referrer_source, page_group, hostname, browser_version, os_version, device_brand, device_model,
+    viewport_width, pixel_ratio, screen_size)
+select
+    id, created_at, website_id, url,
+    nullif(referrer, ''), nullif(browser, ''), nullif(os, ''), nullif(device, ''), nullif(country, ''), nullif(language, ''),
+    nullif(screen_width, 0), nullif(visitor_hash, ''), nullif(country_code, ''), nullif(country_name, ''), nullif(city, ''), nullif(region, ''),
+    nullif(session_id, '00000000-0000-0000-0000-000000000000'::uuid),
+    nullif(utm_source, ''), nullif(utm_medium, ''), nullif(utm_campaign, ''), nullif(utm_term, ''), nullif(utm_content, ''), nullif(click_id, ''),
+    nullif(referrer_source, ''), nullif(page_group, ''), nullif(hostname, ''),
+    nullif(browser_version, ''), nullif(os_version, ''), nullif(device_brand, ''), nullif(device_model, ''),
+    nullif(viewport_width, 0), nullif(pixel_ratio, 0), nullif(screen_size, '')
+from (
+    select
+        unnest($1::uuid[]) as id,
+        unnest($2::timestamptz[]) as created_at,
+        unnest($3::uuid[]) as website_id,
+        unnest($4::text[]) as url,
+        unnest($5::text[]) as referrer,
+        unnest($6::text[]) as browser,
+        unnest($7::text[]) as os,
+        unnest($8::text[]) as device,
+        unnest($9::text[]) as country,
+        unnest($10::text[]) as language,
+        unnest($11::int[]) as screen_width,
+        unnest($12::text[]) as visitor_hash,
+        unnest($13::text[]) as country_code,
+        unnest($14::text[]) as country_name,
+        unnest($15::text[]) as city,
+        unnest($16::text[]) as region,
+        unnest($17::uuid[]) as session_id,
+        unnest($18::text[]) as utm_source,
+        unnest($19::text[]) as utm_medium,
+        unnest($20::text[]) as utm_campaign,
+        unnest($21::text[]) as utm_term,
+        unnest($22::text[]) as utm_content,
+        unnest($23::text[]) as click_id,
+        unnest($24::text[]) as referrer_source,
+        unnest($25::text[]) as page_group,
+        unnest($26::text[]) as hostname,
+        unnest($27::text[]) as browser_version,
+        unnest($28::text[]) as os_version,
+        unnest($29::text[]) as device_brand,
+        unnest($30::text[]) as device_model,
+        unnest($31::int[]) as viewport_width,
+        unnest($32::real[]) as pixel_ratio,
+        unnest($33::text[]) as screen_size
+) as batch
 `
 
-type QueryBounceCountParams struct {
-	WebsiteID uuid.UUID
-	StartDate pgtype.Timestamptz
-	EndDate   pgtype.Timestamptz
+type InsertPageviewsParams struct {
+	Ids             []uuid.UUID
+	CreatedAts      []pgtype.Timestamptz
+	WebsiteIds      []uuid.UUID
+	Urls            []string
+	Referrers       []string
+	Browsers        []string
+	Oses            []string
+	Devices         []string
+	Countries       []string
+	Languages       []string
+	ScreenWidths    []int32
+	VisitorHashes   []string
+	CountryCodes    []string
+	CountryNames    []string
+	Cities          []string
+	Regions         []string
+	SessionIds      []uuid.UUID
+	UtmSources      []string
+	UtmMediums      []string
+	UtmCampaigns    []string
+	UtmTerms        []string
+	UtmContents     []string
+	ClickIds        []string
+	ReferrerSources []string
+	PageGroups      []string
+	Hostnames       []string
+	BrowserVersions []string
+	OsVersions      []string
+	DeviceBrands    []string
+	DeviceModels    []string
+	ViewportWidths  []int32
+	PixelRatios     []float32
+	ScreenSizes     []string
 }
 
-// QueryBounceCount
+// InsertPageviews
 //
-//	SELECT count(*)::bigint AS bounce_visitors
-//	FROM (
-//	    SELECT visitor_hash
-//	    FROM pageviews
-//	    WHERE website_id = $1
-//	      AND created_at BETWEEN $2::timestamptz AND $3::timestamptz
-//	      AND visitor_hash IS NOT NULL
-//	    GROUP BY visitor_hash
-//	    HAVING count(*) = 1
-//	) AS single_page_visitors
-func (q *Queries) QueryBounceCount(ctx context.Context, db DBTX, arg QueryBounceCountParams) (int64, error) {
-	row := db.QueryRow(ctx, queryBounceCount, arg.WebsiteID, arg.StartDate, arg.EndDate)
-	var bounce_visitors int64
-	err := row.Scan(&bounce_visitors)
-	return bounce_visitors, err
+//	insert into
+//	    pageviews (id, created_at, website_id, url, referrer, browser, os, device, country, language, screen_width, visitor_hash, country_code, country_name, city, region, session_id,
+//	               utm_source, utm_medium, utm_campaign, utm_term, utm_content, click_id, referrer_source, page_group, hostname, browser_version, os_version, device_brand, device_model,
+//	    viewport_width, pixel_ratio, screen_size)
+//	select
+//	    id, created_at, website_id, url,
+//	    nullif(referrer, ''), nullif(browser, ''), nullif(os, ''), nullif(device, ''), nullif(country, ''), nullif(language, ''),
+//	    nullif(screen_width, 0), nullif(visitor_hash, ''), nullif(country_code, ''), nullif(country_name, ''), nullif(city, ''), nullif(region, ''),
+//	    nullif(session_id, '00000000-0000-0000-0000-000000000000'::uuid),
+//	    nullif(utm_source, ''), nullif(utm_medium, ''), nullif(utm_campaign, ''), nullif(utm_term, ''), nullif(utm_content, ''), nullif(click_id, ''),
+//	    nullif(referrer_source, ''), nullif(page_group, ''), nullif(hostname, ''),
+//	    nullif(browser_version, ''), nullif(os_version, ''), nullif(device_brand, ''), nullif(device_model, ''),
+//	    nullif(viewport_width, 0), nullif(pixel_ratio, 0), nullif(screen_size, '')
+//	from (
+//	    select
+//	        unnest($1::uuid[]) as id,
+//	        unnest($2::timestamptz[]) as created_at,
+//	        unnest($3::uuid[]) as website_id,
+//	        unnest($4::text[]) as url,
+//	        unnest($5::text[]) as referrer,
+//	        unnest($6::text[]) as browser,
+//	        unnest($7::text[]) as os,
+//	        unnest($8::text[]) as device,
+//	        unnest($9::text[]) as country,
+//	        unnest($10::text[]) as language,
+//	        unnest($11::int[]) as screen_width,
+//	        unnest($12::text[]) as visitor_hash,
+//	        unnest($13::text[]) as country_code,
+//	        unnest($14::text[]) as country_name,
+//	        unnest($15::text[]) as city,
+//	        unnest($16::text[]) as region,
+//	        unnest($17::uuid[]) as session_id,
+//	        unnest($18::text[]) as utm_source,
+//	        unnest($19::text[]) as utm_medium,
+//	        unnest($20::text[]) as utm_campaign,
+//	        unnest($21::text[]) as utm_term,
+//	        unnest($22::text[]) as utm_content,
+//	        unnest($23::text[]) as click_id,
+//	        unnest($24::text[]) as referrer_source,
+//	        unnest($25::text[]) as page_group,
+//	        unnest($26::text[]) as hostname,
+//	        unnest($27::text[]) as browser_version,
+//	        unnest($28::text[]) as os_version,
+//	        unnest($29::text[]) as device_brand,
+//	        unnest($30::text[]) as device_model,
+//	        unnest($31::int[]) as viewport_width,
+//	        unnest($32::real[]) as pixel_ratio,
+//	        unnest($33::text[]) as screen_size
+//	) as batch
+func (q *Queries) InsertPageviews(ctx context.Context, db DBTX, arg InsertPageviewsParams) (int64, error) {
+	result, err := db.Exec(ctx, insertPageviews,
+		arg.Ids,
+		arg.CreatedAts,
+		arg.WebsiteIds,
+		arg.Urls,
+		arg.Referrers,
+		arg.Browsers,
+		arg.Oses,
+		arg.Devices,
+		arg.Countries,
+		arg.Languages,
+		arg.ScreenWidths,
+		arg.VisitorHashes,
+		arg.CountryCodes,
+		arg.CountryNames,
+		arg.Cities,
+		arg.Regions,
+		arg.SessionIds,
+		arg.UtmSources,
+		arg.UtmMediums,
+		arg.UtmCampaigns,
+		arg.UtmTerms,
+		arg.UtmContents,
+		arg.ClickIds,
+		arg.ReferrerSources,
+		arg.PageGroups,
+		arg.Hostnames,
+		arg.BrowserVersions,
+		arg.OsVersions,
+		arg.DeviceBrands,
+		arg.DeviceModels,
+		arg.ViewportWidths,
+		arg.PixelRatios,
+		arg.ScreenSizes,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
 }
 
 const queryBrowserBreakdown = `-- name: QueryBrowserBreakdown :many
 select browser, count(*)::bigint as views
 from pageviews
 where website_id = $1 and created_at between $2::timestamptz and $3::timestamptz
+  and ($4::text is null or hostname = $4)
+  and ($5::text is null or language = $5 or language like $5 || '-%')
+  and ($6::jsonb is null or id in (select filtered_pageviews($1, $2::timestamptz, $3::timestamptz, $6)))
 group by browser order by views desc
 `
 
@@ -134,6 +299,9 @@ type QueryBrowserBreakdownParams struct {
 	WebsiteID uuid.UUID
 	StartDate pgtype.Timestamptz
 	EndDate   pgtype.Timestamptz
+	Hostname  pgtype.Text
+	Language  pgtype.Text
+	Filters   []byte
 }
 
 type QueryBrowserBreakdownRow struct {
@@ -146,9 +314,19 @@ type QueryBrowserBreakdownRow struct {
 //	select browser, count(*)::bigint as views
 //	from pageviews
 //	where website_id = $1 and created_at between $2::timestamptz and $3::timestamptz
+//	  and ($4::text is null or hostname = $4)
+//	  and ($5::text is null or language = $5 or language like $5 || '-%')
+//	  and ($6::jsonb is null or id in (select filtered_pageviews($1, $2::timestamptz, $3::timestamptz, $6)))
 //	group by browser order by views desc
 func (q *Queries) QueryBrowserBreakdown(ctx context.Context, db DBTX, arg QueryBrowserBreakdownParams) ([]QueryBrowserBreakdownRow, error) {
-	rows, err := db.Query(ctx, queryBrowserBreakdown, arg.WebsiteID, arg.StartDate, arg.EndDate)
+	rows, err := db.Query(ctx, queryBrowserBreakdown,
+		arg.WebsiteID,
+		arg.StartDate,
+		arg.EndDate,
+		arg.Hostname,
+		arg.Language,
+		arg.Filters,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -167,10 +345,77 @@ func (q *Queries) QueryBrowserBreakdown(ctx context.Context, db DBTX, arg QueryB
 	return items, nil
 }
 
+const queryBrowserVersionBreakdown = `-- name: QueryBrowserVersionBreakdown :many
+select coalesce(browser_version, '')::text as version, count(*)::bigint as views
+from pageviews
+where website_id = $1 and created_at between $2::timestamptz and $3::timestamptz
+  and ($4::text is null or hostname = $4)
+  and ($5::text is null or language = $5 or language like $5 || '-%')
+  and ($6::jsonb is null or id in (select filtered_pageviews($1, $2::timestamptz, $3::timestamptz, $6)))
+  and browser = $7::text
+group by version order by views desc limit 10
+`
+
+type QueryBrowserVersionBreakdownParams struct {
+	WebsiteID uuid.UUID
+	StartDate pgtype.Timestamptz
+	EndDate   pgtype.Timestamptz
+	Hostname  pgtype.Text
+	Language  pgtype.Text
+	Filters   []byte
+	Browser   string
+}
+
+type QueryBrowserVersionBreakdownRow struct {
+	Version string
+	Views   int64
+}
+
+// QueryBrowserVersionBreakdown
+//
+//	select coalesce(browser_version, '')::text as version, count(*)::bigint as views
+//	from pageviews
+//	where website_id = $1 and created_at between $2::timestamptz and $3::timestamptz
+//	  and ($4::text is null or hostname = $4)
+//	  and ($5::text is null or language = $5 or language like $5 || '-%')
+//	  and ($6::jsonb is null or id in (select filtered_pageviews($1, $2::timestamptz, $3::timestamptz, $6)))
+//	  and browser = $7::text
+//	group by version order by views desc limit 10
+func (q *Queries) QueryBrowserVersionBreakdown(ctx context.Context, db DBTX, arg QueryBrowserVersionBreakdownParams) ([]QueryBrowserVersionBreakdownRow, error) {
+	rows, err := db.Query(ctx, queryBrowserVersionBreakdown,
+		arg.WebsiteID,
+		arg.StartDate,
+		arg.EndDate,
+		arg.Hostname,
+		arg.Language,
+		arg.Filters,
+		arg.Browser,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []QueryBrowserVersionBreakdownRow
+	for rows.Next() {
+		var i QueryBrowserVersionBreakdownRow
+		if err := rows.Scan(&i.Version, &i.Views); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const queryDeviceBreakdown = `-- name: QueryDeviceBreakdown :many
 select device, count(*)::bigint as views
 from pageviews
 where website_id = $1 and created_at between $2::timestamptz and $3::timestamptz
+  and ($4::text is null or hostname = $4)
+  and ($5::text is null or language = $5 or language like $5 || '-%')
+  and ($6::jsonb is null or id in (select filtered_pageviews($1, $2::timestamptz, $3::timestamptz, $6)))
 group by device order by views desc
 `
 
@@ -178,6 +423,9 @@ type QueryDeviceBreakdownParams struct {
 	WebsiteID uuid.UUID
 	StartDate pgtype.Timestamptz
 	EndDate   pgtype.Timestamptz
+	Hostname  pgtype.Text
+	Language  pgtype.Text
+	Filters   []byte
 }
 
 type QueryDeviceBreakdownRow struct {
@@ -190,9 +438,19 @@ type QueryDeviceBreakdownRow struct {
 //	select device, count(*)::bigint as views
 //	from pageviews
 //	where website_id = $1 and created_at between $2::timestamptz and $3::timestamptz
+//	  and ($4::text is null or hostname = $4)
+//	  and ($5::text is null or language = $5 or language like $5 || '-%')
+//	  and ($6::jsonb is null or id in (select filtered_pageviews($1, $2::timestamptz, $3::timestamptz, $6)))
 //	group by device order by views desc
 func (q *Queries) QueryDeviceBreakdown(ctx context.Context, db DBTX, arg QueryDeviceBreakdownParams) ([]QueryDeviceBreakdownRow, error) {
-	rows, err := db.Query(ctx, queryDeviceBreakdown, arg.WebsiteID, arg.StartDate, arg.EndDate)
+	rows, err := db.Query(ctx, queryDeviceBreakdown,
+		arg.WebsiteID,
+		arg.StartDate,
+		arg.EndDate,
+		arg.Hostname,
+		arg.Language,
+		arg.Filters,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -211,10 +469,201 @@ func (q *Queries) QueryDeviceBreakdown(ctx context.Context, db DBTX, arg QueryDe
 	return items, nil
 }
 
+const queryDeviceModelBreakdown = `-- name: QueryDeviceModelBreakdown :many
+select concat_ws(' ', device_brand, device_model)::text as model, count(*)::bigint as views
+from pageviews
+where website_id = $1 and created_at between $2::timestamptz and $3::timestamptz
+  and ($4::text is null or hostname = $4)
+  and ($5::text is null or language = $5 or language like $5 || '-%')
+  and ($6::jsonb is null or id in (select filtered_pageviews($1, $2::timestamptz, $3::timestamptz, $6)))
+  and device = $7::text
+group by model order by views desc limit 10
+`
+
+type QueryDeviceModelBreakdownParams struct {
+	WebsiteID uuid.UUID
+	StartDate pgtype.Timestamptz
+	EndDate   pgtype.Timestamptz
+	Hostname  pgtype.Text
+	Language  pgtype.Text
+	Filters   []byte
+	Device    string
+}
+
+type QueryDeviceModelBreakdownRow struct {
+	Model string
+	Views int64
+}
+
+// QueryDeviceModelBreakdown
+//
+//	select concat_ws(' ', device_brand, device_model)::text as model, count(*)::bigint as views
+//	from pageviews
+//	where website_id = $1 and created_at between $2::timestamptz and $3::timestamptz
+//	  and ($4::text is null or hostname = $4)
+//	  and ($5::text is null or language = $5 or language like $5 || '-%')
+//	  and ($6::jsonb is null or id in (select filtered_pageviews($1, $2::timestamptz, $3::timestamptz, $6)))
+//	  and device = $7::text
+//	group by model order by views desc limit 10
+func (q *Queries) QueryDeviceModelBreakdown(ctx context.Context, db DBTX, arg QueryDeviceModelBreakdownParams) ([]QueryDeviceModelBreakdownRow, error) {
+	rows, err := db.Query(ctx, queryDeviceModelBreakdown,
+		arg.WebsiteID,
+		arg.StartDate,
+		arg.EndDate,
+		arg.Hostname,
+		arg.Language,
+		arg.Filters,
+		arg.Device,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []QueryDeviceModelBreakdownRow
+	for rows.Next() {
+		var i QueryDeviceModelBreakdownRow
+		if err := rows.Scan(&i.Model, &i.Views); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const queryLanguageBreakdown = `-- name: QueryLanguageBreakdown :many
+select split_part(language, '-', 1)::text as language, count(*)::bigint as views
+from pageviews
+where website_id = $1 and created_at between $2::timestamptz and $3::timestamptz
+  and ($4::text is null or hostname = $4)
+  and ($5::text is null or language = $5 or language like $5 || '-%')
+  and ($6::jsonb is null or id in (select filtered_pageviews($1, $2::timestamptz, $3::timestamptz, $6)))
+  and language is not null
+group by 1 order by views desc limit 10
+`
+
+type QueryLanguageBreakdownParams struct {
+	WebsiteID uuid.UUID
+	StartDate pgtype.Timestamptz
+	EndDate   pgtype.Timestamptz
+	Hostname  pgtype.Text
+	Language  pgtype.Text
+	Filters   []byte
+}
+
+type QueryLanguageBreakdownRow struct {
+	Language string
+	Views    int64
+}
+
+// Locales are counted under their language, en-US and en-GB as en.
+//
+//	select split_part(language, '-', 1)::text as language, count(*)::bigint as views
+//	from pageviews
+//	where website_id = $1 and created_at between $2::timestamptz and $3::timestamptz
+//	  and ($4::text is null or hostname = $4)
+//	  and ($5::text is null or language = $5 or language like $5 || '-%')
+//	  and ($6::jsonb is null or id in (select filtered_pageviews($1, $2::timestamptz, $3::timestamptz, $6)))
+//	  and language is not null
+//	group by 1 order by views desc limit 10
+func (q *Queries) QueryLanguageBreakdown(ctx context.Context, db DBTX, arg QueryLanguageBreakdownParams) ([]QueryLanguageBreakdownRow, error) {
+	rows, err := db.Query(ctx, queryLanguageBreakdown,
+		arg.WebsiteID,
+		arg.StartDate,
+		arg.EndDate,
+		arg.Hostname,
+		arg.Language,
+		arg.Filters,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []QueryLanguageBreakdownRow
+	for rows.Next() {
+		var i QueryLanguageBreakdownRow
+		if err := rows.Scan(&i.Language, &i.Views); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const queryLocaleBreakdown = `-- name: QueryLocaleBreakdown :many
+select language, count(*)::bigint as views
+from pageviews
+where website_id = $1 and created_at between $2::timestamptz and $3::timestamptz
+  and ($4::text is null or hostname = $4)
+  and ($5::text is null or language = $5 or language like $5 || '-%')
+  and ($6::jsonb is null or id in (select filtered_pageviews($1, $2::timestamptz, $3::timestamptz, $6)))
+  and language is not null
+group by language order by views desc limit 10
+`
+
+type QueryLocaleBreakdownParams struct {
+	WebsiteID uuid.UUID
+	StartDate pgtype.Timestamptz
+	EndDate   pgtype.Timestamptz
+	Hostname  pgtype.Text
+	Language  pgtype.Text
+	Filters   []byte
+}
+
+type QueryLocaleBreakdownRow struct {
+	Language pgtype.Text
+	Views    int64
+}
+
+// QueryLocaleBreakdown
+//
+//	select language, count(*)::bigint as views
+//	from pageviews
+//	where website_id = $1 and created_at between $2::timestamptz and $3::timestamptz
+//	  and ($4::text is null or hostname = $4)
+//	  and ($5::text is null or language = $5 or language like $5 || '-%')
+//	  and ($6::jsonb is null or id in (select filtered_pageviews($1, $2::timestamptz, $3::timestamptz, $6)))
+//	  and language is not null
+//	group by language order by views desc limit 10
+func (q *Queries) QueryLocaleBreakdown(ctx context.Context, db DBTX, arg QueryLocaleBreakdownParams) ([]QueryLocaleBreakdownRow, error) {
+	rows, err := db.Query(ctx, queryLocaleBreakdown,
+		arg.WebsiteID,
+		arg.StartDate,
+		arg.EndDate,
+		arg.Hostname,
+		arg.Language,
+		arg.Filters,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []QueryLocaleBreakdownRow
+	for rows.Next() {
+		var i QueryLocaleBreakdownRow
+		if err := rows.Scan(&i.Language, &i.Views); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const queryOSBreakdown = `-- name: QueryOSBreakdown :many
 select os, count(*)::bigint as views
 from pageviews
 where website_id = $1 and created_at between $2::timestamptz and $3::timestamptz
+  and ($4::text is null or hostname = $4)
+  and ($5::text is null or language = $5 or language like $5 || '-%')
+  and ($6::jsonb is null or id in (select filtered_pageviews($1, $2::timestamptz, $3::timestamptz, $6)))
 group by os order by views desc
 `
 
@@ -222,6 +671,9 @@ type QueryOSBreakdownParams struct {
 	WebsiteID uuid.UUID
 	StartDate pgtype.Timestamptz
 	EndDate   pgtype.Timestamptz
+	Hostname  pgtype.Text
+	Language  pgtype.Text
+	Filters   []byte
 }
 
 type QueryOSBreakdownRow struct {
@@ -234,9 +686,19 @@ type QueryOSBreakdownRow struct {
 //	select os, count(*)::bigint as views
 //	from pageviews
 //	where website_id = $1 and created_at between $2::timestamptz and $3::timestamptz
+//	  and ($4::text is null or hostname = $4)
+//	  and ($5::text is null or language = $5 or language like $5 || '-%')
+//	  and ($6::jsonb is null or id in (select filtered_pageviews($1, $2::timestamptz, $3::timestamptz, $6)))
 //	group by os order by views desc
 func (q *Queries) QueryOSBreakdown(ctx context.Context, db DBTX, arg QueryOSBreakdownParams) ([]QueryOSBreakdownRow, error) {
-	rows, err := db.Query(ctx, queryOSBreakdown, arg.WebsiteID, arg.StartDate, arg.EndDate)
+	rows, err := db.Query(ctx, queryOSBreakdown,
+		arg.WebsiteID,
+		arg.StartDate,
+		arg.EndDate,
+		arg.Hostname,
+		arg.Language,
+		arg.Filters,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -255,6 +717,145 @@ func (q *Queries) QueryOSBreakdown(ctx context.Context, db DBTX, arg QueryOSBrea
 	return items, nil
 }
 
+const queryOSVersionBreakdown = `-- name: QueryOSVersionBreakdown :many
+select coalesce(os_version, '')::text as version, count(*)::bigint as views
+from pageviews
+where website_id = $1 and created_at between $2::timestamptz and $3::timestamptz
+  and ($4::text is null or hostname = $4)
+  and ($5::text is null or language = $5 or language like $5 || '-%')
+  and ($6::jsonb is null or id in (select filtered_pageviews($1, $2::timestamptz, $3::timestamptz, $6)))
+  and os = $7::text
+group by version order by views desc limit 10
+`
+
+type QueryOSVersionBreakdownParams struct {
+	WebsiteID uuid.UUID
+	StartDate pgtype.Timestamptz
+	EndDate   pgtype.Timestamptz
+	Hostname  pgtype.Text
+	Language  pgtype.Text
+	Filters   []byte
+	Os        string
+}
+
+type QueryOSVersionBreakdownRow struct {
+	Version string
+	Views   int64
+}
+
+// QueryOSVersionBreakdown
+//
+//	select coalesce(os_version, '')::text as version, count(*)::bigint as views
+//	from pageviews
+//	where website_id = $1 and created_at between $2::timestamptz and $3::timestamptz
+//	  and ($4::text is null or hostname = $4)
+//	  and ($5::text is null or language = $5 or language like $5 || '-%')
+//	  and ($6::jsonb is null or id in (select filtered_pageviews($1, $2::timestamptz, $3::timestamptz, $6)))
+//	  and os = $7::text
+//	group by version order by views desc limit 10
+func (q *Queries) QueryOSVersionBreakdown(ctx context.Context, db DBTX, arg QueryOSVersionBreakdownParams) ([]QueryOSVersionBreakdownRow, error) {
+	rows, err := db.Query(ctx, queryOSVersionBreakdown,
+		arg.WebsiteID,
+		arg.StartDate,
+		arg.EndDate,
+		arg.Hostname,
+		arg.Language,
+		arg.Filters,
+		arg.Os,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []QueryOSVersionBreakdownRow
+	for rows.Next() {
+		var i QueryOSVersionBreakdownRow
+		if err := rows.Scan(&i.Version, &i.Views); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const queryPageEngagement = `-- name: QueryPageEngagement :many
+select url,
+       count(*)::bigint as views,
+       coalesce(avg(engaged_ms), 0)::float8 as avg_engaged_ms,
+       coalesce(avg(scroll_depth), 0)::float8 as avg_scroll_depth
+from pageviews
+where website_id = $1
+  and created_at between $2::timestamptz and $3::timestamptz
+  and ($4::text is null or hostname = $4)
+  and ($5::text is null or language = $5 or language like $5 || '-%')
+  and ($6::jsonb is null or id in (select filtered_pageviews($1, $2::timestamptz, $3::timestamptz, $6)))
+group by url order by views desc limit 10
+`
+
+type QueryPageEngagementParams struct {
+	WebsiteID uuid.UUID
+	StartDate pgtype.Timestamptz
+	EndDate   pgtype.Timestamptz
+	Hostname  pgtype.Text
+	Language  pgtype.Text
+	Filters   []byte
+}
+
+type QueryPageEngagementRow struct {
+	Url            string
+	Views          int64
+	AvgEngagedMs   float64
+	AvgScrollDepth float64
+}
+
+// QueryPageEngagement
+//
+//	select url,
+//	       count(*)::bigint as views,
+//	       coalesce(avg(engaged_ms), 0)::float8 as avg_engaged_ms,
+//	       coalesce(avg(scroll_depth), 0)::float8 as avg_scroll_depth
+//	from pageviews
+//	where website_id = $1
+//	  and created_at between $2::timestamptz and $3::timestamptz
+//	  and ($4::text is null or hostname = $4)
+//	  and ($5::text is null or language = $5 or language like $5 || '-%')
+//	  and ($6::jsonb is null or id in (select filtered_pageviews($1, $2::timestamptz, $3::timestamptz, $6)))
+//	group by url order by views desc limit 10
+func (q *Queries) QueryPageEngagement(ctx context.Context, db DBTX, arg QueryPageEngagementParams) ([]QueryPageEngagementRow, error) {
+	rows, err := db.Query(ctx, queryPageEngagement,
+		arg.WebsiteID,
+		arg.StartDate,
+		arg.EndDate,
+		arg.Hostname,
+		arg.Language,
+		arg.Filters,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []QueryPageEngagementRow
+	for rows.Next() {
+		var i QueryPageEngagementRow
+		if err := rows.Scan(
+			&i.Url,
+			&i.Views,
+			&i.AvgEngagedMs,
+			&i.AvgScrollDepth,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const queryPageviewsPerDay = `-- name: QueryPageviewsPerDay :many
 select date_trunc('day', created_at)::timestamptz as date, count(*)::bigint as views
 from pageviews
@@ -300,19 +901,26 @@ func (q *Queries) QueryPageviewsPerDay(ctx context.Context, db DBTX, arg QueryPa
 }
 
 const queryPageviewsTimeBucketed = `-- name: QueryPageviewsTimeBucketed :many
-select date_trunc($2::text, created_at)::timestamptz as bucket_time,
+select date_trunc($2::text, created_at, $3::text)::timestamptz as bucket_time,
        count(*)::bigint as views
 from pageviews
 where website_id = $1
-  and created_at between $3::timestamptz and $4::timestamptz
+  and created_at between $4::timestamptz and $5::timestamptz
+  and ($6::text is null or hostname = $6)
+  and ($7::text is null or language = $7 or language like $7 || '-%')
+  and ($8::jsonb is null or id in (select filtered_pageviews($1, $4::timestamptz, $5::timestamptz, $8)))
 group by bucket_time order by bucket_time
 `
 
 type QueryPageviewsTimeBucketedParams struct {
 	WebsiteID uuid.UUID
 	Bucket    string
+	Timezone  string
 	StartDate pgtype.Timestamptz
 	EndDate   pgtype.Timestamptz
+	Hostname  pgtype.Text
+	Language  pgtype.Text
+	Filters   []byte
 }
 
 type QueryPageviewsTimeBucketedRow struct {
@@ -322,18 +930,25 @@ type QueryPageviewsTimeBucketedRow struct {
 
 // QueryPageviewsTimeBucketed
 //
-//	select date_trunc($2::text, created_at)::timestamptz as bucket_time,
+//	select date_trunc($2::text, created_at, $3::text)::timestamptz as bucket_time,
 //	       count(*)::bigint as views
 //	from pageviews
 //	where website_id = $1
-//	  and created_at between $3::timestamptz and $4::timestamptz
+//	  and created_at between $4::timestamptz and $5::timestamptz
+//	  and ($6::text is null or hostname = $6)
+//	  and ($7::text is null or language = $7 or language like $7 || '-%')
+//	  and ($8::jsonb is null or id in (select filtered_pageviews($1, $4::timestamptz, $5::timestamptz, $8)))
 //	group by bucket_time order by bucket_time
 func (q *Queries) QueryPageviewsTimeBucketed(ctx context.Context, db DBTX, arg QueryPageviewsTimeBucketedParams) ([]QueryPageviewsTimeBucketedRow, error) {
 	rows, err := db.Query(ctx, queryPageviewsTimeBucketed,
 		arg.WebsiteID,
 		arg.Bucket,
+		arg.Timezone,
 		arg.StartDate,
 		arg.EndDate,
+		arg.Hostname,
+		arg.Language,
+		arg.Filters,
 	)
 	if err != nil {
 		return nil, err
@@ -353,11 +968,76 @@ func (q *Queries) QueryPageviewsTimeBucketed(ctx context.Context, db DBTX, arg Q
 	return items, nil
 }
 
+const queryScreenSizeBreakdown = `-- name: QueryScreenSizeBreakdown :many
+select screen_size, count(*)::bigint as views
+from pageviews
+where website_id = $1 and created_at between $2::timestamptz and $3::timestamptz
+  and ($4::text is null or hostname = $4)
+  and ($5::text is null or language = $5 or language like $5 || '-%')
+  and ($6::jsonb is null or id in (select filtered_pageviews($1, $2::timestamptz, $3::timestamptz, $6)))
+  and screen_size is not null
+group by screen_size order by views desc
+`
+
+type QueryScreenSizeBreakdownParams struct {
+	WebsiteID uuid.UUID
+	StartDate pgtype.Timestamptz
+	EndDate   pgtype.Timestamptz
+	Hostname  pgtype.Text
+	Language  pgtype.Text
+	Filters   []byte
+}
+
+type QueryScreenSizeBreakdownRow struct {
+	ScreenSize pgtype.Text
+	Views      int64
+}
+
+// QueryScreenSizeBreakdown
+//
+//	select screen_size, count(*)::bigint as views
+//	from pageviews
+//	where website_id = $1 and created_at between $2::timestamptz and $3::timestamptz
+//	  and ($4::text is null or hostname = $4)
+//	  and ($5::text is null or language = $5 or language like $5 || '-%')
+//	  and ($6::jsonb is null or id in (select filtered_pageviews($1, $2::timestamptz, $3::timestamptz, $6)))
+//	  and screen_size is not null
+//	group by screen_size order by views desc
+func (q *Queries) QueryScreenSizeBreakdown(ctx context.Context, db DBTX, arg QueryScreenSizeBreakdownParams) ([]QueryScreenSizeBreakdownRow, error) {
+	rows, err := db.Query(ctx, queryScreenSizeBreakdown,
+		arg.WebsiteID,
+		arg.StartDate,
+		arg.EndDate,
+		arg.Hostname,
+		arg.Language,
+		arg.Filters,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []QueryScreenSizeBreakdownRow
+	for rows.Next() {
+		var i QueryScreenSizeBreakdownRow
+		if err := rows.Scan(&i.ScreenSize, &i.Views); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const queryTopCities = `-- name: QueryTopCities :many
 select city, country_code, count(*)::bigint as views
 from pageviews
 where website_id = $1
   and created_at between $2::timestamptz and $3::timestamptz
+  and ($4::text is null or hostname = $4)
+  and ($5::text is null or language = $5 or language like $5 || '-%')
+  and ($6::jsonb is null or id in (select filtered_pageviews($1, $2::timestamptz, $3::timestamptz, $6)))
   and city is not null and city != ''
 group by city, country_code order by views desc limit 10
 `
@@ -366,6 +1046,9 @@ type QueryTopCitiesParams struct {
 	WebsiteID uuid.UUID
 	StartDate pgtype.Timestamptz
 	EndDate   pgtype.Timestamptz
+	Hostname  pgtype.Text
+	Language  pgtype.Text
+	Filters   []byte
 }
 
 type QueryTopCitiesRow struct {
@@ -380,10 +1063,20 @@ type QueryTopCitiesRow struct {
 //	from pageviews
 //	where website_id = $1
 //	  and created_at between $2::timestamptz and $3::timestamptz
+//	  and ($4::text is null or hostname = $4)
+//	  and ($5::text is null or language = $5 or language like $5 || '-%')
+//	  and ($6::jsonb is null or id in (select filtered_pageviews($1, $2::timestamptz, $3::timestamptz, $6)))
 //	  and city is not null and city != ''
 //	group by city, country_code order by views desc limit 10
 func (q *Queries) QueryTopCities(ctx context.Context, db DBTX, arg QueryTopCitiesParams) ([]QueryTopCitiesRow, error) {
-	rows, err := db.Query(ctx, queryTopCities, arg.WebsiteID, arg.StartDate, arg.EndDate)
+	rows, err := db.Query(ctx, queryTopCities,
+		arg.WebsiteID,
+		arg.StartDate,
+		arg.EndDate,
+		arg.Hostname,
+		arg.Language,
+		arg.Filters,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -407,6 +1100,9 @@ select country_code, country_name, count(*)::bigint as views
 from pageviews
 where website_id = $1
   and created_at between $2::timestamptz and $3::timestamptz
+  and ($4::text is null or hostname = $4)
+  and ($5::text is null or language = $5 or language like $5 || '-%')
+  and ($6::jsonb is null or id in (select filtered_pageviews($1, $2::timestamptz, $3::timestamptz, $6)))
   and country_code is not null and country_code != ''
 group by country_code, country_name order by views desc limit 10
 `
@@ -415,6 +1111,9 @@ type QueryTopCountriesParams struct {
 	WebsiteID uuid.UUID
 	StartDate pgtype.Timestamptz
 	EndDate   pgtype.Timestamptz
+	Hostname  pgtype.Text
+	Language  pgtype.Text
+	Filters   []byte
 }
 
 type QueryTopCountriesRow struct {
@@ -429,10 +1128,20 @@ type QueryTopCountriesRow struct {
 //	from pageviews
 //	where website_id = $1
 //	  and created_at between $2::timestamptz and $3::timestamptz
+//	  and ($4::text is null or hostname = $4)
+//	  and ($5::text is null or language = $5 or language like $5 || '-%')
+//	  and ($6::jsonb is null or id in (select filtered_pageviews($1, $2::timestamptz, $3::timestamptz, $6)))
 //	  and country_code is not null and country_code != ''
 //	group by country_code, country_name order by views desc limit 10
 func (q *Queries) QueryTopCountries(ctx context.Context, db DBTX, arg QueryTopCountriesParams) ([]QueryTopCountriesRow, error) {
-	rows, err := db.Query(ctx, queryTopCountries, arg.WebsiteID, arg.StartDate, arg.EndDate)
+	rows, err := db.Query(ctx, queryTopCountries,
+		arg.WebsiteID,
+		arg.StartDate,
+		arg.EndDate,
+		arg.Hostname,
+		arg.Language,
+		arg.Filters,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -451,32 +1160,108 @@ func (q *Queries) QueryTopCountries(ctx context.Context, db DBTX, arg QueryTopCo
 	return items, nil
 }
 
+const queryTopHostnames = `-- name: QueryTopHostnames :many
+select hostname, count(*)::bigint as views
+from pageviews
+where website_id = $1 and created_at between $2::timestamptz and $3::timestamptz
+  and ($4::text is null or language = $4 or language like $4 || '-%')
+  and ($5::jsonb is null or id in (select filtered_pageviews($1, $2::timestamptz, $3::timestamptz, $5)))
+  and hostname is not null
+group by hostname order by views desc limit 10
+`
+
+type QueryTopHostnamesParams struct {
+	WebsiteID uuid.UUID
+	StartDate pgtype.Timestamptz
+	EndDate   pgtype.Timestamptz
+	Language  pgtype.Text
+	Filters   []byte
+}
+
+type QueryTopHostnamesRow struct {
+	Hostname pgtype.Text
+	Views    int64
+}
+
+// QueryTopHostnames
+//
+//	select hostname, count(*)::bigint as views
+//	from pageviews
+//	where website_id = $1 and created_at between $2::timestamptz and $3::timestamptz
+//	  and ($4::text is null or language = $4 or language like $4 || '-%')
+//	  and ($5::jsonb is null or id in (select filtered_pageviews($1, $2::timestamptz, $3::timestamptz, $5)))
+//	  and hostname is not null
+//	group by hostname order by views desc limit 10
+func (q *Queries) QueryTopHostnames(ctx context.Context, db DBTX, arg QueryTopHostnamesParams) ([]QueryTopHostnamesRow, error) {
+	rows, err := db.Query(ctx, queryTopHostnames,
+		arg.WebsiteID,
+		arg.StartDate,
+		arg.EndDate,
+		arg.Language,
+		arg.Filters,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []QueryTopHostnamesRow
+	for rows.Next() {
+		var i QueryTopHostnamesRow
+		if err := rows.Scan(&i.Hostname, &i.Views); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const queryTopPages = `-- name: QueryTopPages :many
-select url, count(*)::bigint as views
+select coalesce(page_group, url)::text as page, (page_group is not null)::bool as grouped, count(*)::bigint as views
 from pageviews
 where website_id = $1 and created_at between $2::timestamptz and $3::timestamptz
-group by url order by views desc limit 10
+  and ($4::text is null or hostname = $4)
+  and ($5::text is null or language = $5 or language like $5 || '-%')
+  and ($6::jsonb is null or id in (select filtered_pageviews($1, $2::timestamptz, $3::timestamptz, $6)))
+group by page, grouped order by views desc limit 10
 `
 
 type QueryTopPagesParams struct {
 	WebsiteID uuid.UUID
 	StartDate pgtype.Timestamptz
 	EndDate   pgtype.Timestamptz
+	Hostname  pgtype.Text
+	Language  pgtype.Text
+	Filters   []byte
 }
 
 type QueryTopPagesRow struct {
-	Url   string
-	Views int64
+	Page    string
+	Grouped bool
+	Views   int64
 }
 
-// QueryTopPages
+// Pages matching one of the website's path patterns are counted under the
+// pattern.
 //
-//	select url, count(*)::bigint as views
+//	select coalesce(page_group, url)::text as page, (page_group is not null)::bool as grouped, count(*)::bigint as views
 //	from pageviews
 //	where website_id = $1 and created_at between $2::timestamptz and $3::timestamptz
-//	group by url order by views desc limit 10
+//	  and ($4::text is null or hostname = $4)
+//	  and ($5::text is null or language = $5 or language like $5 || '-%')
+//	  and ($6::jsonb is null or id in (select filtered_pageviews($1, $2::timestamptz, $3::timestamptz, $6)))
+//	group by page, grouped order by views desc limit 10
 func (q *Queries) QueryTopPages(ctx context.Context, db DBTX, arg QueryTopPagesParams) ([]QueryTopPagesRow, error) {
-	rows, err := db.Query(ctx, queryTopPages, arg.WebsiteID, arg.StartDate, arg.EndDate)
+	rows, err := db.Query(ctx, queryTopPages,
+		arg.WebsiteID,
+		arg.StartDate,
+		arg.EndDate,
+		arg.Hostname,
+		arg.Language,
+		arg.Filters,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -484,7 +1269,7 @@ func (q *Queries) QueryTopPages(ctx context.Context, db DBTX, arg QueryTopPagesP
 	var items []QueryTopPagesRow
 	for rows.Next() {
 		var i QueryTopPagesRow
-		if err := rows.Scan(&i.Url, &i.Views); err != nil {
+		if err := rows.Scan(&i.Page, &i.Grouped, &i.Views); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
@@ -495,42 +1280,60 @@ func (q *Queries) QueryTopPages(ctx context.Context, db DBTX, arg QueryTopPagesP
 	return items, nil
 }
 
-const queryTopReferrers = `-- name: QueryTopReferrers :many
-select referrer, count(*)::bigint as views
+const queryTopPagesInGroup = `-- name: QueryTopPagesInGroup :many
+select url, count(*)::bigint as views
 from pageviews
 where website_id = $1 and created_at between $2::timestamptz and $3::timestamptz
-  and referrer is not null and referrer != ''
-group by referrer order by views desc limit 10
+  and ($4::text is null or hostname = $4)
+  and ($5::text is null or language = $5 or language like $5 || '-%')
+  and ($6::jsonb is null or id in (select filtered_pageviews($1, $2::timestamptz, $3::timestamptz, $6)))
+  and page_group = $7::text
+group by url order by views desc limit 10
 `
 
-type QueryTopReferrersParams struct {
+type QueryTopPagesInGroupParams struct {
 	WebsiteID uuid.UUID
 	StartDate pgtype.Timestamptz
 	EndDate   pgtype.Timestamptz
+	Hostname  pgtype.Text
+	Language  pgtype.Text
+	Filters   []byte
+	PageGroup string
 }
 
-type QueryTopReferrersRow struct {
-	Referrer pgtype.Text
-	Views    int64
+type QueryTopPagesInGroupRow struct {
+	Url   string
+	Views int64
 }
 
-// QueryTopReferrers
+// QueryTopPagesInGroup
 //
-//	select referrer, count(*)::bigint as views
+//	select url, count(*)::bigint as views
 //	from pageviews
 //	where website_id = $1 and created_at between $2::timestamptz and $3::timestamptz
-//	  and referrer is not null and referrer != ''
-//	group by referrer order by views desc limit 10
-func (q *Queries) QueryTopReferrers(ctx context.Context, db DBTX, arg QueryTopReferrersParams) ([]QueryTopReferrersRow, error) {
-	rows, err := db.Query(ctx, queryTopReferrers, arg.WebsiteID, arg.StartDate, arg.EndDate)
+//	  and ($4::text is null or hostname = $4)
+//	  and ($5::text is null or language = $5 or language like $5 || '-%')
+//	  and ($6::jsonb is null or id in (select filtered_pageviews($1, $2::timestamptz, $3::timestamptz, $6)))
+//	  and page_group = $7::text
+//	group by url order by views desc limit 10
+func (q *Queries) QueryTopPagesInGroup(ctx context.Context, db DBTX, arg QueryTopPagesInGroupParams) ([]QueryTopPagesInGroupRow, error) {
+	rows, err := db.Query(ctx, queryTopPagesInGroup,
+		arg.WebsiteID,
+		arg.StartDate,
+		arg.EndDate,
+		arg.Hostname,
+		arg.Language,
+		arg.Filters,
+		arg.PageGroup,
+	)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []QueryTopReferrersRow
+	var items []QueryTopPagesInGroupRow
 	for rows.Next() {
-		var i QueryTopReferrersRow
-		if err := rows.Scan(&i.Referrer, &i.Views); err != nil {
+		var i QueryTopPagesInGroupRow
+		if err := rows.Scan(&i.Url, &i.Views); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
@@ -545,12 +1348,18 @@ const queryTotalPageviews = `-- name: QueryTotalPageviews :one
 select count(*)::bigint as total
 from pageviews
 where website_id = $1 and created_at between $2::timestamptz and $3::timestamptz
+  and ($4::text is null or hostname = $4)
+  and ($5::text is null or language = $5 or language like $5 || '-%')
+  and ($6::jsonb is null or id in (select filtered_pageviews($1, $2::timestamptz, $3::timestamptz, $6)))
 `
 
 type QueryTotalPageviewsParams struct {
 	WebsiteID uuid.UUID
 	StartDate pgtype.Timestamptz
 	EndDate   pgtype.Timestamptz
+	Hostname  pgtype.Text
+	Language  pgtype.Text
+	Filters   []byte
 }
 
 // QueryTotalPageviews
@@ -558,8 +1367,18 @@ type QueryTotalPageviewsParams struct {
 //	select count(*)::bigint as total
 //	from pageviews
 //	where website_id = $1 and created_at between $2::timestamptz and $3::timestamptz
+//	  and ($4::text is null or hostname = $4)
+//	  and ($5::text is null or language = $5 or language like $5 || '-%')
+//	  and ($6::jsonb is null or id in (select filtered_pageviews($1, $2::timestamptz, $3::timestamptz, $6)))
 func (q *Queries) QueryTotalPageviews(ctx context.Context, db DBTX, arg QueryTotalPageviewsParams) (int64, error) {
-	row := db.QueryRow(ctx, queryTotalPageviews, arg.WebsiteID, arg.StartDate, arg.EndDate)
+	row := db.QueryRow(ctx, queryTotalPageviews,
+		arg.WebsiteID,
+		arg.StartDate,
+		arg.EndDate,
+		arg.Hostname,
+		arg.Language,
+		arg.Filters,
+	)
 	var total int64
 	err := row.Scan(&total)
 	return total, err
@@ -570,6 +1389,9 @@ select count(distinct visitor_hash)::bigint as total
 from pageviews
 where website_id = $1
   and created_at between $2::timestamptz and $3::timestamptz
+  and ($4::text is null or hostname = $4)
+  and ($5::text is null or language = $5 or language like $5 || '-%')
+  and ($6::jsonb is null or id in (select filtered_pageviews($1, $2::timestamptz, $3::timestamptz, $6)))
   and visitor_hash is not null
 `
 
@@ -577,6 +1399,9 @@ type QueryTotalUniqueVisitorsParams struct {
 	WebsiteID uuid.UUID
 	StartDate pgtype.Timestamptz
 	EndDate   pgtype.Timestamptz
+	Hostname  pgtype.Text
+	Language  pgtype.Text
+	Filters   []byte
 }
 
 // QueryTotalUniqueVisitors
@@ -585,20 +1410,33 @@ type QueryTotalUniqueVisitorsParams struct {
 //	from pageviews
 //	where website_id = $1
 //	  and created_at between $2::timestamptz and $3::timestamptz
+//	  and ($4::text is null or hostname = $4)
+//	  and ($5::text is null or language = $5 or language like $5 || '-%')
+//	  and ($6::jsonb is null or id in (select filtered_pageviews($1, $2::timestamptz, $3::timestamptz, $6)))
 //	  and visitor_hash is not null
 func (q *Queries) QueryTotalUniqueVisitors(ctx context.Context, db DBTX, arg QueryTotalUniqueVisitorsParams) (int64, error) {
-	row := db.QueryRow(ctx, queryTotalUniqueVisitors, arg.WebsiteID, arg.StartDate, arg.EndDate)
+	row := db.QueryRow(ctx, queryTotalUniqueVisitors,
+		arg.WebsiteID,
+		arg.StartDate,
+		arg.EndDate,
+		arg.Hostname,
+		arg.Language,
+		arg.Filters,
+	)
 	var total int64
 	err := row.Scan(&total)
 	return total, err
 }
 
 const queryUniqueVisitorsTimeBucketed = `-- name: QueryUniqueVisitorsTimeBucketed :many
-select date_trunc($2::text, created_at)::timestamptz as bucket_time,
+select date_trunc($2::text, created_at, $3::text)::timestamptz as bucket_time,
        count(distinct visitor_hash)::bigint as visitors
 from pageviews
 where website_id = $1
-  and created_at between $3::timestamptz and $4::timestamptz
+  and created_at between $4::timestamptz and $5::timestamptz
+  and ($6::text is null or hostname = $6)
+  and ($7::text is null or language = $7 or language like $7 || '-%')
+  and ($8::jsonb is null or id in (select filtered_pageviews($1, $4::timestamptz, $5::timestamptz, $8)))
   and visitor_hash is not null
 group by bucket_time order by bucket_time
 `
@@ -606,8 +1444,12 @@ group by bucket_time order by bucket_time
 type QueryUniqueVisitorsTimeBucketedParams struct {
 	WebsiteID uuid.UUID
 	Bucket    string
+	Timezone  string
 	StartDate pgtype.Timestamptz
 	EndDate   pgtype.Timestamptz
+	Hostname  pgtype.Text
+	Language  pgtype.Text
+	Filters   []byte
 }
 
 type QueryUniqueVisitorsTimeBucketedRow struct {
@@ -617,19 +1459,26 @@ type QueryUniqueVisitorsTimeBucketedRow struct {
 
 // QueryUniqueVisitorsTimeBucketed
 //
-//	select date_trunc($2::text, created_at)::timestamptz as bucket_time,
+//	select date_trunc($2::text, created_at, $3::text)::timestamptz as bucket_time,
 //	       count(distinct visitor_hash)::bigint as visitors
 //	from pageviews
 //	where website_id = $1
-//	  and created_at between $3::timestamptz and $4::timestamptz
+//	  and created_at between $4::timestamptz and $5::timestamptz
+//	  and ($6::text is null or hostname = $6)
+//	  and ($7::text is null or language = $7 or language like $7 || '-%')
+//	  and ($8::jsonb is null or id in (select filtered_pageviews($1, $4::timestamptz, $5::timestamptz, $8)))
 //	  and visitor_hash is not null
 //	group by bucket_time order by bucket_time
 func (q *Queries) QueryUniqueVisitorsTimeBucketed(ctx context.Context, db DBTX, arg QueryUniqueVisitorsTimeBucketedParams) ([]QueryUniqueVisitorsTimeBucketedRow, error) {
 	rows, err := db.Query(ctx, queryUniqueVisitorsTimeBucketed,
 		arg.WebsiteID,
 		arg.Bucket,
+		arg.Timezone,
 		arg.StartDate,
 		arg.EndDate,
+		arg.Hostname,
+		arg.Language,
+		arg.Filters,
 	)
 	if err != nil {
 		return nil, err
@@ -648,3 +1497,91 @@ func (q *Queries) QueryUniqueVisitorsTimeBucketed(ctx context.Context, db DBTX,
 	}
 	return items, nil
 }
+
+const updatePageviewEngagement = `-- name: UpdatePageviewEngagement :execrows
+update pageviews p
+set engaged_ms = greatest(coalesce(p.engaged_ms, 0), e.engaged_ms),
+    scroll_depth = greatest(coalesce(p.scroll_depth, 0), e.scroll_depth)
+from (
+    select latest.id, max(latest.engaged_ms)::int as engaged_ms, max(latest.scroll_depth)::smallint as scroll_depth
+    from (
+        select distinct on (batch.position) pv.id, batch.engaged_ms, batch.scroll_depth
+        from (
+            select
+                unnest($1::int[]) as position,
+                unnest($2::uuid[]) as website_id,
+                unnest($3::text[]) as visitor_hash,
+                unnest($4::text[]) as url,
+                unnest($5::timestamptz[]) as sent_at,
+                unnest($6::int[]) as engaged_ms,
+                unnest($7::smallint[]) as scroll_depth
+        ) as batch
+        join pageviews pv
+          on pv.website_id = batch.website_id
+         and pv.visitor_hash = batch.visitor_hash
+         and pv.url = batch.url
+         and pv.created_at between batch.sent_at - interval '1 day' and batch.sent_at
+        order by batch.position, pv.created_at desc
+    ) as latest
+    group by latest.id
+) as e
+where p.id = e.id
+`
+
+type UpdatePageviewEngagementParams struct {
+	Positions     []int32
+	WebsiteIds    []uuid.UUID
+	VisitorHashes []string
+	Urls          []string
+	SentAts       []pgtype.Timestamptz
+	EngagedMs     []int32
+	ScrollDepths  []int16
+}
+
+// Engagement hits carry running totals for a page, so each one is applied to
+// the visitor's latest pageview of that URL and only ever raises the values.
+// Several hits in a batch can resolve to the same pageview; the update takes
+// the largest of them, as Postgres would otherwise apply an arbitrary one.
+//
+//	update pageviews p
+//	set engaged_ms = greatest(coalesce(p.engaged_ms, 0), e.engaged_ms),
+//	    scroll_depth = greatest(coalesce(p.scroll_depth, 0), e.scroll_depth)
+//	from (
+//	    select latest.id, max(latest.engaged_ms)::int as engaged_ms, max(latest.scroll_depth)::smallint as scroll_depth
+//	    from (
+//	        select distinct on (batch.position) pv.id, batch.engaged_ms, batch.scroll_depth
+//	        from (
+//	            select
+//	                unnest($1::int[]) as position,
+//	                unnest($2::uuid[]) as website_id,
+//	                unnest($3::text[]) as visitor_hash,
+//	                unnest($4::text[]) as url,
+//	                unnest($5::timestamptz[]) as sent_at,
+//	                unnest($6::int[]) as engaged_ms,
+//	                unnest($7::smallint[]) as scroll_depth
+//	        ) as batch
+//	        join pageviews pv
+//	          on pv.website_id = batch.website_id
+//	         and pv.visitor_hash = batch.visitor_hash
+//	         and pv.url = batch.url
+//	         and pv.created_at between batch.sent_at - interval '1 day' and batch.sent_at
+//	        order by batch.position, pv.created_at desc
+//	    ) as latest
+//	    group by latest.id
+//	) as e
+//	where p.id = e.id
+func (q *Queries) UpdatePageviewEngagement(ctx context.Context, db DBTX, arg UpdatePageviewEngagementParams) (int64, error) {
+	result, err := db.Exec(ctx, updatePageviewEngagement,
+		arg.Positions,
+		arg.WebsiteIds,
+		arg.VisitorHashes,
+		arg.Urls,
+		arg.SentAts,
+		arg.EngagedMs,
+		arg.ScrollDepths,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
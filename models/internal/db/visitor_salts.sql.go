@@ -0,0 +1,57 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: visitor_salts.sql
+
+package db
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const deleteVisitorSaltsBefore = `-- name: DeleteVisitorSaltsBefore :execrows
+delete from visitor_salts where day < $1
+`
+
+// DeleteVisitorSaltsBefore
+//
+//	delete from visitor_salts where day < $1
+func (q *Queries) DeleteVisitorSaltsBefore(ctx context.Context, db DBTX, day pgtype.Date) (int64, error) {
+	result, err := db.Exec(ctx, deleteVisitorSaltsBefore, day)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const upsertVisitorSalt = `-- name: UpsertVisitorSalt :one
+insert into
+    visitor_salts (day, created_at, salt)
+values
+    ($1, now(), $2)
+on conflict (day) do update set day=excluded.day
+returning day, created_at, salt
+`
+
+type UpsertVisitorSaltParams struct {
+	Day  pgtype.Date
+	Salt []byte
+}
+
+// Returns the existing salt when another instance already created one for
+// the day, so every instance hashes with the same salt.
+//
+//	insert into
+//	    visitor_salts (day, created_at, salt)
+//	values
+//	    ($1, now(), $2)
+//	on conflict (day) do update set day=excluded.day
+//	returning day, created_at, salt
+func (q *Queries) UpsertVisitorSalt(ctx context.Context, db DBTX, arg UpsertVisitorSaltParams) (VisitorSalt, error) {
+	row := db.QueryRow(ctx, upsertVisitorSalt, arg.Day, arg.Salt)
+	var i VisitorSalt
+	err := row.Scan(&i.Day, &i.CreatedAt, &i.Salt)
+	return i, err
+}
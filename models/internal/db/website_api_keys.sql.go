@@ -0,0 +1,156 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: website_api_keys.sql
+
+package db
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+const deleteWebsiteAPIKey = `-- name: DeleteWebsiteAPIKey :one
+delete from website_api_keys where id=$1 and website_id=$2 returning id, created_at, updated_at, website_id, name, prefix, hash, last_used_at
+`
+
+type DeleteWebsiteAPIKeyParams struct {
+	ID        uuid.UUID
+	WebsiteID uuid.UUID
+}
+
+// DeleteWebsiteAPIKey
+//
+//	delete from website_api_keys where id=$1 and website_id=$2 returning id, created_at, updated_at, website_id, name, prefix, hash, last_used_at
+func (q *Queries) DeleteWebsiteAPIKey(ctx context.Context, db DBTX, arg DeleteWebsiteAPIKeyParams) (WebsiteApiKey, error) {
+	row := db.QueryRow(ctx, deleteWebsiteAPIKey, arg.ID, arg.WebsiteID)
+	var i WebsiteApiKey
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.WebsiteID,
+		&i.Name,
+		&i.Prefix,
+		&i.Hash,
+		&i.LastUsedAt,
+	)
+	return i, err
+}
+
+const insertWebsiteAPIKey = `-- name: InsertWebsiteAPIKey :one
+insert into
+    website_api_keys (id, created_at, updated_at, website_id, name, prefix, hash)
+values
+    ($1, now(), now(), $2, $3, $4, $5)
+returning id, created_at, updated_at, website_id, name, prefix, hash, last_used_at
+`
+
+type InsertWebsiteAPIKeyParams struct {
+	ID        uuid.UUID
+	WebsiteID uuid.UUID
+	Name      string
+	Prefix    string
+	Hash      string
+}
+
+// InsertWebsiteAPIKey
+//
+//	insert into
+//	    website_api_keys (id, created_at, updated_at, website_id, name, prefix, hash)
+//	values
+//	    ($1, now(), now(), $2, $3, $4, $5)
+//	returning id, created_at, updated_at, website_id, name, prefix, hash, last_used_at
+func (q *Queries) InsertWebsiteAPIKey(ctx context.Context, db DBTX, arg InsertWebsiteAPIKeyParams) (WebsiteApiKey, error) {
+	row := db.QueryRow(ctx, insertWebsiteAPIKey,
+		arg.ID,
+		arg.WebsiteID,
+		arg.Name,
+		arg.Prefix,
+		arg.Hash,
+	)
+	var i WebsiteApiKey
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.WebsiteID,
+		&i.Name,
+		&i.Prefix,
+		&i.Hash,
+		&i.LastUsedAt,
+	)
+	return i, err
+}
+
+const queryWebsiteAPIKeyByHash = `-- name: QueryWebsiteAPIKeyByHash :one
+select id, created_at, updated_at, website_id, name, prefix, hash, last_used_at from website_api_keys where hash=$1
+`
+
+// QueryWebsiteAPIKeyByHash
+//
+//	select id, created_at, updated_at, website_id, name, prefix, hash, last_used_at from website_api_keys where hash=$1
+func (q *Queries) QueryWebsiteAPIKeyByHash(ctx context.Context, db DBTX, hash string) (WebsiteApiKey, error) {
+	row := db.QueryRow(ctx, queryWebsiteAPIKeyByHash, hash)
+	var i WebsiteApiKey
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.WebsiteID,
+		&i.Name,
+		&i.Prefix,
+		&i.Hash,
+		&i.LastUsedAt,
+	)
+	return i, err
+}
+
+const queryWebsiteAPIKeysByWebsiteID = `-- name: QueryWebsiteAPIKeysByWebsiteID :many
+select id, created_at, updated_at, website_id, name, prefix, hash, last_used_at from website_api_keys where website_id=$1 order by created_at desc
+`
+
+// QueryWebsiteAPIKeysByWebsiteID
+//
+//	select id, created_at, updated_at, website_id, name, prefix, hash, last_used_at from website_api_keys where website_id=$1 order by created_at desc
+func (q *Queries) QueryWebsiteAPIKeysByWebsiteID(ctx context.Context, db DBTX, websiteID uuid.UUID) ([]WebsiteApiKey, error) {
+	rows, err := db.Query(ctx, queryWebsiteAPIKeysByWebsiteID, websiteID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []WebsiteApiKey
+	for rows.Next() {
+		var i WebsiteApiKey
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.WebsiteID,
+			&i.Name,
+			&i.Prefix,
+			&i.Hash,
+			&i.LastUsedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const touchWebsiteAPIKey = `-- name: TouchWebsiteAPIKey :exec
+update website_api_keys set last_used_at=now() where id=$1
+`
+
+// TouchWebsiteAPIKey
+//
+//	update website_api_keys set last_used_at=now() where id=$1
+func (q *Queries) TouchWebsiteAPIKey(ctx context.Context, db DBTX, id uuid.UUID) error {
+	_, err := db.Exec(ctx, touchWebsiteAPIKey, id)
+	return err
+}
@@ -25,32 +25,40 @@ func (q *Queries) DeleteWebsite(ctx context.Context, db DBTX, id uuid.UUID) erro
 
 const insertWebsite = `-- name: InsertWebsite :one
 insert into
-    websites (id, created_at, updated_at, user_id, name, domain)
+    websites (id, created_at, updated_at, user_id, name, domain, allowed_hostnames, allow_localhost, proxy_script_path, proxy_collect_path)
 values
-    ($1, now(), now(), $2, $3, $4)
-returning id, created_at, updated_at, user_id, name, domain
+    ($1, now(), now(), $2, $3, $4, $5, $6, $7, $8)
+returning id, created_at, updated_at, user_id, name, domain, allowed_hostnames, allow_localhost, rate_limit_per_second, rate_limit_burst, proxy_script_path, proxy_collect_path, query_param_mode, query_params, lowercase_paths, strip_trailing_slash, path_patterns, timezone
 `
 
 type InsertWebsiteParams struct {
-	ID     uuid.UUID
-	UserID uuid.UUID
-	Name   string
-	Domain string
+	ID               uuid.UUID
+	UserID           uuid.UUID
+	Name             string
+	Domain           string
+	AllowedHostnames []string
+	AllowLocalhost   bool
+	ProxyScriptPath  string
+	ProxyCollectPath string
 }
 
 // InsertWebsite
 //
 //	insert into
-//	    websites (id, created_at, updated_at, user_id, name, domain)
+//	    websites (id, created_at, updated_at, user_id, name, domain, allowed_hostnames, allow_localhost, proxy_script_path, proxy_collect_path)
 //	values
-//	    ($1, now(), now(), $2, $3, $4)
-//	returning id, created_at, updated_at, user_id, name, domain
+//	    ($1, now(), now(), $2, $3, $4, $5, $6, $7, $8)
+//	returning id, created_at, updated_at, user_id, name, domain, allowed_hostnames, allow_localhost, rate_limit_per_second, rate_limit_burst, proxy_script_path, proxy_collect_path, query_param_mode, query_params, lowercase_paths, strip_trailing_slash, path_patterns, timezone
 func (q *Queries) InsertWebsite(ctx context.Context, db DBTX, arg InsertWebsiteParams) (Website, error) {
 	row := db.QueryRow(ctx, insertWebsite,
 		arg.ID,
 		arg.UserID,
 		arg.Name,
 		arg.Domain,
+		arg.AllowedHostnames,
+		arg.AllowLocalhost,
+		arg.ProxyScriptPath,
+		arg.ProxyCollectPath,
 	)
 	var i Website
 	err := row.Scan(
@@ -60,17 +68,29 @@ func (q *Queries) InsertWebsite(ctx context.Context, db DBTX, arg InsertWebsiteP
 		&i.UserID,
 		&i.Name,
 		&i.Domain,
+		&i.AllowedHostnames,
+		&i.AllowLocalhost,
+		&i.RateLimitPerSecond,
+		&i.RateLimitBurst,
+		&i.ProxyScriptPath,
+		&i.ProxyCollectPath,
+		&i.QueryParamMode,
+		&i.QueryParams,
+		&i.LowercasePaths,
+		&i.StripTrailingSlash,
+		&i.PathPatterns,
+		&i.Timezone,
 	)
 	return i, err
 }
 
 const queryWebsiteByID = `-- name: QueryWebsiteByID :one
-select id, created_at, updated_at, user_id, name, domain from websites where id=$1
+select id, created_at, updated_at, user_id, name, domain, allowed_hostnames, allow_localhost, rate_limit_per_second, rate_limit_burst, proxy_script_path, proxy_collect_path, query_param_mode, query_params, lowercase_paths, strip_trailing_slash, path_patterns, timezone from websites where id=$1
 `
 
 // QueryWebsiteByID
 //
-//	select id, created_at, updated_at, user_id, name, domain from websites where id=$1
+//	select id, created_at, updated_at, user_id, name, domain, allowed_hostnames, allow_localhost, rate_limit_per_second, rate_limit_burst, proxy_script_path, proxy_collect_path, query_param_mode, query_params, lowercase_paths, strip_trailing_slash, path_patterns, timezone from websites where id=$1
 func (q *Queries) QueryWebsiteByID(ctx context.Context, db DBTX, id uuid.UUID) (Website, error) {
 	row := db.QueryRow(ctx, queryWebsiteByID, id)
 	var i Website
@@ -81,17 +101,29 @@ func (q *Queries) QueryWebsiteByID(ctx context.Context, db DBTX, id uuid.UUID) (
 		&i.UserID,
 		&i.Name,
 		&i.Domain,
+		&i.AllowedHostnames,
+		&i.AllowLocalhost,
+		&i.RateLimitPerSecond,
+		&i.RateLimitBurst,
+		&i.ProxyScriptPath,
+		&i.ProxyCollectPath,
+		&i.QueryParamMode,
+		&i.QueryParams,
+		&i.LowercasePaths,
+		&i.StripTrailingSlash,
+		&i.PathPatterns,
+		&i.Timezone,
 	)
 	return i, err
 }
 
 const queryWebsitesByUserID = `-- name: QueryWebsitesByUserID :many
-select id, created_at, updated_at, user_id, name, domain from websites where user_id=$1 order by created_at desc
+select id, created_at, updated_at, user_id, name, domain, allowed_hostnames, allow_localhost, rate_limit_per_second, rate_limit_burst, proxy_script_path, proxy_collect_path, query_param_mode, query_params, lowercase_paths, strip_trailing_slash, path_patterns, timezone from websites where user_id=$1 order by created_at desc
 `
 
 // QueryWebsitesByUserID
 //
-//	select id, created_at, updated_at, user_id, name, domain from websites where user_id=$1 order by created_at desc
+//	select id, created_at, updated_at, user_id, name, domain, allowed_hostnames, allow_localhost, rate_limit_per_second, rate_limit_burst, proxy_script_path, proxy_collect_path, query_param_mode, query_params, lowercase_paths, strip_trailing_slash, path_patterns, timezone from websites where user_id=$1 order by created_at desc
 func (q *Queries) QueryWebsitesByUserID(ctx context.Context, db DBTX, userID uuid.UUID) ([]Website, error) {
 	rows, err := db.Query(ctx, queryWebsitesByUserID, userID)
 	if err != nil {
@@ -108,6 +140,18 @@ func (q *Queries) QueryWebsitesByUserID(ctx context.Context, db DBTX, userID uui
 			&i.UserID,
 			&i.Name,
 			&i.Domain,
+			&i.AllowedHostnames,
+			&i.AllowLocalhost,
+			&i.RateLimitPerSecond,
+			&i.RateLimitBurst,
+			&i.ProxyScriptPath,
+			&i.ProxyCollectPath,
+			&i.QueryParamMode,
+			&i.QueryParams,
+			&i.LowercasePaths,
+			&i.StripTrailingSlash,
+			&i.PathPatterns,
+			&i.Timezone,
 		); err != nil {
 			return nil, err
 		}
@@ -121,25 +165,59 @@ func (q *Queries) QueryWebsitesByUserID(ctx context.Context, db DBTX, userID uui
 
 const updateWebsite = `-- name: UpdateWebsite :one
 update websites
-    set updated_at=now(), name=$2, domain=$3
+    set updated_at=now(), name=$2, domain=$3, allowed_hostnames=$4, allow_localhost=$5,
+        rate_limit_per_second=$6, rate_limit_burst=$7, proxy_script_path=$8, proxy_collect_path=$9,
+        query_param_mode=$10, query_params=$11, lowercase_paths=$12, strip_trailing_slash=$13, path_patterns=$14,
+        timezone=$15
 where id = $1
-returning id, created_at, updated_at, user_id, name, domain
+returning id, created_at, updated_at, user_id, name, domain, allowed_hostnames, allow_localhost, rate_limit_per_second, rate_limit_burst, proxy_script_path, proxy_collect_path, query_param_mode, query_params, lowercase_paths, strip_trailing_slash, path_patterns, timezone
 `
 
 type UpdateWebsiteParams struct {
-	ID     uuid.UUID
-	Name   string
-	Domain string
+	ID                 uuid.UUID
+	Name               string
+	Domain             string
+	AllowedHostnames   []string
+	AllowLocalhost     bool
+	RateLimitPerSecond int32
+	RateLimitBurst     int32
+	ProxyScriptPath    string
+	ProxyCollectPath   string
+	QueryParamMode     string
+	QueryParams        []string
+	LowercasePaths     bool
+	StripTrailingSlash bool
+	PathPatterns       []string
+	Timezone           string
 }
 
 // UpdateWebsite
 //
 //	update websites
-//	    set updated_at=now(), name=$2, domain=$3
+//	    set updated_at=now(), name=$2, domain=$3, allowed_hostnames=$4, allow_localhost=$5,
+//	        rate_limit_per_second=$6, rate_limit_burst=$7, proxy_script_path=$8, proxy_collect_path=$9,
+//	        query_param_mode=$10, query_params=$11, lowercase_paths=$12, strip_trailing_slash=$13, path_patterns=$14,
+//	        timezone=$15
 //	where id = $1
-//	returning id, created_at, updated_at, user_id, name, domain
+//	returning id, created_at, updated_at, user_id, name, domain, allowed_hostnames, allow_localhost, rate_limit_per_second, rate_limit_burst, proxy_script_path, proxy_collect_path, query_param_mode, query_params, lowercase_paths, strip_trailing_slash, path_patterns, timezone
 func (q *Queries) UpdateWebsite(ctx context.Context, db DBTX, arg UpdateWebsiteParams) (Website, error) {
-	row := db.QueryRow(ctx, updateWebsite, arg.ID, arg.Name, arg.Domain)
+	row := db.QueryRow(ctx, updateWebsite,
+		arg.ID,
+		arg.Name,
+		arg.Domain,
+		arg.AllowedHostnames,
+		arg.AllowLocalhost,
+		arg.RateLimitPerSecond,
+		arg.RateLimitBurst,
+		arg.ProxyScriptPath,
+		arg.ProxyCollectPath,
+		arg.QueryParamMode,
+		arg.QueryParams,
+		arg.LowercasePaths,
+		arg.StripTrailingSlash,
+		arg.PathPatterns,
+		arg.Timezone,
+	)
 	var i Website
 	err := row.Scan(
 		&i.ID,
@@ -148,6 +226,18 @@ func (q *Queries) UpdateWebsite(ctx context.Context, db DBTX, arg UpdateWebsiteP
 		&i.UserID,
 		&i.Name,
 		&i.Domain,
+		&i.AllowedHostnames,
+		&i.AllowLocalhost,
+		&i.RateLimitPerSecond,
+		&i.RateLimitBurst,
+		&i.ProxyScriptPath,
+		&i.ProxyCollectPath,
+		&i.QueryParamMode,
+		&i.QueryParams,
+		&i.LowercasePaths,
+		&i.StripTrailingSlash,
+		&i.PathPatterns,
+		&i.Timezone,
 	)
 	return i, err
 }
@@ -28,9 +28,41 @@ type Pageview struct {
 	CountryName string
 	City        string
 	Region      string
+	// SessionID is the visit the hit belongs to, if it was sessionized.
+	SessionID uuid.UUID
+	// EngagedMs and ScrollDepth are reported by the tracker when the visitor
+	// leaves or hides the page. ScrollDepth is a percentage.
+	EngagedMs   int32
+	ScrollDepth int16
+	// UTM parameters and the click identifier type the URL carried before
+	// they were stripped from it.
+	UTMSource      string
+	UTMMedium      string
+	UTMCampaign    string
+	UTMTerm        string
+	UTMContent     string
+	ClickID        string
+	ReferrerSource string
+	// PageGroup is the path pattern the URL matched, if any.
+	PageGroup string
+	// Hostname is the host of the page, which URL leaves out.
+	Hostname       string
+	BrowserVersion string
+	OSVersion      string
+	DeviceBrand    string
+	DeviceModel    string
+	// ViewportWidth is the width of the browser window, ScreenWidth that of
+	// the screen. Pageviews from before both were tracked only have the
+	// viewport width, in both fields.
+	ViewportWidth int32
+	PixelRatio    float32
+	ScreenSize    string
 }
 
 type CreatePageviewData struct {
+	// CreatedAt is only honoured by CreatePageviews, where a zero value
+	// falls back to the time of the insert.
+	CreatedAt   time.Time
 	WebsiteID   uuid.UUID
 	URL         string
 	Referrer    string
@@ -45,6 +77,24 @@ type CreatePageviewData struct {
 	CountryName string
 	City        string
 	Region      string
+	SessionID   uuid.UUID
+	// ReferrerSource is the parsed referrer, empty for internal navigation.
+	ReferrerSource string
+	UTMSource      string
+	UTMMedium      string
+	UTMCampaign    string
+	UTMTerm        string
+	UTMContent     string
+	ClickID        string
+	PageGroup      string
+	Hostname       string
+	BrowserVersion string
+	OSVersion      string
+	DeviceBrand    string
+	DeviceModel    string
+	ViewportWidth  int32
+	PixelRatio     float32
+	ScreenSize     string
 }
 
 func CreatePageview(
@@ -77,6 +127,141 @@ func CreatePageview(
 	return rowToPageview(row), nil
 }
 
+// CreatePageviews inserts all pageviews in a single statement and returns the
+// number of rows written.
+func CreatePageviews(
+	ctx context.Context,
+	exec storage.Executor,
+	data []CreatePageviewData,
+) (int64, error) {
+	if len(data) == 0 {
+		return 0, nil
+	}
+
+	now := time.Now()
+	params := db.InsertPageviewsParams{
+		Ids:             make([]uuid.UUID, len(data)),
+		CreatedAts:      make([]pgtype.Timestamptz, len(data)),
+		WebsiteIds:      make([]uuid.UUID, len(data)),
+		Urls:            make([]string, len(data)),
+		Referrers:       make([]string, len(data)),
+		Browsers:        make([]string, len(data)),
+		Oses:            make([]string, len(data)),
+		Devices:         make([]string, len(data)),
+		Countries:       make([]string, len(data)),
+		Languages:       make([]string, len(data)),
+		ScreenWidths:    make([]int32, len(data)),
+		VisitorHashes:   make([]string, len(data)),
+		CountryCodes:    make([]string, len(data)),
+		CountryNames:    make([]string, len(data)),
+		Cities:          make([]string, len(data)),
+		Regions:         make([]string, len(data)),
+		SessionIds:      make([]uuid.UUID, len(data)),
+		UtmSources:      make([]string, len(data)),
+		UtmMediums:      make([]string, len(data)),
+		UtmCampaigns:    make([]string, len(data)),
+		UtmTerms:        make([]string, len(data)),
+		UtmContents:     make([]string, len(data)),
+		ClickIds:        make([]string, len(data)),
+		ReferrerSources: make([]string, len(data)),
+		PageGroups:      make([]string, len(data)),
+		Hostnames:       make([]string, len(data)),
+		BrowserVersions: make([]string, len(data)),
+		OsVersions:      make([]string, len(data)),
+		DeviceBrands:    make([]string, len(data)),
+		DeviceModels:    make([]string, len(data)),
+		ViewportWidths:  make([]int32, len(data)),
+		PixelRatios:     make([]float32, len(data)),
+		ScreenSizes:     make([]string, len(data)),
+	}
+	for i, d := range data {
+		createdAt := d.CreatedAt
+		if createdAt.IsZero() {
+			createdAt = now
+		}
+
+		params.Ids[i] = uuid.New()
+		params.CreatedAts[i] = pgtype.Timestamptz{Time: createdAt, Valid: true}
+		params.WebsiteIds[i] = d.WebsiteID
+		params.Urls[i] = d.URL
+		params.Referrers[i] = d.Referrer
+		params.Browsers[i] = d.Browser
+		params.Oses[i] = d.OS
+		params.Devices[i] = d.Device
+		params.Countries[i] = d.Country
+		params.Languages[i] = d.Language
+		params.ScreenWidths[i] = d.ScreenWidth
+		params.VisitorHashes[i] = d.VisitorHash
+		params.CountryCodes[i] = d.CountryCode
+		params.CountryNames[i] = d.CountryName
+		params.Cities[i] = d.City
+		params.Regions[i] = d.Region
+		params.SessionIds[i] = d.SessionID
+		params.UtmSources[i] = d.UTMSource
+		params.UtmMediums[i] = d.UTMMedium
+		params.UtmCampaigns[i] = d.UTMCampaign
+		params.UtmTerms[i] = d.UTMTerm
+		params.UtmContents[i] = d.UTMContent
+		params.ClickIds[i] = d.ClickID
+		params.ReferrerSources[i] = d.ReferrerSource
+		params.PageGroups[i] = d.PageGroup
+		params.Hostnames[i] = d.Hostname
+		params.BrowserVersions[i] = d.BrowserVersion
+		params.OsVersions[i] = d.OSVersion
+		params.DeviceBrands[i] = d.DeviceBrand
+		params.DeviceModels[i] = d.DeviceModel
+		params.ViewportWidths[i] = d.ViewportWidth
+		params.PixelRatios[i] = d.PixelRatio
+		params.ScreenSizes[i] = d.ScreenSize
+	}
+
+	return queries.InsertPageviews(ctx, exec, params)
+}
+
+// PageviewEngagement is the time a visitor spent on a page and how far they
+// scrolled, as of SentAt.
+type PageviewEngagement struct {
+	WebsiteID   uuid.UUID
+	VisitorHash string
+	URL         string
+	SentAt      time.Time
+	EngagedMs   int32
+	ScrollDepth int16
+}
+
+// RecordPageviewEngagement applies engagement to the latest matching pageview
+// of each visitor and returns the number of pageviews updated.
+func RecordPageviewEngagement(
+	ctx context.Context,
+	exec storage.Executor,
+	data []PageviewEngagement,
+) (int64, error) {
+	if len(data) == 0 {
+		return 0, nil
+	}
+
+	params := db.UpdatePageviewEngagementParams{
+		Positions:     make([]int32, len(data)),
+		WebsiteIds:    make([]uuid.UUID, len(data)),
+		VisitorHashes: make([]string, len(data)),
+		Urls:          make([]string, len(data)),
+		SentAts:       make([]pgtype.Timestamptz, len(data)),
+		EngagedMs:     make([]int32, len(data)),
+		ScrollDepths:  make([]int16, len(data)),
+	}
+	for i, d := range data {
+		params.Positions[i] = int32(i)
+		params.WebsiteIds[i] = d.WebsiteID
+		params.VisitorHashes[i] = d.VisitorHash
+		params.Urls[i] = d.URL
+		params.SentAts[i] = pgtype.Timestamptz{Time: d.SentAt, Valid: true}
+		params.EngagedMs[i] = d.EngagedMs
+		params.ScrollDepths[i] = d.ScrollDepth
+	}
+
+	return queries.UpdatePageviewEngagement(ctx, exec, params)
+}
+
 type PageviewsPerDay struct {
 	Date  time.Time
 	Views int64
@@ -92,6 +277,24 @@ type BreakdownItem struct {
 	Views int64
 }
 
+// PageItem is a row of the top pages breakdown. Grouped rows are a path
+// pattern standing in for every URL that matched it.
+type PageItem struct {
+	Name    string
+	Views   int64
+	Grouped bool
+}
+
+// PageEngagementItem averages engagement over the pageviews of a URL that
+// reported any.
+type PageEngagementItem struct {
+	URL   string
+	Views int64
+	// AvgTimeOnPage is in seconds and AvgScrollDepth a percentage.
+	AvgTimeOnPage  float64
+	AvgScrollDepth float64
+}
+
 type GeoBreakdownItem struct {
 	Name  string
 	Code  string
@@ -101,27 +304,61 @@ type GeoBreakdownItem struct {
 type DashboardStats struct {
 	TotalPageviews      int64
 	TotalUniqueVisitors int64
-	BounceCount         int64
-	ViewsPerVisitor     float64
-	BounceRate          float64
+	TotalSessions       int64
+	// BounceCount is the number of sessions with a single pageview.
+	BounceCount int64
+	// ViewsPerVisitor is the average number of pageviews per session.
+	ViewsPerVisitor float64
+	BounceRate      float64
+	// AvgVisitDuration is the mean session length in seconds.
+	AvgVisitDuration float64
 
 	// Percentage changes vs previous period
-	PageviewsChange      float64
-	UniqueVisitorsChange float64
+	PageviewsChange       float64
+	UniqueVisitorsChange  float64
+	SessionsChange        float64
 	ViewsPerVisitorChange float64
-	BounceRateChange     float64
+	BounceRateChange      float64
+	VisitDurationChange   float64
 
 	PageviewsOverTime []TimeBucket
 	VisitorsOverTime  []TimeBucket
-	TopPages          []BreakdownItem
-	TopReferrers      []BreakdownItem
-	Browsers          []BreakdownItem
-	OSes              []BreakdownItem
-	Devices           []BreakdownItem
-	TopCountries      []GeoBreakdownItem
-	TopCities         []GeoBreakdownItem
-	TopEvents         []BreakdownItem
-	EventsOverTime    []TimeBucket
+	// Every breakdown is limited to Filters. TopPages lists the URLs of the
+	// filtered page group instead, if any, and Browsers, OSes and Devices
+	// the details of the browser, operating system or device filtered on.
+	Filters  DashboardFilters
+	TopPages []PageItem
+	// Hostnames counts pageviews by host.
+	Hostnames      []BreakdownItem
+	PageEngagement []PageEngagementItem
+	EntryPages     []BreakdownItem
+	ExitPages      []BreakdownItem
+	// TopReferrers counts visits by referrer source, with search engines,
+	// social networks and email clients collapsed into one name each.
+	TopReferrers []BreakdownItem
+	Channels     []BreakdownItem
+	// UTM breakdowns count visits by the campaign that started them.
+	UTMSources   []BreakdownItem
+	UTMMediums   []BreakdownItem
+	UTMCampaigns []BreakdownItem
+	Browsers     []BreakdownItem
+	OSes         []BreakdownItem
+	Devices      []BreakdownItem
+	ScreenSizes  []BreakdownItem
+	// Languages counts pageviews by language, or by locale once a language
+	// is filtered on.
+	Languages       []BreakdownItem
+	TopCountries    []GeoBreakdownItem
+	TopCities       []GeoBreakdownItem
+	TopEvents       []BreakdownItem
+	EventsOverTime  []TimeBucket
+	OutboundLinks   []BreakdownItem
+	FileDownloads   []BreakdownItem
+	NotFoundPages   []BreakdownItem
+	FormSubmissions []BreakdownItem
+
+	// BotHitsExcluded counts hits dropped by bot filtering in the range.
+	BotHitsExcluded int64
 }
 
 func GetDashboardStats(
@@ -133,6 +370,7 @@ func GetDashboardStats(
 	prevStartDate time.Time,
 	prevEndDate time.Time,
 	bucket string,
+	filters DashboardFilters,
 ) (DashboardStats, error) {
 	dateParams := func() (pgtype.Timestamptz, pgtype.Timestamptz) {
 		return pgtype.Timestamptz{Time: startDate, Valid: true},
@@ -142,11 +380,19 @@ func GetDashboardStats(
 
 	prevStart := pgtype.Timestamptz{Time: prevStartDate, Valid: true}
 	prevEnd := pgtype.Timestamptz{Time: prevEndDate, Valid: true}
+	hostnameFilter := pgtype.Text{String: filters.Hostname, Valid: filters.Hostname != ""}
+	languageFilter := pgtype.Text{String: filters.Language, Valid: filters.Language != ""}
+	filtersJSON := filters.filtersJSON()
+	// Time series are bucketed in the timezone the range was given in.
+	timezone := startDate.Location().String()
 
 	total, err := queries.QueryTotalPageviews(ctx, exec, db.QueryTotalPageviewsParams{
 		WebsiteID: websiteID,
 		StartDate: start,
 		EndDate:   end,
+		Hostname:  hostnameFilter,
+		Language:  languageFilter,
+		Filters:   filtersJSON,
 	})
 	if err != nil {
 		return DashboardStats{}, err
@@ -156,15 +402,21 @@ func GetDashboardStats(
 		WebsiteID: websiteID,
 		StartDate: start,
 		EndDate:   end,
+		Hostname:  hostnameFilter,
+		Language:  languageFilter,
+		Filters:   filtersJSON,
 	})
 	if err != nil {
 		return DashboardStats{}, err
 	}
 
-	bounceCount, err := queries.QueryBounceCount(ctx, exec, db.QueryBounceCountParams{
+	sessions, err := queries.QuerySessionTotals(ctx, exec, db.QuerySessionTotalsParams{
 		WebsiteID: websiteID,
 		StartDate: start,
 		EndDate:   end,
+		Hostname:  hostnameFilter,
+		Language:  languageFilter,
+		Filters:   filtersJSON,
 	})
 	if err != nil {
 		return DashboardStats{}, err
@@ -175,6 +427,9 @@ func GetDashboardStats(
 		WebsiteID: websiteID,
 		StartDate: prevStart,
 		EndDate:   prevEnd,
+		Hostname:  hostnameFilter,
+		Language:  languageFilter,
+		Filters:   filtersJSON,
 	})
 	if err != nil {
 		return DashboardStats{}, err
@@ -184,31 +439,41 @@ func GetDashboardStats(
 		WebsiteID: websiteID,
 		StartDate: prevStart,
 		EndDate:   prevEnd,
+		Hostname:  hostnameFilter,
+		Language:  languageFilter,
+		Filters:   filtersJSON,
 	})
 	if err != nil {
 		return DashboardStats{}, err
 	}
 
-	prevBounce, err := queries.QueryBounceCount(ctx, exec, db.QueryBounceCountParams{
+	prevSessions, err := queries.QuerySessionTotals(ctx, exec, db.QuerySessionTotalsParams{
 		WebsiteID: websiteID,
 		StartDate: prevStart,
 		EndDate:   prevEnd,
+		Hostname:  hostnameFilter,
+		Language:  languageFilter,
+		Filters:   filtersJSON,
 	})
 	if err != nil {
 		return DashboardStats{}, err
 	}
 
 	// Compute derived metrics
-	viewsPerVisitor := computeRatio(total, totalUnique)
-	prevViewsPerVisitor := computeRatio(prevTotal, prevUnique)
-	bounceRate := computeRatio(bounceCount*100, totalUnique)
-	prevBounceRate := computeRatio(prevBounce*100, prevUnique)
+	viewsPerVisitor := computeRatio(total, sessions.Sessions)
+	prevViewsPerVisitor := computeRatio(prevTotal, prevSessions.Sessions)
+	bounceRate := computeRatio(sessions.Bounces*100, sessions.Sessions)
+	prevBounceRate := computeRatio(prevSessions.Bounces*100, prevSessions.Sessions)
 
 	pvBucketRows, err := queries.QueryPageviewsTimeBucketed(ctx, exec, db.QueryPageviewsTimeBucketedParams{
 		WebsiteID: websiteID,
 		Bucket:    bucket,
+		Timezone:  timezone,
 		StartDate: start,
 		EndDate:   end,
+		Hostname:  hostnameFilter,
+		Language:  languageFilter,
+		Filters:   filtersJSON,
 	})
 	if err != nil {
 		return DashboardStats{}, err
@@ -223,8 +488,12 @@ func GetDashboardStats(
 	uvBucketRows, err := queries.QueryUniqueVisitorsTimeBucketed(ctx, exec, db.QueryUniqueVisitorsTimeBucketedParams{
 		WebsiteID: websiteID,
 		Bucket:    bucket,
+		Timezone:  timezone,
 		StartDate: start,
 		EndDate:   end,
+		Hostname:  hostnameFilter,
+		Language:  languageFilter,
+		Filters:   filtersJSON,
 	})
 	if err != nil {
 		return DashboardStats{}, err
@@ -236,80 +505,376 @@ func GetDashboardStats(
 	}
 	uvOverTime := fillTimeBuckets(uvSparse, startDate, endDate, bucket)
 
-	topPagesRows, err := queries.QueryTopPages(ctx, exec, db.QueryTopPagesParams{
+	var topPages []PageItem
+	if filters.PageGroup != "" {
+		groupRows, err := queries.QueryTopPagesInGroup(ctx, exec, db.QueryTopPagesInGroupParams{
+			WebsiteID: websiteID,
+			StartDate: start,
+			EndDate:   end,
+			Hostname:  hostnameFilter,
+			Language:  languageFilter,
+			Filters:   filtersJSON,
+			PageGroup: filters.PageGroup,
+		})
+		if err != nil {
+			return DashboardStats{}, err
+		}
+
+		topPages = make([]PageItem, len(groupRows))
+		for i, row := range groupRows {
+			topPages[i] = PageItem{Name: row.Url, Views: row.Views}
+		}
+	} else {
+		topPagesRows, err := queries.QueryTopPages(ctx, exec, db.QueryTopPagesParams{
+			WebsiteID: websiteID,
+			StartDate: start,
+			EndDate:   end,
+			Hostname:  hostnameFilter,
+			Language:  languageFilter,
+			Filters:   filtersJSON,
+		})
+		if err != nil {
+			return DashboardStats{}, err
+		}
+
+		topPages = make([]PageItem, len(topPagesRows))
+		for i, row := range topPagesRows {
+			topPages[i] = PageItem{Name: row.Page, Views: row.Views, Grouped: row.Grouped}
+		}
+	}
+
+	hostnameRows, err := queries.QueryTopHostnames(ctx, exec, db.QueryTopHostnamesParams{
 		WebsiteID: websiteID,
 		StartDate: start,
 		EndDate:   end,
+		Language:  languageFilter,
+		Filters:   filtersJSON,
 	})
 	if err != nil {
 		return DashboardStats{}, err
 	}
 
-	topPages := make([]BreakdownItem, len(topPagesRows))
-	for i, row := range topPagesRows {
-		topPages[i] = BreakdownItem{Name: row.Url, Views: row.Views}
+	hostnames := make([]BreakdownItem, len(hostnameRows))
+	for i, row := range hostnameRows {
+		hostnames[i] = BreakdownItem{Name: row.Hostname.String, Views: row.Views}
 	}
 
-	topRefRows, err := queries.QueryTopReferrers(ctx, exec, db.QueryTopReferrersParams{
+	engagementRows, err := queries.QueryPageEngagement(ctx, exec, db.QueryPageEngagementParams{
 		WebsiteID: websiteID,
 		StartDate: start,
 		EndDate:   end,
+		Hostname:  hostnameFilter,
+		Language:  languageFilter,
+		Filters:   filtersJSON,
 	})
 	if err != nil {
 		return DashboardStats{}, err
 	}
 
-	topReferrers := make([]BreakdownItem, len(topRefRows))
-	for i, row := range topRefRows {
-		topReferrers[i] = BreakdownItem{Name: row.Referrer.String, Views: row.Views}
+	pageEngagement := make([]PageEngagementItem, len(engagementRows))
+	for i, row := range engagementRows {
+		pageEngagement[i] = PageEngagementItem{
+			URL:            row.Url,
+			Views:          row.Views,
+			AvgTimeOnPage:  row.AvgEngagedMs / 1000,
+			AvgScrollDepth: row.AvgScrollDepth,
+		}
 	}
 
-	browserRows, err := queries.QueryBrowserBreakdown(ctx, exec, db.QueryBrowserBreakdownParams{
+	entryRows, err := queries.QueryTopEntryPages(ctx, exec, db.QueryTopEntryPagesParams{
 		WebsiteID: websiteID,
 		StartDate: start,
 		EndDate:   end,
+		Hostname:  hostnameFilter,
+		Language:  languageFilter,
+		Filters:   filtersJSON,
 	})
 	if err != nil {
 		return DashboardStats{}, err
 	}
 
-	browsers := make([]BreakdownItem, len(browserRows))
-	for i, row := range browserRows {
-		browsers[i] = BreakdownItem{Name: row.Browser.String, Views: row.Views}
+	entryPages := make([]BreakdownItem, len(entryRows))
+	for i, row := range entryRows {
+		entryPages[i] = BreakdownItem{Name: row.EntryPage.String, Views: row.Sessions}
 	}
 
-	osRows, err := queries.QueryOSBreakdown(ctx, exec, db.QueryOSBreakdownParams{
+	exitRows, err := queries.QueryTopExitPages(ctx, exec, db.QueryTopExitPagesParams{
 		WebsiteID: websiteID,
 		StartDate: start,
 		EndDate:   end,
+		Hostname:  hostnameFilter,
+		Language:  languageFilter,
+		Filters:   filtersJSON,
 	})
 	if err != nil {
 		return DashboardStats{}, err
 	}
 
-	oses := make([]BreakdownItem, len(osRows))
-	for i, row := range osRows {
-		oses[i] = BreakdownItem{Name: row.Os.String, Views: row.Views}
+	exitPages := make([]BreakdownItem, len(exitRows))
+	for i, row := range exitRows {
+		exitPages[i] = BreakdownItem{Name: row.ExitPage.String, Views: row.Sessions}
 	}
 
-	deviceRows, err := queries.QueryDeviceBreakdown(ctx, exec, db.QueryDeviceBreakdownParams{
+	sourceRows, err := queries.QueryTopUTMSources(ctx, exec, db.QueryTopUTMSourcesParams{
 		WebsiteID: websiteID,
 		StartDate: start,
 		EndDate:   end,
+		Hostname:  hostnameFilter,
+		Language:  languageFilter,
+		Filters:   filtersJSON,
 	})
 	if err != nil {
 		return DashboardStats{}, err
 	}
 
-	devices := make([]BreakdownItem, len(deviceRows))
-	for i, row := range deviceRows {
-		devices[i] = BreakdownItem{Name: row.Device.String, Views: row.Views}
+	utmSources := make([]BreakdownItem, len(sourceRows))
+	for i, row := range sourceRows {
+		utmSources[i] = BreakdownItem{Name: row.UtmSource.String, Views: row.Sessions}
+	}
+
+	mediumRows, err := queries.QueryTopUTMMediums(ctx, exec, db.QueryTopUTMMediumsParams{
+		WebsiteID: websiteID,
+		StartDate: start,
+		EndDate:   end,
+		Hostname:  hostnameFilter,
+		Language:  languageFilter,
+		Filters:   filtersJSON,
+	})
+	if err != nil {
+		return DashboardStats{}, err
+	}
+
+	utmMediums := make([]BreakdownItem, len(mediumRows))
+	for i, row := range mediumRows {
+		utmMediums[i] = BreakdownItem{Name: row.UtmMedium.String, Views: row.Sessions}
+	}
+
+	campaignRows, err := queries.QueryTopUTMCampaigns(ctx, exec, db.QueryTopUTMCampaignsParams{
+		WebsiteID: websiteID,
+		StartDate: start,
+		EndDate:   end,
+		Hostname:  hostnameFilter,
+		Language:  languageFilter,
+		Filters:   filtersJSON,
+	})
+	if err != nil {
+		return DashboardStats{}, err
+	}
+
+	utmCampaigns := make([]BreakdownItem, len(campaignRows))
+	for i, row := range campaignRows {
+		utmCampaigns[i] = BreakdownItem{Name: row.UtmCampaign.String, Views: row.Sessions}
+	}
+
+	topRefRows, err := queries.QueryTopReferrerSources(ctx, exec, db.QueryTopReferrerSourcesParams{
+		WebsiteID: websiteID,
+		StartDate: start,
+		EndDate:   end,
+		Hostname:  hostnameFilter,
+		Language:  languageFilter,
+		Filters:   filtersJSON,
+	})
+	if err != nil {
+		return DashboardStats{}, err
+	}
+
+	topReferrers := make([]BreakdownItem, len(topRefRows))
+	for i, row := range topRefRows {
+		topReferrers[i] = BreakdownItem{Name: row.ReferrerSource.String, Views: row.Sessions}
+	}
+
+	channelRows, err := queries.QueryTopChannels(ctx, exec, db.QueryTopChannelsParams{
+		WebsiteID: websiteID,
+		StartDate: start,
+		EndDate:   end,
+		Hostname:  hostnameFilter,
+		Language:  languageFilter,
+		Filters:   filtersJSON,
+	})
+	if err != nil {
+		return DashboardStats{}, err
+	}
+
+	channels := make([]BreakdownItem, len(channelRows))
+	for i, row := range channelRows {
+		channels[i] = BreakdownItem{Name: row.Channel.String, Views: row.Sessions}
+	}
+
+	var browsers []BreakdownItem
+	if filters.Is(FilterBrowser) != "" {
+		versionRows, err := queries.QueryBrowserVersionBreakdown(ctx, exec, db.QueryBrowserVersionBreakdownParams{
+			WebsiteID: websiteID,
+			StartDate: start,
+			EndDate:   end,
+			Hostname:  hostnameFilter,
+			Language:  languageFilter,
+			Filters:   filtersJSON,
+			Browser:   filters.Is(FilterBrowser),
+		})
+		if err != nil {
+			return DashboardStats{}, err
+		}
+
+		browsers = make([]BreakdownItem, len(versionRows))
+		for i, row := range versionRows {
+			browsers[i] = BreakdownItem{Name: detailName(filters.Is(FilterBrowser), row.Version), Views: row.Views}
+		}
+	} else {
+		browserRows, err := queries.QueryBrowserBreakdown(ctx, exec, db.QueryBrowserBreakdownParams{
+			WebsiteID: websiteID,
+			StartDate: start,
+			EndDate:   end,
+			Hostname:  hostnameFilter,
+			Language:  languageFilter,
+			Filters:   filtersJSON,
+		})
+		if err != nil {
+			return DashboardStats{}, err
+		}
+
+		browsers = make([]BreakdownItem, len(browserRows))
+		for i, row := range browserRows {
+			browsers[i] = BreakdownItem{Name: row.Browser.String, Views: row.Views}
+		}
+	}
+
+	var oses []BreakdownItem
+	if filters.Is(FilterOS) != "" {
+		versionRows, err := queries.QueryOSVersionBreakdown(ctx, exec, db.QueryOSVersionBreakdownParams{
+			WebsiteID: websiteID,
+			StartDate: start,
+			EndDate:   end,
+			Hostname:  hostnameFilter,
+			Language:  languageFilter,
+			Filters:   filtersJSON,
+			Os:        filters.Is(FilterOS),
+		})
+		if err != nil {
+			return DashboardStats{}, err
+		}
+
+		oses = make([]BreakdownItem, len(versionRows))
+		for i, row := range versionRows {
+			oses[i] = BreakdownItem{Name: detailName(filters.Is(FilterOS), row.Version), Views: row.Views}
+		}
+	} else {
+		osRows, err := queries.QueryOSBreakdown(ctx, exec, db.QueryOSBreakdownParams{
+			WebsiteID: websiteID,
+			StartDate: start,
+			EndDate:   end,
+			Hostname:  hostnameFilter,
+			Language:  languageFilter,
+			Filters:   filtersJSON,
+		})
+		if err != nil {
+			return DashboardStats{}, err
+		}
+
+		oses = make([]BreakdownItem, len(osRows))
+		for i, row := range osRows {
+			oses[i] = BreakdownItem{Name: row.Os.String, Views: row.Views}
+		}
+	}
+
+	var devices []BreakdownItem
+	if filters.Is(FilterDevice) != "" {
+		modelRows, err := queries.QueryDeviceModelBreakdown(ctx, exec, db.QueryDeviceModelBreakdownParams{
+			WebsiteID: websiteID,
+			StartDate: start,
+			EndDate:   end,
+			Hostname:  hostnameFilter,
+			Language:  languageFilter,
+			Filters:   filtersJSON,
+			Device:    filters.Is(FilterDevice),
+		})
+		if err != nil {
+			return DashboardStats{}, err
+		}
+
+		devices = make([]BreakdownItem, len(modelRows))
+		for i, row := range modelRows {
+			devices[i] = BreakdownItem{Name: detailName("", row.Model), Views: row.Views}
+		}
+	} else {
+		deviceRows, err := queries.QueryDeviceBreakdown(ctx, exec, db.QueryDeviceBreakdownParams{
+			WebsiteID: websiteID,
+			StartDate: start,
+			EndDate:   end,
+			Hostname:  hostnameFilter,
+			Language:  languageFilter,
+			Filters:   filtersJSON,
+		})
+		if err != nil {
+			return DashboardStats{}, err
+		}
+
+		devices = make([]BreakdownItem, len(deviceRows))
+		for i, row := range deviceRows {
+			devices[i] = BreakdownItem{Name: row.Device.String, Views: row.Views}
+		}
+	}
+
+	// Once a language is picked its locales are listed instead.
+	var languages []BreakdownItem
+	if filters.Language != "" {
+		localeRows, err := queries.QueryLocaleBreakdown(ctx, exec, db.QueryLocaleBreakdownParams{
+			WebsiteID: websiteID,
+			StartDate: start,
+			EndDate:   end,
+			Hostname:  hostnameFilter,
+			Language:  languageFilter,
+			Filters:   filtersJSON,
+		})
+		if err != nil {
+			return DashboardStats{}, err
+		}
+
+		languages = make([]BreakdownItem, len(localeRows))
+		for i, row := range localeRows {
+			languages[i] = BreakdownItem{Name: row.Language.String, Views: row.Views}
+		}
+	} else {
+		languageRows, err := queries.QueryLanguageBreakdown(ctx, exec, db.QueryLanguageBreakdownParams{
+			WebsiteID: websiteID,
+			StartDate: start,
+			EndDate:   end,
+			Hostname:  hostnameFilter,
+			Filters:   filtersJSON,
+		})
+		if err != nil {
+			return DashboardStats{}, err
+		}
+
+		languages = make([]BreakdownItem, len(languageRows))
+		for i, row := range languageRows {
+			languages[i] = BreakdownItem{Name: row.Language, Views: row.Views}
+		}
+	}
+
+	screenSizeRows, err := queries.QueryScreenSizeBreakdown(ctx, exec, db.QueryScreenSizeBreakdownParams{
+		WebsiteID: websiteID,
+		StartDate: start,
+		EndDate:   end,
+		Hostname:  hostnameFilter,
+		Language:  languageFilter,
+		Filters:   filtersJSON,
+	})
+	if err != nil {
+		return DashboardStats{}, err
+	}
+
+	screenSizes := make([]BreakdownItem, len(screenSizeRows))
+	for i, row := range screenSizeRows {
+		screenSizes[i] = BreakdownItem{Name: row.ScreenSize.String, Views: row.Views}
 	}
 
 	countryRows, err := queries.QueryTopCountries(ctx, exec, db.QueryTopCountriesParams{
 		WebsiteID: websiteID,
 		StartDate: start,
 		EndDate:   end,
+		Hostname:  hostnameFilter,
+		Language:  languageFilter,
+		Filters:   filtersJSON,
 	})
 	if err != nil {
 		return DashboardStats{}, err
@@ -324,6 +889,9 @@ func GetDashboardStats(
 		WebsiteID: websiteID,
 		StartDate: start,
 		EndDate:   end,
+		Hostname:  hostnameFilter,
+		Language:  languageFilter,
+		Filters:   filtersJSON,
 	})
 	if err != nil {
 		return DashboardStats{}, err
@@ -338,6 +906,9 @@ func GetDashboardStats(
 		WebsiteID: websiteID,
 		StartDate: start,
 		EndDate:   end,
+		Hostname:  hostnameFilter,
+		Language:  languageFilter,
+		Filters:   filtersJSON,
 	})
 	if err != nil {
 		return DashboardStats{}, err
@@ -351,8 +922,12 @@ func GetDashboardStats(
 	eventBucketRows, err := queries.QueryEventsTimeBucketed(ctx, exec, db.QueryEventsTimeBucketedParams{
 		WebsiteID: websiteID,
 		Bucket:    bucket,
+		Timezone:  timezone,
 		StartDate: start,
 		EndDate:   end,
+		Hostname:  hostnameFilter,
+		Language:  languageFilter,
+		Filters:   filtersJSON,
 	})
 	if err != nil {
 		return DashboardStats{}, err
@@ -364,30 +939,135 @@ func GetDashboardStats(
 	}
 	eventsOverTime := fillTimeBuckets(eventsSparse, startDate, endDate, bucket)
 
+	outboundLinks, err := eventPropertyBreakdown(ctx, exec, websiteID, EventOutboundLink, "url", start, end, hostnameFilter, languageFilter, filtersJSON)
+	if err != nil {
+		return DashboardStats{}, err
+	}
+
+	fileDownloads, err := eventPropertyBreakdown(ctx, exec, websiteID, EventFileDownload, "url", start, end, hostnameFilter, languageFilter, filtersJSON)
+	if err != nil {
+		return DashboardStats{}, err
+	}
+
+	notFoundPages, err := eventPropertyBreakdown(ctx, exec, websiteID, EventNotFound, "path", start, end, hostnameFilter, languageFilter, filtersJSON)
+	if err != nil {
+		return DashboardStats{}, err
+	}
+
+	formSubmissions, err := eventPropertyBreakdown(ctx, exec, websiteID, EventFormSubmission, "form", start, end, hostnameFilter, languageFilter, filtersJSON)
+	if err != nil {
+		return DashboardStats{}, err
+	}
+
+	botHits, err := queries.QueryDroppedHitsForReason(ctx, exec, db.QueryDroppedHitsForReasonParams{
+		WebsiteID: websiteID,
+		Reason:    DroppedReasonBot,
+		StartDate: calendarDate(startDate),
+		EndDate:   calendarDate(endDate),
+	})
+	if err != nil {
+		return DashboardStats{}, err
+	}
+
 	return DashboardStats{
 		TotalPageviews:        total,
 		TotalUniqueVisitors:   totalUnique,
-		BounceCount:           bounceCount,
+		TotalSessions:         sessions.Sessions,
+		BounceCount:           sessions.Bounces,
 		ViewsPerVisitor:       viewsPerVisitor,
 		BounceRate:            bounceRate,
+		AvgVisitDuration:      sessions.AvgDurationSeconds,
+		VisitDurationChange:   percentChangeFloat(prevSessions.AvgDurationSeconds, sessions.AvgDurationSeconds),
 		PageviewsChange:       percentChange(prevTotal, total),
 		UniqueVisitorsChange:  percentChange(prevUnique, totalUnique),
+		SessionsChange:        percentChange(prevSessions.Sessions, sessions.Sessions),
 		ViewsPerVisitorChange: percentChangeFloat(prevViewsPerVisitor, viewsPerVisitor),
 		BounceRateChange:      -percentChangeFloat(prevBounceRate, bounceRate), // negate: decrease is good
 		PageviewsOverTime:     pvOverTime,
 		VisitorsOverTime:      uvOverTime,
 		TopPages:              topPages,
+		Filters:               filters,
+		Hostnames:             hostnames,
+		PageEngagement:        pageEngagement,
+		EntryPages:            entryPages,
+		ExitPages:             exitPages,
 		TopReferrers:          topReferrers,
+		Channels:              channels,
+		UTMSources:            utmSources,
+		UTMMediums:            utmMediums,
+		UTMCampaigns:          utmCampaigns,
 		Browsers:              browsers,
 		OSes:                  oses,
 		Devices:               devices,
+		ScreenSizes:           screenSizes,
+		Languages:             languages,
 		TopCountries:          countries,
 		TopCities:             cities,
 		TopEvents:             topEvents,
 		EventsOverTime:        eventsOverTime,
+		OutboundLinks:         outboundLinks,
+		FileDownloads:         fileDownloads,
+		NotFoundPages:         notFoundPages,
+		FormSubmissions:       formSubmissions,
+		BotHitsExcluded:       botHits,
 	}, nil
 }
 
+// eventPropertyBreakdown counts the events with the given name by one of the
+// properties in their data.
+func eventPropertyBreakdown(
+	ctx context.Context,
+	exec storage.Executor,
+	websiteID uuid.UUID,
+	eventName string,
+	property string,
+	start pgtype.Timestamptz,
+	end pgtype.Timestamptz,
+	hostname pgtype.Text,
+	language pgtype.Text,
+	filters []byte,
+) ([]BreakdownItem, error) {
+	rows, err := queries.QueryEventPropertyBreakdown(ctx, exec, db.QueryEventPropertyBreakdownParams{
+		WebsiteID: websiteID,
+		Property:  property,
+		EventName: eventName,
+		StartDate: start,
+		EndDate:   end,
+		Hostname:  hostname,
+		Language:  language,
+		Filters:   filters,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]BreakdownItem, len(rows))
+	for i, row := range rows {
+		items[i] = BreakdownItem{Name: row.Value, Views: row.EventCount}
+	}
+
+	return items, nil
+}
+
+// detailName names a drill-down row such as "Chrome 120". Rows recorded
+// before details were parsed, or without them, are "Unknown".
+func detailName(parent, detail string) string {
+	if detail == "" {
+		return "Unknown"
+	}
+	if parent == "" {
+		return detail
+	}
+	return parent + " " + detail
+}
+
+// calendarDate returns the date t falls on in the website's timezone, which
+// t is in. Converting to UTC first would move local midnight onto the
+// previous day for zones east of UTC.
+func calendarDate(t time.Time) pgtype.Date {
+	return pgtype.Date{Time: time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC), Valid: true}
+}
+
 // fillTimeBuckets generates a complete time series from startDate to endDate
 // with the given bucket granularity, filling in zeros for missing buckets.
 func fillTimeBuckets(sparse []TimeBucket, startDate, endDate time.Time, bucket string) []TimeBucket {
@@ -401,15 +1081,15 @@ func fillTimeBuckets(sparse []TimeBucket, startDate, endDate time.Time, bucket s
 	start := truncateToBucket(startDate, bucket)
 	end := endDate
 
-	var step time.Duration
+	// Days are stepped through on the calendar, as they are 23 or 25 hours
+	// long when daylight saving time begins or ends.
+	next := func(t time.Time) time.Time { return t.AddDate(0, 0, 1) }
 	if bucket == "hour" {
-		step = time.Hour
-	} else {
-		step = 24 * time.Hour
+		next = func(t time.Time) time.Time { return t.Add(time.Hour) }
 	}
 
 	var result []TimeBucket
-	for t := start; !t.After(end); t = t.Add(step) {
+	for t := start; !t.After(end); t = next(t) {
 		count := existing[t.Unix()]
 		result = append(result, TimeBucket{Time: t, Count: count})
 	}
@@ -453,21 +1133,40 @@ func computeRatio(numerator, denominator int64) float64 {
 
 func rowToPageview(row db.Pageview) Pageview {
 	return Pageview{
-		ID:          row.ID,
-		CreatedAt:   row.CreatedAt.Time,
-		WebsiteID:   row.WebsiteID,
-		URL:         row.Url,
-		Referrer:    row.Referrer.String,
-		Browser:     row.Browser.String,
-		OS:          row.Os.String,
-		Device:      row.Device.String,
-		Country:     row.Country.String,
-		Language:    row.Language.String,
-		ScreenWidth: row.ScreenWidth.Int32,
-		VisitorHash: row.VisitorHash.String,
-		CountryCode: row.CountryCode.String,
-		CountryName: row.CountryName.String,
-		City:        row.City.String,
-		Region:      row.Region.String,
+		ID:             row.ID,
+		CreatedAt:      row.CreatedAt.Time,
+		WebsiteID:      row.WebsiteID,
+		URL:            row.Url,
+		Referrer:       row.Referrer.String,
+		Browser:        row.Browser.String,
+		OS:             row.Os.String,
+		Device:         row.Device.String,
+		Country:        row.Country.String,
+		Language:       row.Language.String,
+		ScreenWidth:    row.ScreenWidth.Int32,
+		VisitorHash:    row.VisitorHash.String,
+		CountryCode:    row.CountryCode.String,
+		CountryName:    row.CountryName.String,
+		City:           row.City.String,
+		Region:         row.Region.String,
+		SessionID:      uuid.UUID(row.SessionID.Bytes),
+		EngagedMs:      row.EngagedMs.Int32,
+		ScrollDepth:    row.ScrollDepth.Int16,
+		UTMSource:      row.UtmSource.String,
+		UTMMedium:      row.UtmMedium.String,
+		UTMCampaign:    row.UtmCampaign.String,
+		UTMTerm:        row.UtmTerm.String,
+		UTMContent:     row.UtmContent.String,
+		ClickID:        row.ClickID.String,
+		ReferrerSource: row.ReferrerSource.String,
+		PageGroup:      row.PageGroup.String,
+		Hostname:       row.Hostname.String,
+		BrowserVersion: row.BrowserVersion.String,
+		OSVersion:      row.OsVersion.String,
+		DeviceBrand:    row.DeviceBrand.String,
+		DeviceModel:    row.DeviceModel.String,
+		ViewportWidth:  row.ViewportWidth.Int32,
+		PixelRatio:     row.PixelRatio.Float32,
+		ScreenSize:     row.ScreenSize.String,
 	}
 }
@@ -0,0 +1,107 @@
+package models_test
+
+import (
+	"context"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+
+	"palantir/models"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// recordingExecutor captures the arguments of Exec calls and reports
+// rowsAffected rows changed. Queries aren't supported.
+type recordingExecutor struct {
+	rowsAffected int64
+	execs        [][]any
+}
+
+func (e *recordingExecutor) Exec(_ context.Context, _ string, args ...any) (pgconn.CommandTag, error) {
+	e.execs = append(e.execs, args)
+	return pgconn.NewCommandTag("UPDATE " + strconv.FormatInt(e.rowsAffected, 10)), nil
+}
+
+func (e *recordingExecutor) Query(context.Context, string, ...any) (pgx.Rows, error) {
+	panic("unexpected query")
+}
+
+func (e *recordingExecutor) QueryRow(context.Context, string, ...any) pgx.Row {
+	panic("unexpected query")
+}
+
+func TestCalendarDate(t *testing.T) {
+	copenhagen, err := time.LoadLocation("Europe/Copenhagen")
+	if err != nil {
+		t.Fatal(err)
+	}
+	losAngeles, err := time.LoadLocation("America/Los_Angeles")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		at   time.Time
+		want string
+	}{
+		{name: "utc", at: time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), want: "2026-03-01"},
+		{name: "local midnight east of utc", at: time.Date(2026, 3, 1, 0, 0, 0, 0, copenhagen), want: "2026-03-01"},
+		{name: "end of day west of utc", at: time.Date(2026, 3, 31, 23, 59, 59, 0, losAngeles), want: "2026-03-31"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := models.CalendarDate(tt.at)
+			if !got.Valid || got.Time.Format(time.DateOnly) != tt.want {
+				t.Errorf("CalendarDate() = %v, want %s", got.Time, tt.want)
+			}
+		})
+	}
+}
+
+func TestRecordPageviewEngagement(t *testing.T) {
+	websiteID := uuid.New()
+	sentAt := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	exec := &recordingExecutor{rowsAffected: 1}
+	updated, err := models.RecordPageviewEngagement(t.Context(), exec, []models.PageviewEngagement{
+		{WebsiteID: websiteID, VisitorHash: "visitor", URL: "/a", SentAt: sentAt, EngagedMs: 5_000, ScrollDepth: 40},
+		{WebsiteID: websiteID, VisitorHash: "visitor", URL: "/a", SentAt: sentAt.Add(time.Second), EngagedMs: 9_000, ScrollDepth: 80},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if updated != 1 {
+		t.Errorf("updated %d pageviews, want 1", updated)
+	}
+	if len(exec.execs) != 1 {
+		t.Fatalf("got %d statements, want 1", len(exec.execs))
+	}
+
+	args := exec.execs[0]
+	if got := args[0].([]int32); !slices.Equal(got, []int32{0, 1}) {
+		t.Errorf("positions = %v, want [0 1]", got)
+	}
+	if got := args[5].([]int32); !slices.Equal(got, []int32{5_000, 9_000}) {
+		t.Errorf("engaged ms = %v, want [5000 9000]", got)
+	}
+	if got := args[6].([]int16); !slices.Equal(got, []int16{40, 80}) {
+		t.Errorf("scroll depths = %v, want [40 80]", got)
+	}
+}
+
+func TestRecordPageviewEngagementEmpty(t *testing.T) {
+	exec := &recordingExecutor{}
+	if _, err := models.RecordPageviewEngagement(t.Context(), exec, nil); err != nil {
+		t.Fatal(err)
+	}
+	if len(exec.execs) != 0 {
+		t.Errorf("got %d statements, want none", len(exec.execs))
+	}
+}
@@ -0,0 +1,193 @@
+package models
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"palantir/internal/storage"
+	"palantir/models/internal/db"
+)
+
+// SessionTimeout is how long a visitor can be inactive before their next hit
+// starts a new session.
+const SessionTimeout = 30 * time.Minute
+
+// Session is a single visit: the hits of one visitor to one website with no
+// gap longer than SessionTimeout between them.
+type Session struct {
+	ID          uuid.UUID
+	WebsiteID   uuid.UUID
+	VisitorHash string
+	StartedAt   time.Time
+	EndedAt     time.Time
+	EntryPage   string
+	ExitPage    string
+	Pageviews   int32
+	Events      int32
+	Referrer    string
+	UTMSource   string
+	UTMMedium   string
+	UTMCampaign string
+	UTMTerm     string
+	UTMContent  string
+	ClickID     string
+	// ReferrerSource and Channel attribute the visit; see
+	// services.ParseReferrer and services.Channel.
+	ReferrerSource string
+	Channel        string
+	// Hostname is the host the visit entered the website on.
+	Hostname string
+	Language string
+}
+
+type SessionVisitor struct {
+	WebsiteID   uuid.UUID
+	VisitorHash string
+}
+
+// LockSessionVisitors takes a lock per visitor that is held until exec's
+// transaction ends. Holding it while finding and upserting sessions keeps
+// concurrent writers from each starting a session for the same visitor.
+func LockSessionVisitors(
+	ctx context.Context,
+	exec storage.Executor,
+	visitors []SessionVisitor,
+) error {
+	if len(visitors) == 0 {
+		return nil
+	}
+
+	params := db.LockSessionVisitorsParams{
+		WebsiteIds:    make([]uuid.UUID, len(visitors)),
+		VisitorHashes: make([]string, len(visitors)),
+	}
+	for i, visitor := range visitors {
+		params.WebsiteIds[i] = visitor.WebsiteID
+		params.VisitorHashes[i] = visitor.VisitorHash
+	}
+
+	return queries.LockSessionVisitors(ctx, exec, params)
+}
+
+// FindRecentSessions returns the latest session of each visitor that ended
+// at or after since.
+func FindRecentSessions(
+	ctx context.Context,
+	exec storage.Executor,
+	visitors []SessionVisitor,
+	since time.Time,
+) ([]Session, error) {
+	if len(visitors) == 0 {
+		return nil, nil
+	}
+
+	params := db.QueryRecentSessionsParams{
+		WebsiteIds:    make([]uuid.UUID, len(visitors)),
+		VisitorHashes: make([]string, len(visitors)),
+		Since:         pgtype.Timestamptz{Time: since, Valid: true},
+	}
+	for i, visitor := range visitors {
+		params.WebsiteIds[i] = visitor.WebsiteID
+		params.VisitorHashes[i] = visitor.VisitorHash
+	}
+
+	rows, err := queries.QueryRecentSessions(ctx, exec, params)
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := make([]Session, len(rows))
+	for i, row := range rows {
+		sessions[i] = rowToSession(row)
+	}
+	return sessions, nil
+}
+
+// UpsertSessions creates sessions or extends existing ones. For an existing
+// session, Pageviews and Events are added to its counts and the boundaries,
+// entry and exit pages only change when the data reaches further out.
+// Referrer and UTM parameters are only set when a session is created.
+func UpsertSessions(
+	ctx context.Context,
+	exec storage.Executor,
+	sessions []Session,
+) error {
+	if len(sessions) == 0 {
+		return nil
+	}
+
+	params := db.UpsertSessionsParams{
+		Ids:             make([]uuid.UUID, len(sessions)),
+		WebsiteIds:      make([]uuid.UUID, len(sessions)),
+		VisitorHashes:   make([]string, len(sessions)),
+		StartedAts:      make([]pgtype.Timestamptz, len(sessions)),
+		EndedAts:        make([]pgtype.Timestamptz, len(sessions)),
+		EntryPages:      make([]string, len(sessions)),
+		ExitPages:       make([]string, len(sessions)),
+		PageviewCounts:  make([]int32, len(sessions)),
+		EventCounts:     make([]int32, len(sessions)),
+		Referrers:       make([]string, len(sessions)),
+		UtmSources:      make([]string, len(sessions)),
+		UtmMediums:      make([]string, len(sessions)),
+		UtmCampaigns:    make([]string, len(sessions)),
+		UtmTerms:        make([]string, len(sessions)),
+		UtmContents:     make([]string, len(sessions)),
+		ClickIds:        make([]string, len(sessions)),
+		ReferrerSources: make([]string, len(sessions)),
+		Channels:        make([]string, len(sessions)),
+		Hostnames:       make([]string, len(sessions)),
+		Languages:       make([]string, len(sessions)),
+	}
+	for i, s := range sessions {
+		params.Ids[i] = s.ID
+		params.WebsiteIds[i] = s.WebsiteID
+		params.VisitorHashes[i] = s.VisitorHash
+		params.StartedAts[i] = pgtype.Timestamptz{Time: s.StartedAt, Valid: true}
+		params.EndedAts[i] = pgtype.Timestamptz{Time: s.EndedAt, Valid: true}
+		params.EntryPages[i] = s.EntryPage
+		params.ExitPages[i] = s.ExitPage
+		params.PageviewCounts[i] = s.Pageviews
+		params.EventCounts[i] = s.Events
+		params.Referrers[i] = s.Referrer
+		params.UtmSources[i] = s.UTMSource
+		params.UtmMediums[i] = s.UTMMedium
+		params.UtmCampaigns[i] = s.UTMCampaign
+		params.UtmTerms[i] = s.UTMTerm
+		params.UtmContents[i] = s.UTMContent
+		params.ClickIds[i] = s.ClickID
+		params.ReferrerSources[i] = s.ReferrerSource
+		params.Channels[i] = s.Channel
+		params.Hostnames[i] = s.Hostname
+		params.Languages[i] = s.Language
+	}
+
+	return queries.UpsertSessions(ctx, exec, params)
+}
+
+func rowToSession(row db.Session) Session {
+	return Session{
+		ID:             row.ID,
+		WebsiteID:      row.WebsiteID,
+		VisitorHash:    row.VisitorHash,
+		StartedAt:      row.StartedAt.Time,
+		EndedAt:        row.EndedAt.Time,
+		EntryPage:      row.EntryPage.String,
+		ExitPage:       row.ExitPage.String,
+		Pageviews:      row.Pageviews,
+		Events:         row.Events,
+		Referrer:       row.Referrer.String,
+		UTMSource:      row.UtmSource.String,
+		UTMMedium:      row.UtmMedium.String,
+		UTMCampaign:    row.UtmCampaign.String,
+		UTMTerm:        row.UtmTerm.String,
+		UTMContent:     row.UtmContent.String,
+		ClickID:        row.ClickID.String,
+		ReferrerSource: row.ReferrerSource.String,
+		Channel:        row.Channel.String,
+		Hostname:       row.Hostname.String,
+		Language:       row.Language.String,
+	}
+}
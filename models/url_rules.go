@@ -0,0 +1,147 @@
+package models
+
+import (
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// Query parameter modes of a website's URL rules.
+const (
+	QueryParamModeKeep      = "keep"
+	QueryParamModeStrip     = "strip"
+	QueryParamModeAllowlist = "allowlist"
+)
+
+// NormalizeURL applies the website's URL rules to a hit URL, which may be a
+// path or an absolute URL. Only the path is lowercased and the fragment is
+// left alone, as hash based single page apps route on it.
+func (w Website) NormalizeURL(rawURL string) string {
+	rest, fragment := rawURL, ""
+	if i := strings.IndexByte(rest, '#'); i >= 0 {
+		rest, fragment = rest[:i], rest[i:]
+	}
+	base, rawQuery, hasQuery := strings.Cut(rest, "?")
+
+	origin, path := splitOrigin(base)
+	if w.LowercasePaths {
+		path = strings.ToLower(path)
+	}
+	if w.StripTrailingSlash && len(path) > 1 {
+		path = strings.TrimRight(path, "/")
+		if path == "" {
+			path = "/"
+		}
+	}
+
+	normalized := origin + path
+	if hasQuery {
+		if query := w.filterQuery(rawQuery); query != "" {
+			normalized += "?" + query
+		}
+	}
+	return normalized + fragment
+}
+
+// filterQuery drops the query parameters the website's rules exclude,
+// keeping the order and encoding of the rest.
+func (w Website) filterQuery(rawQuery string) string {
+	if w.QueryParamMode == QueryParamModeStrip {
+		return ""
+	}
+	allowlist := w.QueryParamMode == QueryParamModeAllowlist
+
+	var kept []string
+	for pair := range strings.SplitSeq(rawQuery, "&") {
+		if pair == "" {
+			continue
+		}
+
+		rawKey, _, _ := strings.Cut(pair, "=")
+		key, err := url.QueryUnescape(rawKey)
+		if err != nil {
+			key = rawKey
+		}
+		listed := slices.ContainsFunc(w.QueryParams, func(param string) bool {
+			return strings.EqualFold(param, key)
+		})
+		if listed == allowlist {
+			kept = append(kept, pair)
+		}
+	}
+
+	return strings.Join(kept, "&")
+}
+
+// PageGroup returns the first of the website's path patterns the path of a
+// normalized hit URL matches, or an empty string. Pattern segments starting
+// with ":" or consisting of "*" match any one path segment, and a trailing
+// "*" matches one or more, so both /blog/:slug and /orders/* work.
+func (w Website) PageGroup(normalizedURL string) string {
+	path := normalizedURL
+	if i := strings.IndexAny(path, "?#"); i >= 0 {
+		path = path[:i]
+	}
+	_, path = splitOrigin(path)
+
+	for _, pattern := range w.PathPatterns {
+		if matchPathPattern(pattern, path) {
+			return pattern
+		}
+	}
+
+	return ""
+}
+
+func matchPathPattern(pattern, path string) bool {
+	patternSegments := strings.Split(strings.Trim(pattern, "/"), "/")
+	pathSegments := strings.Split(strings.Trim(path, "/"), "/")
+
+	for i, segment := range patternSegments {
+		if segment == "*" && i == len(patternSegments)-1 {
+			return len(pathSegments) > i && pathSegments[i] != ""
+		}
+		if i >= len(pathSegments) {
+			return false
+		}
+
+		switch {
+		case segment == "*" || strings.HasPrefix(segment, ":"):
+			if pathSegments[i] == "" {
+				return false
+			}
+		case segment != pathSegments[i]:
+			return false
+		}
+	}
+
+	return len(pathSegments) == len(patternSegments)
+}
+
+// splitOrigin splits an absolute URL into its scheme and host and its path.
+// Paths are returned as they are.
+func splitOrigin(rawURL string) (string, string) {
+	scheme, rest, ok := strings.Cut(rawURL, "://")
+	if !ok {
+		return "", rawURL
+	}
+
+	if i := strings.IndexByte(rest, '/'); i >= 0 {
+		return scheme + "://" + rest[:i], rest[i:]
+	}
+	return rawURL, ""
+}
+
+// uniqueValues trims values and drops empty and repeated ones.
+func uniqueValues(values []string) []string {
+	unique := make([]string, 0, len(values))
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if value == "" || slices.Contains(unique, value) {
+			continue
+		}
+		unique = append(unique, value)
+	}
+
+	return unique
+}
@@ -0,0 +1,60 @@
+package models
+
+import (
+	"context"
+	"crypto/rand"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"palantir/internal/storage"
+	"palantir/models/internal/db"
+)
+
+const visitorSaltLength = 32
+
+// VisitorSalt is the random salt visitor hashes are computed with on a given
+// UTC day. Salts are deleted shortly after their day ends, after which hashes
+// from that day can't be linked to an IP and user agent anymore.
+type VisitorSalt struct {
+	Day       time.Time
+	CreatedAt time.Time
+	Salt      []byte
+}
+
+// FindOrCreateVisitorSalt returns the salt for the day, generating it if no
+// salt exists yet.
+func FindOrCreateVisitorSalt(
+	ctx context.Context,
+	exec storage.Executor,
+	day time.Time,
+) (VisitorSalt, error) {
+	salt := make([]byte, visitorSaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return VisitorSalt{}, err
+	}
+
+	row, err := queries.UpsertVisitorSalt(ctx, exec, db.UpsertVisitorSaltParams{
+		Day:  pgtype.Date{Time: day, Valid: true},
+		Salt: salt,
+	})
+	if err != nil {
+		return VisitorSalt{}, err
+	}
+
+	return VisitorSalt{
+		Day:       row.Day.Time,
+		CreatedAt: row.CreatedAt.Time,
+		Salt:      row.Salt,
+	}, nil
+}
+
+// DestroyVisitorSaltsBefore deletes the salts of every day before the given
+// one and returns how many were removed.
+func DestroyVisitorSaltsBefore(
+	ctx context.Context,
+	exec storage.Executor,
+	day time.Time,
+) (int64, error) {
+	return queries.DeleteVisitorSaltsBefore(ctx, exec, pgtype.Date{Time: day, Valid: true})
+}
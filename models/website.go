@@ -2,7 +2,11 @@ package models
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
+	"net"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -18,12 +22,39 @@ type Website struct {
 	UserID    uuid.UUID
 	Name      string
 	Domain    string
+	// AllowedHostnames lists the hostnames hits are accepted from. Entries
+	// may start with "*." to match any subdomain. When empty, Domain and its
+	// subdomains are allowed.
+	AllowedHostnames []string
+	AllowLocalhost   bool
+	// RateLimitPerSecond and RateLimitBurst override the default per-visitor
+	// collect limits when greater than zero.
+	RateLimitPerSecond int32
+	RateLimitBurst     int32
+	// ProxyScriptPath and ProxyCollectPath are the paths the tracker is
+	// served and posts hits under when proxied through the website's own
+	// domain. They are random so blocklists can't match them.
+	ProxyScriptPath  string
+	ProxyCollectPath string
+	// URL rules applied to hit URLs at ingestion; see NormalizeURL and
+	// PageGroup. QueryParams are the parameters removed in keep mode and the
+	// only ones kept in allowlist mode.
+	QueryParamMode     string
+	QueryParams        []string
+	LowercasePaths     bool
+	StripTrailingSlash bool
+	PathPatterns       []string
+	// Timezone is the IANA name of the timezone reports are in; see
+	// Location.
+	Timezone string
 }
 
 type CreateWebsiteData struct {
-	UserID uuid.UUID
-	Name   string `validate:"required,max=255"`
-	Domain string `validate:"required,max=255"`
+	UserID           uuid.UUID
+	Name             string   `validate:"required,max=255"`
+	Domain           string   `validate:"required,max=255"`
+	AllowedHostnames []string `validate:"dive,max=255"`
+	AllowLocalhost   bool
 }
 
 func CreateWebsite(
@@ -35,11 +66,20 @@ func CreateWebsite(
 		return Website{}, errors.Join(ErrDomainValidation, err)
 	}
 
+	scriptPath, collectPath, err := randomProxyPaths()
+	if err != nil {
+		return Website{}, err
+	}
+
 	params := db.InsertWebsiteParams{
-		ID:     uuid.New(),
-		UserID: data.UserID,
-		Name:   data.Name,
-		Domain: data.Domain,
+		ID:               uuid.New(),
+		UserID:           data.UserID,
+		Name:             data.Name,
+		Domain:           data.Domain,
+		AllowedHostnames: normalizeHostnames(data.AllowedHostnames),
+		AllowLocalhost:   data.AllowLocalhost,
+		ProxyScriptPath:  scriptPath,
+		ProxyCollectPath: collectPath,
 	}
 	row, err := queries.InsertWebsite(ctx, exec, params)
 	if err != nil {
@@ -50,9 +90,26 @@ func CreateWebsite(
 }
 
 type UpdateWebsiteData struct {
-	ID     uuid.UUID
-	Name   string `validate:"required,max=255"`
-	Domain string `validate:"required,max=255"`
+	ID                 uuid.UUID
+	Name               string   `validate:"required,max=255"`
+	Domain             string   `validate:"required,max=255"`
+	AllowedHostnames   []string `validate:"dive,max=255"`
+	AllowLocalhost     bool
+	RateLimitPerSecond int32 `validate:"min=0,max=10000"`
+	RateLimitBurst     int32 `validate:"min=0,max=100000"`
+	// Empty proxy paths are replaced with new random ones.
+	ProxyScriptPath  string `validate:"omitempty,min=2,max=64,startswith=/,excludesall=?#"`
+	ProxyCollectPath string `validate:"omitempty,min=2,max=64,startswith=/,excludesall=?#,nefield=ProxyScriptPath"`
+
+	// An empty QueryParamMode keeps query parameters.
+	QueryParamMode     string   `validate:"omitempty,oneof=keep strip allowlist"`
+	QueryParams        []string `validate:"max=100,dive,max=100"`
+	LowercasePaths     bool
+	StripTrailingSlash bool
+	PathPatterns       []string `validate:"max=100,dive,startswith=/,max=255,excludesall=?#"`
+
+	// An empty Timezone is UTC.
+	Timezone string `validate:"omitempty,max=64,timezone"`
 }
 
 func UpdateWebsite(
@@ -64,10 +121,44 @@ func UpdateWebsite(
 		return Website{}, errors.Join(ErrDomainValidation, err)
 	}
 
+	scriptPath, collectPath, err := randomProxyPaths()
+	if err != nil {
+		return Website{}, err
+	}
+	if data.ProxyScriptPath != "" {
+		scriptPath = data.ProxyScriptPath
+	}
+	if data.ProxyCollectPath != "" {
+		collectPath = data.ProxyCollectPath
+	}
+	queryParamMode := data.QueryParamMode
+	if queryParamMode == "" {
+		queryParamMode = QueryParamModeKeep
+	}
+	timezone := data.Timezone
+	if timezone == "" {
+		timezone = "UTC"
+	}
+
 	params := db.UpdateWebsiteParams{
-		ID:     data.ID,
-		Name:   data.Name,
-		Domain: data.Domain,
+		ID:               data.ID,
+		Name:             data.Name,
+		Domain:           data.Domain,
+		AllowedHostnames: normalizeHostnames(data.AllowedHostnames),
+		AllowLocalhost:   data.AllowLocalhost,
+
+		RateLimitPerSecond: data.RateLimitPerSecond,
+		RateLimitBurst:     data.RateLimitBurst,
+		ProxyScriptPath:    scriptPath,
+		ProxyCollectPath:   collectPath,
+
+		QueryParamMode:     queryParamMode,
+		QueryParams:        uniqueValues(data.QueryParams),
+		LowercasePaths:     data.LowercasePaths,
+		StripTrailingSlash: data.StripTrailingSlash,
+		PathPatterns:       uniqueValues(data.PathPatterns),
+
+		Timezone: timezone,
 	}
 	row, err := queries.UpdateWebsite(ctx, exec, params)
 	if err != nil {
@@ -123,5 +214,118 @@ func rowToWebsite(row db.Website) Website {
 		UserID:    row.UserID,
 		Name:      row.Name,
 		Domain:    row.Domain,
+
+		AllowedHostnames: row.AllowedHostnames,
+		AllowLocalhost:   row.AllowLocalhost,
+
+		RateLimitPerSecond: row.RateLimitPerSecond,
+		RateLimitBurst:     row.RateLimitBurst,
+		ProxyScriptPath:    row.ProxyScriptPath,
+		ProxyCollectPath:   row.ProxyCollectPath,
+
+		QueryParamMode:     row.QueryParamMode,
+		QueryParams:        row.QueryParams,
+		LowercasePaths:     row.LowercasePaths,
+		StripTrailingSlash: row.StripTrailingSlash,
+		PathPatterns:       row.PathPatterns,
+
+		Timezone: row.Timezone,
+	}
+}
+
+// Location is the timezone the website's dashboards report days and hours
+// in, UTC when the timezone is unknown.
+func (w Website) Location() *time.Location {
+	loc, err := time.LoadLocation(w.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// randomProxyPaths returns new random proxy paths for the tracker script
+// and collect endpoint.
+func randomProxyPaths() (string, string, error) {
+	b := make([]byte, 10)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+
+	return "/" + hex.EncodeToString(b[:5]) + ".js", "/" + hex.EncodeToString(b[5:]), nil
+}
+
+// AllowsHostname reports whether hits sent from hostname belong to this
+// website.
+func (w Website) AllowsHostname(hostname string) bool {
+	hostname = NormalizeHostname(hostname)
+	if hostname == "" {
+		return false
+	}
+
+	if w.AllowLocalhost && isLocalhost(hostname) {
+		return true
 	}
+
+	patterns := w.AllowedHostnames
+	if len(patterns) == 0 {
+		domain := NormalizeHostname(w.Domain)
+		patterns = []string{domain, "*." + domain}
+	}
+
+	for _, pattern := range patterns {
+		if suffix, ok := strings.CutPrefix(pattern, "*."); ok {
+			if strings.HasSuffix(hostname, "."+suffix) {
+				return true
+			}
+			continue
+		}
+
+		if hostname == pattern {
+			return true
+		}
+	}
+
+	return false
+}
+
+// NormalizeHostname reduces a hostname, origin or URL to a lowercase
+// hostname without scheme, port, path or trailing dot.
+func NormalizeHostname(value string) string {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if _, rest, ok := strings.Cut(value, "://"); ok {
+		value = rest
+	}
+	if i := strings.IndexAny(value, "/?#"); i >= 0 {
+		value = value[:i]
+	}
+	if host, _, err := net.SplitHostPort(value); err == nil {
+		value = host
+	}
+	value = strings.Trim(value, "[]")
+
+	return strings.TrimSuffix(value, ".")
+}
+
+func normalizeHostnames(hostnames []string) []string {
+	normalized := make([]string, 0, len(hostnames))
+	seen := make(map[string]struct{}, len(hostnames))
+	for _, hostname := range hostnames {
+		hostname = NormalizeHostname(hostname)
+		if _, ok := seen[hostname]; ok || hostname == "" {
+			continue
+		}
+		seen[hostname] = struct{}{}
+		normalized = append(normalized, hostname)
+	}
+
+	return normalized
+}
+
+func isLocalhost(hostname string) bool {
+	if hostname == "localhost" || strings.HasSuffix(hostname, ".localhost") {
+		return true
+	}
+
+	ip := net.ParseIP(hostname)
+	return ip != nil && ip.IsLoopback()
 }
@@ -0,0 +1,140 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+
+	"palantir/internal/storage"
+	"palantir/models/internal/db"
+)
+
+// apiKeyPrefix marks Palantir API keys so they are easy to recognise in
+// configs and secret scanners.
+const apiKeyPrefix = "plt_"
+
+// apiKeyDisplayLength is how much of the plain key is kept to tell keys
+// apart in the UI.
+const apiKeyDisplayLength = len(apiKeyPrefix) + 6
+
+// WebsiteAPIKey authenticates server-side hits for a single website. Only a
+// hash of the key is stored; the plain key is shown once on creation.
+type WebsiteAPIKey struct {
+	ID         uuid.UUID
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	WebsiteID  uuid.UUID
+	Name       string
+	Prefix     string
+	Hash       string
+	LastUsedAt time.Time
+}
+
+type CreateWebsiteAPIKeyData struct {
+	WebsiteID uuid.UUID `validate:"required"`
+	Name      string    `validate:"required,max=255"`
+}
+
+// CreateWebsiteAPIKey stores a new key and returns it together with the plain
+// key, which cannot be recovered afterwards.
+func CreateWebsiteAPIKey(
+	ctx context.Context,
+	exec storage.Executor,
+	pepper string,
+	data CreateWebsiteAPIKeyData,
+) (WebsiteAPIKey, string, error) {
+	if err := Validate.Struct(data); err != nil {
+		return WebsiteAPIKey{}, "", errors.Join(ErrDomainValidation, err)
+	}
+
+	tkn, err := GenerateSecureToken()
+	if err != nil {
+		return WebsiteAPIKey{}, "", err
+	}
+	plain := apiKeyPrefix + tkn
+
+	row, err := queries.InsertWebsiteAPIKey(ctx, exec, db.InsertWebsiteAPIKeyParams{
+		ID:        uuid.New(),
+		WebsiteID: data.WebsiteID,
+		Name:      data.Name,
+		Prefix:    plain[:apiKeyDisplayLength],
+		Hash:      HashForStorage(plain, pepper),
+	})
+	if err != nil {
+		return WebsiteAPIKey{}, "", err
+	}
+
+	return rowToWebsiteAPIKey(row), plain, nil
+}
+
+func FindWebsiteAPIKeysByWebsiteID(
+	ctx context.Context,
+	exec storage.Executor,
+	websiteID uuid.UUID,
+) ([]WebsiteAPIKey, error) {
+	rows, err := queries.QueryWebsiteAPIKeysByWebsiteID(ctx, exec, websiteID)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]WebsiteAPIKey, len(rows))
+	for i, row := range rows {
+		keys[i] = rowToWebsiteAPIKey(row)
+	}
+	return keys, nil
+}
+
+func FindWebsiteAPIKeyByHash(
+	ctx context.Context,
+	exec storage.Executor,
+	hash string,
+) (WebsiteAPIKey, error) {
+	row, err := queries.QueryWebsiteAPIKeyByHash(ctx, exec, hash)
+	if err != nil {
+		return WebsiteAPIKey{}, err
+	}
+
+	return rowToWebsiteAPIKey(row), nil
+}
+
+func TouchWebsiteAPIKey(
+	ctx context.Context,
+	exec storage.Executor,
+	id uuid.UUID,
+) error {
+	return queries.TouchWebsiteAPIKey(ctx, exec, id)
+}
+
+// DestroyWebsiteAPIKey revokes a key of the given website and returns it, so
+// callers can drop it from any cache keyed by hash.
+func DestroyWebsiteAPIKey(
+	ctx context.Context,
+	exec storage.Executor,
+	websiteID uuid.UUID,
+	id uuid.UUID,
+) (WebsiteAPIKey, error) {
+	row, err := queries.DeleteWebsiteAPIKey(ctx, exec, db.DeleteWebsiteAPIKeyParams{
+		ID:        id,
+		WebsiteID: websiteID,
+	})
+	if err != nil {
+		return WebsiteAPIKey{}, err
+	}
+
+	return rowToWebsiteAPIKey(row), nil
+}
+
+func rowToWebsiteAPIKey(row db.WebsiteApiKey) WebsiteAPIKey {
+	return WebsiteAPIKey{
+		ID:         row.ID,
+		CreatedAt:  row.CreatedAt.Time,
+		UpdatedAt:  row.UpdatedAt.Time,
+		WebsiteID:  row.WebsiteID,
+		Name:       row.Name,
+		Prefix:     row.Prefix,
+		Hash:       row.Hash,
+		LastUsedAt: row.LastUsedAt.Time,
+	}
+}
@@ -0,0 +1,113 @@
+package models_test
+
+import (
+	"testing"
+
+	"palantir/models"
+)
+
+func TestWebsiteAllowsHostname(t *testing.T) {
+	tests := []struct {
+		name     string
+		website  models.Website
+		hostname string
+		expected bool
+	}{
+		// Default rules derived from the domain
+		{name: "domain", website: models.Website{Domain: "example.com"}, hostname: "example.com", expected: true},
+		{name: "domain subdomain", website: models.Website{Domain: "example.com"}, hostname: "www.example.com", expected: true},
+		{name: "domain with scheme", website: models.Website{Domain: "https://Example.com/"}, hostname: "example.com", expected: true},
+		{name: "other domain", website: models.Website{Domain: "example.com"}, hostname: "badexample.com", expected: false},
+		{name: "empty hostname", website: models.Website{Domain: "example.com"}, hostname: "", expected: false},
+
+		// Explicit hostnames
+		{name: "exact match", website: models.Website{Domain: "example.com", AllowedHostnames: []string{"app.example.com"}}, hostname: "app.example.com", expected: true},
+		{name: "exact excludes domain", website: models.Website{Domain: "example.com", AllowedHostnames: []string{"app.example.com"}}, hostname: "example.com", expected: false},
+		{name: "wildcard subdomain", website: models.Website{AllowedHostnames: []string{"*.example.com"}}, hostname: "a.b.example.com", expected: true},
+		{name: "wildcard excludes apex", website: models.Website{AllowedHostnames: []string{"*.example.com"}}, hostname: "example.com", expected: false},
+		{name: "origin with port", website: models.Website{AllowedHostnames: []string{"example.com"}}, hostname: "https://example.com:8443", expected: true},
+
+		// Localhost
+		{name: "localhost denied", website: models.Website{Domain: "example.com"}, hostname: "localhost", expected: false},
+		{name: "localhost allowed", website: models.Website{Domain: "example.com", AllowLocalhost: true}, hostname: "http://localhost:3000", expected: true},
+		{name: "loopback ipv6 allowed", website: models.Website{Domain: "example.com", AllowLocalhost: true}, hostname: "http://[::1]:3000", expected: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.website.AllowsHostname(tt.hostname); got != tt.expected {
+				t.Errorf("AllowsHostname(%q) = %v, want %v", tt.hostname, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestWebsiteNormalizeURL(t *testing.T) {
+	tests := []struct {
+		name     string
+		website  models.Website
+		url      string
+		expected string
+	}{
+		{name: "no rules", website: models.Website{}, url: "/Blog/?page=2#top", expected: "/Blog/?page=2#top"},
+		{name: "keep except listed", website: models.Website{QueryParamMode: models.QueryParamModeKeep, QueryParams: []string{"sessionid"}}, url: "/cart?SessionID=1&step=2", expected: "/cart?step=2"},
+		{name: "allowlist", website: models.Website{QueryParamMode: models.QueryParamModeAllowlist, QueryParams: []string{"q"}}, url: "/search?q=go&sort=new", expected: "/search?q=go"},
+		{name: "strip all", website: models.Website{QueryParamMode: models.QueryParamModeStrip}, url: "/search?q=go#results", expected: "/search#results"},
+		{name: "lowercase path only", website: models.Website{LowercasePaths: true}, url: "https://Example.com/About?Ref=X", expected: "https://Example.com/about?Ref=X"},
+		{name: "trailing slash", website: models.Website{StripTrailingSlash: true}, url: "/docs//", expected: "/docs"},
+		{name: "root keeps slash", website: models.Website{StripTrailingSlash: true}, url: "/?a=1", expected: "/?a=1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.website.NormalizeURL(tt.url); got != tt.expected {
+				t.Errorf("NormalizeURL(%q) = %q, want %q", tt.url, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestWebsitePageGroup(t *testing.T) {
+	website := models.Website{PathPatterns: []string{"/orders/*/items", "/orders/*", "/blog/:slug"}}
+
+	tests := []struct {
+		url      string
+		expected string
+	}{
+		{url: "/orders/8123", expected: "/orders/*"},
+		{url: "/orders/8123/items", expected: "/orders/*/items"},
+		{url: "/orders/8123/invoice/pdf", expected: "/orders/*"},
+		{url: "/orders", expected: ""},
+		{url: "/blog/hello-world?ref=x", expected: "/blog/:slug"},
+		{url: "https://example.com/blog/hello-world/", expected: "/blog/:slug"},
+		{url: "/blog/2024/hello-world", expected: ""},
+		{url: "/blog", expected: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			if got := website.PageGroup(tt.url); got != tt.expected {
+				t.Errorf("PageGroup(%q) = %q, want %q", tt.url, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestWebsiteLocation(t *testing.T) {
+	tests := []struct {
+		timezone string
+		expected string
+	}{
+		{timezone: "", expected: "UTC"},
+		{timezone: "Europe/Copenhagen", expected: "Europe/Copenhagen"},
+		{timezone: "Mars/Olympus_Mons", expected: "UTC"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.timezone, func(t *testing.T) {
+			if got := (models.Website{Timezone: tt.timezone}).Location().String(); got != tt.expected {
+				t.Errorf("Location() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,187 @@
+// Package proxy forwards the tracker script and its hits to Palantir from an
+// app's own domain, so the requests don't match the blocklists ad blockers
+// use for third party analytics.
+//
+// Mount the handler under the website's proxy paths, shown on its page in
+// Palantir, and point the script tag at them:
+//
+//	handler, err := proxy.New(proxy.Options{
+//		PalantirURL: "https://palantir.example.com",
+//		ScriptPath:  "/3f9a1c27e4.js",
+//		CollectPath: "/b81d02f6aa",
+//	})
+//	if err != nil {
+//		return err
+//	}
+//	mux.Handle("/3f9a1c27e4.js", handler)
+//	mux.Handle("/b81d02f6aa", handler)
+//	mux.Handle("/b81d02f6aa/batch", handler)
+//
+//	<script defer src="/3f9a1c27e4.js" data-website-id="..." data-api="/b81d02f6aa"></script>
+//
+// Batches of queued hits posted to CollectPath + "/batch" are forwarded to
+// Palantir's batch endpoint. The server-to-server endpoints aren't, as they
+// are authenticated with an API key that must not reach the browser.
+//
+// The visitor's address is passed on in X-Forwarded-For. Palantir only
+// honours it when the app's address is listed in its TRUSTED_PROXIES.
+//
+// The package only depends on the standard library and palantir/clientip. Apps
+// outside this module can copy both packages in, updating the clientip import.
+package proxy
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+
+	"palantir/clientip"
+)
+
+// The paths Palantir serves the tracker script and accepts hits under, used
+// when Options leaves them empty.
+const (
+	DefaultUpstreamScriptPath  = "/t/script.js"
+	DefaultUpstreamCollectPath = "/api/collect"
+)
+
+// batchSuffix is appended to the collect paths for batch submissions.
+const batchSuffix = "/batch"
+
+type Options struct {
+	// PalantirURL is the base URL of the Palantir instance.
+	PalantirURL string
+	// ScriptPath and CollectPath are the paths the handler serves the
+	// tracker script and accepts hits under.
+	ScriptPath  string
+	CollectPath string
+	// UpstreamScriptPath and UpstreamCollectPath are where Palantir serves
+	// the tracker script and accepts hits, relative to PalantirURL. They
+	// default to DefaultUpstreamScriptPath and DefaultUpstreamCollectPath.
+	UpstreamScriptPath  string
+	UpstreamCollectPath string
+	// TrustedProxies lists the load balancers in front of the app, as CIDR
+	// ranges or single addresses. Their X-Forwarded-* headers are passed on;
+	// those of anyone else are replaced.
+	TrustedProxies []string
+	// Transport is used to reach Palantir, http.DefaultTransport if nil.
+	Transport http.RoundTripper
+}
+
+type Handler struct {
+	scriptPath          string
+	collectPath         string
+	upstreamScriptPath  string
+	upstreamCollectPath string
+	trusted             clientip.TrustedProxies
+	proxy               *httputil.ReverseProxy
+}
+
+func New(opts Options) (*Handler, error) {
+	target, err := url.Parse(opts.PalantirURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid palantir url: %w", err)
+	}
+	if target.Scheme == "" || target.Host == "" {
+		return nil, errors.New("palantir url must be absolute")
+	}
+	if !strings.HasPrefix(opts.ScriptPath, "/") || !strings.HasPrefix(opts.CollectPath, "/") {
+		return nil, errors.New("script and collect paths must start with /")
+	}
+	if opts.ScriptPath == opts.CollectPath || opts.ScriptPath == opts.CollectPath+batchSuffix {
+		return nil, errors.New("script and collect paths must differ")
+	}
+	if opts.UpstreamScriptPath == "" {
+		opts.UpstreamScriptPath = DefaultUpstreamScriptPath
+	}
+	if opts.UpstreamCollectPath == "" {
+		opts.UpstreamCollectPath = DefaultUpstreamCollectPath
+	}
+	if !strings.HasPrefix(opts.UpstreamScriptPath, "/") || !strings.HasPrefix(opts.UpstreamCollectPath, "/") {
+		return nil, errors.New("upstream script and collect paths must start with /")
+	}
+
+	h := &Handler{
+		scriptPath:          opts.ScriptPath,
+		collectPath:         opts.CollectPath,
+		upstreamScriptPath:  opts.UpstreamScriptPath,
+		upstreamCollectPath: opts.UpstreamCollectPath,
+	}
+
+	h.trusted, err = clientip.ParseTrustedProxies(opts.TrustedProxies)
+	if err != nil {
+		return nil, err
+	}
+
+	h.proxy = &httputil.ReverseProxy{
+		Rewrite: func(pr *httputil.ProxyRequest) {
+			h.rewrite(pr, target)
+		},
+		Transport: opts.Transport,
+	}
+
+	return h, nil
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.URL.Path == h.scriptPath && (r.Method == http.MethodGet || r.Method == http.MethodHead):
+	case r.URL.Path == h.collectPath && r.Method == http.MethodPost:
+	case r.URL.Path == h.collectPath+batchSuffix && r.Method == http.MethodPost:
+	default:
+		http.NotFound(w, r)
+		return
+	}
+
+	h.proxy.ServeHTTP(w, r)
+}
+
+func (h *Handler) rewrite(pr *httputil.ProxyRequest, target *url.URL) {
+	var path string
+	switch pr.In.URL.Path {
+	case h.scriptPath:
+		path = h.upstreamScriptPath
+	case h.collectPath + batchSuffix:
+		path = h.upstreamCollectPath + batchSuffix
+	default:
+		path = h.upstreamCollectPath
+	}
+
+	pr.Out.URL.Scheme = target.Scheme
+	pr.Out.URL.Host = target.Host
+	pr.Out.URL.Path = strings.TrimSuffix(target.Path, "/") + path
+	pr.Out.URL.RawPath = ""
+	pr.Out.Host = ""
+
+	// Hits are anonymous, so the app's cookies and credentials stay behind.
+	pr.Out.Header.Del("Cookie")
+	pr.Out.Header.Del("Authorization")
+
+	// Rewrite drops the inbound X-Forwarded-* headers. They are only kept
+	// when they were set by a proxy of our own, as anyone else could forge
+	// them.
+	if h.isTrusted(pr.In.RemoteAddr) {
+		for _, header := range []string{"X-Forwarded-For", "X-Forwarded-Host", "X-Forwarded-Proto"} {
+			if values := pr.In.Header.Values(header); len(values) > 0 {
+				pr.Out.Header[header] = values
+			}
+		}
+	}
+
+	host, proto := pr.Out.Header.Get("X-Forwarded-Host"), pr.Out.Header.Get("X-Forwarded-Proto")
+	pr.SetXForwarded()
+	if host != "" {
+		pr.Out.Header.Set("X-Forwarded-Host", host)
+	}
+	if proto != "" {
+		pr.Out.Header.Set("X-Forwarded-Proto", proto)
+	}
+}
+
+func (h *Handler) isTrusted(remoteAddr string) bool {
+	addr, ok := clientip.ParseHostAddr(remoteAddr)
+	return ok && h.trusted.Contains(addr)
+}
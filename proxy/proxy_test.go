@@ -0,0 +1,168 @@
+package proxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHandler(t *testing.T) {
+	var got *http.Request
+	palantir := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer palantir.Close()
+
+	handler, err := New(Options{
+		PalantirURL:    palantir.URL,
+		ScriptPath:     "/a1b2c3.js",
+		CollectPath:    "/d4e5f6",
+		TrustedProxies: []string{"10.0.0.0/8"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		remote     string
+		headers    map[string]string
+		wantStatus int
+		wantPath   string
+		wantFor    string
+	}{
+		{
+			name:       "script",
+			method:     http.MethodGet,
+			path:       "/a1b2c3.js",
+			remote:     "203.0.113.9:5000",
+			wantStatus: http.StatusAccepted,
+			wantPath:   "/t/script.js",
+			wantFor:    "203.0.113.9",
+		},
+		{
+			name:       "hit from untrusted peer",
+			method:     http.MethodPost,
+			path:       "/d4e5f6",
+			remote:     "203.0.113.9:5000",
+			headers:    map[string]string{"X-Forwarded-For": "198.51.100.1", "Cookie": "session=1"},
+			wantStatus: http.StatusAccepted,
+			wantPath:   "/api/collect",
+			wantFor:    "203.0.113.9",
+		},
+		{
+			name:       "hit through trusted load balancer",
+			method:     http.MethodPost,
+			path:       "/d4e5f6",
+			remote:     "10.0.0.2:5000",
+			headers:    map[string]string{"X-Forwarded-For": "198.51.100.1"},
+			wantStatus: http.StatusAccepted,
+			wantPath:   "/api/collect",
+			wantFor:    "198.51.100.1, 10.0.0.2",
+		},
+		{
+			name:       "batch",
+			method:     http.MethodPost,
+			path:       "/d4e5f6/batch",
+			remote:     "203.0.113.9:5000",
+			wantStatus: http.StatusAccepted,
+			wantPath:   "/api/collect/batch",
+			wantFor:    "203.0.113.9",
+		},
+		{
+			name:       "wrong method",
+			method:     http.MethodPost,
+			path:       "/a1b2c3.js",
+			remote:     "203.0.113.9:5000",
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "unknown path",
+			method:     http.MethodGet,
+			path:       "/other",
+			remote:     "203.0.113.9:5000",
+			wantStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got = nil
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader("{}"))
+			req.RemoteAddr = tt.remote
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if tt.wantPath == "" {
+				if got != nil {
+					t.Fatal("request was forwarded")
+				}
+				return
+			}
+
+			if got.URL.Path != tt.wantPath {
+				t.Errorf("path = %q, want %q", got.URL.Path, tt.wantPath)
+			}
+			if forwarded := got.Header.Get("X-Forwarded-For"); forwarded != tt.wantFor {
+				t.Errorf("X-Forwarded-For = %q, want %q", forwarded, tt.wantFor)
+			}
+			if got.Header.Get("Cookie") != "" {
+				t.Error("cookies were forwarded")
+			}
+		})
+	}
+}
+
+func TestHandlerUpstreamPaths(t *testing.T) {
+	var got *http.Request
+	palantir := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer palantir.Close()
+
+	handler, err := New(Options{
+		PalantirURL:         palantir.URL + "/analytics",
+		ScriptPath:          "/a1b2c3.js",
+		CollectPath:         "/d4e5f6",
+		UpstreamScriptPath:  "/tracker.js",
+		UpstreamCollectPath: "/hits",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		method   string
+		path     string
+		wantPath string
+	}{
+		{method: http.MethodGet, path: "/a1b2c3.js", wantPath: "/analytics/tracker.js"},
+		{method: http.MethodPost, path: "/d4e5f6", wantPath: "/analytics/hits"},
+		{method: http.MethodPost, path: "/d4e5f6/batch", wantPath: "/analytics/hits/batch"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(tt.method, tt.path, strings.NewReader("[]")))
+
+			if got == nil {
+				t.Fatal("request was not forwarded")
+			}
+			if got.URL.Path != tt.wantPath {
+				t.Errorf("path = %q, want %q", got.URL.Path, tt.wantPath)
+			}
+		})
+	}
+}
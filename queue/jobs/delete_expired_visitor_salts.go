@@ -0,0 +1,5 @@
+package jobs
+
+type DeleteExpiredVisitorSaltsArgs struct{}
+
+func (DeleteExpiredVisitorSaltsArgs) Kind() string { return "delete_expired_visitor_salts" }
@@ -4,8 +4,10 @@ package queue
 import (
 	"context"
 	"log/slog"
+	"time"
 
 	"palantir/internal/storage"
+	"palantir/queue/jobs"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/riverqueue/river"
@@ -40,8 +42,9 @@ func NewProcessor(
 		Queues: map[string]river.QueueConfig{
 			river.QueueDefault: {MaxWorkers: 100},
 		},
-		Logger:  slog.Default(),
-		Workers: workers,
+		Logger:       slog.Default(),
+		Workers:      workers,
+		PeriodicJobs: periodicJobs(),
 	})
 	if err != nil {
 		return Processor{}, err
@@ -50,6 +53,18 @@ func NewProcessor(
 	return Processor{riverClient}, nil
 }
 
+func periodicJobs() []*river.PeriodicJob {
+	return []*river.PeriodicJob{
+		river.NewPeriodicJob(
+			river.PeriodicInterval(time.Hour),
+			func() (river.JobArgs, *river.InsertOpts) {
+				return jobs.DeleteExpiredVisitorSaltsArgs{}, nil
+			},
+			&river.PeriodicJobOpts{RunOnStart: true},
+		),
+	}
+}
+
 type InsertOnly struct {
 	client *river.Client[pgx.Tx]
 }
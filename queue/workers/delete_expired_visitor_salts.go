@@ -0,0 +1,41 @@
+package workers
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/riverqueue/river"
+
+	"palantir/internal/storage"
+	"palantir/models"
+	"palantir/queue/jobs"
+)
+
+// DeleteExpiredVisitorSaltsWorker removes every salt older than yesterday,
+// which makes visitor hashes from those days unlinkable.
+type DeleteExpiredVisitorSaltsWorker struct {
+	river.WorkerDefaults[jobs.DeleteExpiredVisitorSaltsArgs]
+	db storage.Pool
+}
+
+func NewDeleteExpiredVisitorSaltsWorker(db storage.Pool) *DeleteExpiredVisitorSaltsWorker {
+	return &DeleteExpiredVisitorSaltsWorker{
+		db: db,
+	}
+}
+
+func (w *DeleteExpiredVisitorSaltsWorker) Work(ctx context.Context, job *river.Job[jobs.DeleteExpiredVisitorSaltsArgs]) error {
+	yesterday := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, -1)
+
+	deleted, err := models.DestroyVisitorSaltsBefore(ctx, w.db.Conn(), yesterday)
+	if err != nil {
+		return err
+	}
+
+	if deleted > 0 {
+		slog.InfoContext(ctx, "deleted expired visitor salts", "count", deleted)
+	}
+
+	return nil
+}
@@ -5,9 +5,14 @@ import (
 	"github.com/riverqueue/river"
 
 	"palantir/email"
+	"palantir/internal/storage"
 )
 
-func Register(transactionalSender email.TransactionalSender, marketingSender email.MarketingSender) (*river.Workers, error) {
+func Register(
+	db storage.Pool,
+	transactionalSender email.TransactionalSender,
+	marketingSender email.MarketingSender,
+) (*river.Workers, error) {
 	wrks := river.NewWorkers()
 
 	if err := river.AddWorkerSafely(wrks, NewSendTransactionalEmailWorker(transactionalSender)); err != nil {
@@ -18,5 +23,9 @@ func Register(transactionalSender email.TransactionalSender, marketingSender ema
 		return nil, err
 	}
 
+	if err := river.AddWorkerSafely(wrks, NewDeleteExpiredVisitorSaltsWorker(db)); err != nil {
+		return nil, err
+	}
+
 	return wrks, nil
 }
@@ -0,0 +1,75 @@
+package router
+
+import (
+	"net"
+	"net/http"
+	"strings"
+
+	"palantir/clientip"
+
+	"github.com/labstack/echo/v5"
+)
+
+// NewIPExtractor returns the strategy echo uses for RealIP. Forwarding
+// headers are only honoured when the request comes from one of the trusted
+// proxies, which are given as CIDR ranges or single addresses. headers lists
+// the headers to consult in order; the first one present wins.
+//
+// Forwarded and X-Forwarded-For are walked from the right, skipping trusted
+// proxies, so a client can't spoof its address by prepending entries.
+func NewIPExtractor(trustedProxies []string, headers []string) (echo.IPExtractor, error) {
+	trusted, err := clientip.ParseTrustedProxies(trustedProxies)
+	if err != nil {
+		return nil, err
+	}
+
+	var canonical []string
+	for _, header := range headers {
+		if header = strings.TrimSpace(header); header != "" {
+			canonical = append(canonical, http.CanonicalHeaderKey(header))
+		}
+	}
+
+	e := ipExtractor{trusted: trusted, headers: canonical}
+	return e.extract, nil
+}
+
+type ipExtractor struct {
+	trusted clientip.TrustedProxies
+	headers []string
+}
+
+func (e ipExtractor) extract(r *http.Request) string {
+	remote, ok := clientip.ParseHostAddr(r.RemoteAddr)
+	if !ok {
+		host, _, _ := net.SplitHostPort(r.RemoteAddr)
+		return host
+	}
+
+	if !e.trusted.Contains(remote) {
+		return remote.String()
+	}
+
+	for _, header := range e.headers {
+		values := r.Header.Values(header)
+		if len(values) == 0 {
+			continue
+		}
+
+		switch header {
+		case "Forwarded":
+			return e.trusted.FromChain(remote, clientip.ParseForwarded(values)).String()
+		case "X-Forwarded-For":
+			return e.trusted.FromChain(remote, clientip.SplitChain(values)).String()
+		default:
+			// Single value headers such as X-Real-IP or CF-Connecting-IP
+			// are set by the proxy itself.
+			if addr, ok := clientip.ParseHostAddr(strings.TrimSpace(values[0])); ok {
+				return addr.String()
+			}
+			return remote.String()
+		}
+	}
+
+	return remote.String()
+}
@@ -0,0 +1,93 @@
+package router
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestIPExtractor(t *testing.T) {
+	extract, err := NewIPExtractor(
+		[]string{"10.0.0.0/8", "2001:db8::1"},
+		[]string{"Forwarded", "X-Forwarded-For", "CF-Connecting-IP"},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		remote  string
+		headers map[string]string
+		want    string
+	}{
+		{
+			name:   "direct client",
+			remote: "203.0.113.9:5000",
+			want:   "203.0.113.9",
+		},
+		{
+			name:    "untrusted peer cannot spoof",
+			remote:  "203.0.113.9:5000",
+			headers: map[string]string{"X-Forwarded-For": "198.51.100.1"},
+			want:    "203.0.113.9",
+		},
+		{
+			name:    "trusted proxy",
+			remote:  "10.0.0.2:5000",
+			headers: map[string]string{"X-Forwarded-For": "198.51.100.1"},
+			want:    "198.51.100.1",
+		},
+		{
+			name:    "prepended entries are ignored",
+			remote:  "10.0.0.2:5000",
+			headers: map[string]string{"X-Forwarded-For": "1.2.3.4, 198.51.100.1, 10.0.0.3"},
+			want:    "198.51.100.1",
+		},
+		{
+			name:    "all hops trusted",
+			remote:  "10.0.0.2:5000",
+			headers: map[string]string{"X-Forwarded-For": "10.0.0.4, 10.0.0.3"},
+			want:    "10.0.0.4",
+		},
+		{
+			name:   "forwarded header",
+			remote: "[2001:db8::1]:443",
+			headers: map[string]string{
+				"Forwarded": `for="[2001:db8:cafe::17]:4711";proto=https, for=10.0.0.3`,
+			},
+			want: "2001:db8:cafe::17",
+		},
+		{
+			name:    "forwarded takes precedence",
+			remote:  "10.0.0.2:5000",
+			headers: map[string]string{"Forwarded": "for=192.0.2.60", "X-Forwarded-For": "198.51.100.1"},
+			want:    "192.0.2.60",
+		},
+		{
+			name:    "obfuscated forwarded hop",
+			remote:  "10.0.0.2:5000",
+			headers: map[string]string{"Forwarded": "for=192.0.2.60, for=_hidden"},
+			want:    "10.0.0.2",
+		},
+		{
+			name:    "cdn header",
+			remote:  "10.0.0.2:5000",
+			headers: map[string]string{"CF-Connecting-IP": "192.0.2.61"},
+			want:    "192.0.2.61",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/", nil)
+			r.RemoteAddr = tt.remote
+			for key, value := range tt.headers {
+				r.Header.Set(key, value)
+			}
+
+			if got := extract(r); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
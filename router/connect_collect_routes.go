@@ -33,5 +33,45 @@ func (r Router) RegisterCollectRoutes(collect controllers.Collect) error {
 		errs = append(errs, err)
 	}
 
+	_, err = r.e.AddRoute(echo.Route{
+		Method:  http.MethodOptions,
+		Path:    routes.CollectBatch.Path(),
+		Name:    routes.CollectBatch.Name() + ".options",
+		Handler: collect.Preflight,
+	})
+	if err != nil {
+		errs = append(errs, err)
+	}
+
+	_, err = r.e.AddRoute(echo.Route{
+		Method:  http.MethodPost,
+		Path:    routes.CollectBatch.Path(),
+		Name:    routes.CollectBatch.Name(),
+		Handler: collect.CreateBatch,
+	})
+	if err != nil {
+		errs = append(errs, err)
+	}
+
+	_, err = r.e.AddRoute(echo.Route{
+		Method:  http.MethodPost,
+		Path:    routes.CollectServer.Path(),
+		Name:    routes.CollectServer.Name(),
+		Handler: collect.CreateServer,
+	})
+	if err != nil {
+		errs = append(errs, err)
+	}
+
+	_, err = r.e.AddRoute(echo.Route{
+		Method:  http.MethodPost,
+		Path:    routes.CollectServerBatch.Path(),
+		Name:    routes.CollectServerBatch.Name(),
+		Handler: collect.CreateServerBatch,
+	})
+	if err != nil {
+		errs = append(errs, err)
+	}
+
 	return errors.Join(errs...)
 }
@@ -23,5 +23,15 @@ func (r Router) RegisterTrackingRoutes(tracking controllers.Tracking) error {
 		errs = append(errs, err)
 	}
 
+	_, err = r.e.AddRoute(echo.Route{
+		Method:  http.MethodGet,
+		Path:    routes.TrackingScriptVersion.Path(),
+		Name:    routes.TrackingScriptVersion.Name(),
+		Handler: tracking.ScriptVersion,
+	})
+	if err != nil {
+		errs = append(errs, err)
+	}
+
 	return errors.Join(errs...)
 }
@@ -105,5 +105,31 @@ func (r Router) RegisterWebsitesRoutes(websites controllers.Websites) error {
 		errs = append(errs, err)
 	}
 
+	_, err = r.e.AddRoute(echo.Route{
+		Method:  http.MethodPost,
+		Path:    routes.WebsiteAPIKeyCreate.Path(),
+		Name:    routes.WebsiteAPIKeyCreate.Name(),
+		Handler: websites.CreateAPIKey,
+		Middlewares: []echo.MiddlewareFunc{
+			middleware.AuthOnly,
+		},
+	})
+	if err != nil {
+		errs = append(errs, err)
+	}
+
+	_, err = r.e.AddRoute(echo.Route{
+		Method:  http.MethodDelete,
+		Path:    routes.WebsiteAPIKeyDestroy.Path(),
+		Name:    routes.WebsiteAPIKeyDestroy.Name(),
+		Handler: websites.DestroyAPIKey,
+		Middlewares: []echo.MiddlewareFunc{
+			middleware.AuthOnly,
+		},
+	})
+	if err != nil {
+		errs = append(errs, err)
+	}
+
 	return errors.Join(errs...)
 }
@@ -0,0 +1,21 @@
+package router
+
+import (
+	"testing"
+
+	"palantir/proxy"
+	"palantir/router/routes"
+)
+
+// The proxy can't import the routes, so its defaults are checked here.
+func TestProxyDefaultsMatchRoutes(t *testing.T) {
+	if got := routes.TrackingScript.URL(); got != proxy.DefaultUpstreamScriptPath {
+		t.Errorf("tracking script is served at %q, proxy defaults to %q", got, proxy.DefaultUpstreamScriptPath)
+	}
+	if got := routes.CollectCreate.URL(); got != proxy.DefaultUpstreamCollectPath {
+		t.Errorf("hits are collected at %q, proxy defaults to %q", got, proxy.DefaultUpstreamCollectPath)
+	}
+	if got := routes.CollectBatch.URL(); got != proxy.DefaultUpstreamCollectPath+"/batch" {
+		t.Errorf("batches are collected at %q, proxy forwards them to %q", got, proxy.DefaultUpstreamCollectPath+"/batch")
+	}
+}
@@ -4,10 +4,12 @@ package router
 import (
 	"encoding/gob"
 	"net/http"
+	"strings"
 
 	"palantir/config"
 	"palantir/router/cookies"
 	"palantir/router/middleware"
+	"palantir/router/routes"
 	"palantir/telemetry"
 
 	"github.com/google/uuid"
@@ -25,12 +27,14 @@ type Router struct {
 
 func New(
 	enableHTTPInstrumentation bool,
+	ipExtractor echo.IPExtractor,
 	globalMiddleware []echo.MiddlewareFunc,
 ) (*Router, error) {
 	gob.Register(uuid.UUID{})
 	gob.Register(cookies.FlashMessage{})
 
 	router := echo.New()
+	router.IPExtractor = ipExtractor
 
 	router.Use(globalMiddleware...)
 
@@ -74,6 +78,11 @@ func SetupGlobalMiddleware(
 		mw.RegisterFlashMessagesContext,
 		mw.TrackReturnTo,
 		echomw.CORSWithConfig(echomw.CORSConfig{
+			// The collect endpoints answer CORS themselves, based on each
+			// website's allowed hostnames.
+			Skipper: func(c *echo.Context) bool {
+				return strings.HasPrefix(c.Request().URL.Path, routes.CollectCreate.URL())
+			},
 			UnsafeAllowOriginFunc: func(_ *echo.Context, origin string) (allowedOrigin string, allowed bool, err error) {
 				if origin == "" {
 					return "", false, nil
@@ -9,3 +9,21 @@ var CollectCreate = routing.NewSimpleRoute(
 	"api.collect",
 	APIPrefix,
 )
+
+var CollectBatch = routing.NewSimpleRoute(
+	"/collect/batch",
+	"api.collect.batch",
+	APIPrefix,
+)
+
+var CollectServer = routing.NewSimpleRoute(
+	"/collect/server",
+	"api.collect.server",
+	APIPrefix,
+)
+
+var CollectServerBatch = routing.NewSimpleRoute(
+	"/collect/server/batch",
+	"api.collect.server.batch",
+	APIPrefix,
+)
@@ -11,3 +11,11 @@ var TrackingScript = routing.NewSimpleRoute(
 	"tracking.script",
 	TrackingPrefix,
 )
+
+// TrackingScriptVersion serves a pinned build of the tracker, so it can be
+// cached for good and embedded with an integrity hash.
+var TrackingScriptVersion = routing.NewRouteWithStringID(
+	"/:id/script.js",
+	"tracking.script.version",
+	TrackingPrefix,
+)
@@ -59,3 +59,15 @@ var WebsiteDashboardLive = routing.NewRouteWithUUIDID(
 	"websites.dashboard.live",
 	WebsitesPrefix,
 )
+
+var WebsiteAPIKeyCreate = routing.NewRouteWithUUIDID(
+	"/:id/api_keys",
+	"websites.api_keys.create",
+	WebsitesPrefix,
+)
+
+var WebsiteAPIKeyDestroy = routing.NewRouteWithMultipleIDs(
+	"/:id/api_keys/:key_id",
+	"websites.api_keys.destroy",
+	WebsitesPrefix,
+)
@@ -0,0 +1,135 @@
+package services
+
+import (
+	"bufio"
+	_ "embed"
+	"fmt"
+	"net/netip"
+	"strings"
+)
+
+//go:generate go run ../cmd/datacenters
+
+var (
+	//go:embed botlists/crawler_user_agents.txt
+	crawlerUserAgents string
+
+	//go:embed botlists/datacenter_ranges.txt
+	datacenterRanges string
+)
+
+// headlessMarkers identify automation frameworks and headless browsers that
+// otherwise present a regular browser user agent.
+var headlessMarkers = []string{
+	"headlesschrome",
+	"phantomjs",
+	"slimerjs",
+	"puppeteer",
+	"playwright",
+	"selenium",
+	"webdriver",
+	"cypress",
+	"chrome-lighthouse",
+}
+
+// BotSignals is what is known about the client that sent a hit.
+type BotSignals struct {
+	UserAgent string
+	IP        string
+	// FlaggedByParser is the useragent parser's own bot verdict.
+	FlaggedByParser bool
+	// Webdriver is navigator.webdriver as reported by the tracker.
+	Webdriver bool
+}
+
+// BotDetector decides whether a hit was sent by a crawler or another kind
+// of automated client, using the embedded crawler and datacenter lists.
+type BotDetector struct {
+	crawlers    []string
+	datacenters []netip.Prefix
+}
+
+func NewBotDetector() (*BotDetector, error) {
+	datacenters, err := parsePrefixes(datacenterRanges)
+	if err != nil {
+		return nil, err
+	}
+
+	return &BotDetector{
+		crawlers:    parseListLines(crawlerUserAgents),
+		datacenters: datacenters,
+	}, nil
+}
+
+func (d *BotDetector) IsBot(signals BotSignals) bool {
+	if signals.FlaggedByParser || signals.Webdriver {
+		return true
+	}
+
+	ua := strings.ToLower(strings.TrimSpace(signals.UserAgent))
+	if ua == "" {
+		return true
+	}
+
+	for _, marker := range headlessMarkers {
+		if strings.Contains(ua, marker) {
+			return true
+		}
+	}
+
+	for _, pattern := range d.crawlers {
+		if strings.Contains(ua, pattern) {
+			return true
+		}
+	}
+
+	return d.isDatacenterIP(signals.IP)
+}
+
+func (d *BotDetector) isDatacenterIP(ip string) bool {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+
+	for _, prefix := range d.datacenters {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// parseListLines returns the lowercased, non-empty lines of an embedded list,
+// skipping # comments.
+func parseListLines(list string) []string {
+	var lines []string
+
+	scanner := bufio.NewScanner(strings.NewReader(list))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		lines = append(lines, strings.ToLower(line))
+	}
+
+	return lines
+}
+
+func parsePrefixes(list string) ([]netip.Prefix, error) {
+	lines := parseListLines(list)
+
+	prefixes := make([]netip.Prefix, len(lines))
+	for i, line := range lines {
+		prefix, err := netip.ParsePrefix(line)
+		if err != nil {
+			return nil, fmt.Errorf("invalid datacenter range %q: %w", line, err)
+		}
+		prefixes[i] = prefix
+	}
+
+	return prefixes, nil
+}
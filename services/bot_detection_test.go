@@ -0,0 +1,49 @@
+package services
+
+import "testing"
+
+func TestBotDetectorIsBot(t *testing.T) {
+	d, err := NewBotDetector()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const chrome = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.0.0 Safari/537.36"
+
+	tests := []struct {
+		name    string
+		signals BotSignals
+		want    bool
+	}{
+		{name: "browser", signals: BotSignals{UserAgent: chrome, IP: "198.51.100.7"}, want: false},
+		{name: "empty user agent", signals: BotSignals{IP: "198.51.100.7"}, want: true},
+		{
+			name:    "crawler",
+			signals: BotSignals{UserAgent: "Mozilla/5.0 (compatible; Bingbot/2.0; +http://www.bing.com/bingbot.htm)", IP: "198.51.100.7"},
+			want:    true,
+		},
+		{
+			name:    "crawler in different case",
+			signals: BotSignals{UserAgent: "Mozilla/5.0 (compatible; AhrefsBot/7.0)", IP: "198.51.100.7"},
+			want:    true,
+		},
+		{
+			name:    "headless chrome",
+			signals: BotSignals{UserAgent: "Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) HeadlessChrome/124.0.0.0 Safari/537.36", IP: "198.51.100.7"},
+			want:    true,
+		},
+		{name: "webdriver", signals: BotSignals{UserAgent: chrome, IP: "198.51.100.7", Webdriver: true}, want: true},
+		{name: "flagged by parser", signals: BotSignals{UserAgent: chrome, IP: "198.51.100.7", FlaggedByParser: true}, want: true},
+		{name: "datacenter address", signals: BotSignals{UserAgent: chrome, IP: "3.120.0.1"}, want: true},
+		{name: "mapped datacenter address", signals: BotSignals{UserAgent: chrome, IP: "::ffff:3.120.0.1"}, want: true},
+		{name: "unparsable address", signals: BotSignals{UserAgent: chrome, IP: "unknown"}, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := d.IsBot(tt.signals); got != tt.want {
+				t.Errorf("IsBot() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,238 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"sync"
+	"time"
+
+	"palantir/telemetry"
+
+	"github.com/maypok86/otter/v2"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+var (
+	ErrGeoLookupTimeout = errors.New("geo lookup timed out")
+	ErrGeoCircuitOpen   = errors.New("geo lookup circuit is open")
+)
+
+const (
+	geoLookupHit      = "hit"
+	geoLookupMiss     = "miss"
+	geoLookupFailure  = "failure"
+	geoLookupRejected = "rejected"
+)
+
+type CachedGeoOptions struct {
+	Size int
+	TTL  time.Duration
+	// GroupByPrefix caches per /24 (IPv4) or /48 (IPv6) network instead of
+	// per address, trading some city-level precision for a higher hit rate.
+	GroupByPrefix bool
+	Timeout       time.Duration
+	// FailureThreshold consecutive failures open the circuit for
+	// OpenDuration, after which a single trial lookup is let through.
+	FailureThreshold int
+	OpenDuration     time.Duration
+}
+
+// CachedGeoResolver wraps another GeoResolver with a result cache, a per-call
+// timeout and a circuit breaker, so a slow or failing upstream degrades to
+// empty geolocation instead of stalling ingestion.
+type CachedGeoResolver struct {
+	next          GeoResolver
+	cache         *otter.Cache[string, GeoResult]
+	groupByPrefix bool
+	timeout       time.Duration
+	breaker       *circuitBreaker
+	lookups       metric.Int64Counter
+}
+
+func NewCachedGeoResolver(next GeoResolver, opts CachedGeoOptions) *CachedGeoResolver {
+	if opts.Size < 1 {
+		opts.Size = 10_000
+	}
+	if opts.TTL <= 0 {
+		opts.TTL = 24 * time.Hour
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = time.Second
+	}
+	if opts.FailureThreshold < 1 {
+		opts.FailureThreshold = 5
+	}
+	if opts.OpenDuration <= 0 {
+		opts.OpenDuration = 30 * time.Second
+	}
+
+	lookups, err := telemetry.GeoLookupsTotal()
+	if err != nil {
+		slog.Warn("failed to create geo_lookups_total metric", "error", err)
+	}
+
+	return &CachedGeoResolver{
+		next: next,
+		cache: otter.Must(&otter.Options[string, GeoResult]{
+			MaximumSize:      opts.Size,
+			ExpiryCalculator: otter.ExpiryWriting[string, GeoResult](opts.TTL),
+		}),
+		groupByPrefix: opts.GroupByPrefix,
+		timeout:       opts.Timeout,
+		breaker:       newCircuitBreaker(opts.FailureThreshold, opts.OpenDuration),
+		lookups:       lookups,
+	}
+}
+
+func (r *CachedGeoResolver) Resolve(ip string) (GeoResult, error) {
+	key := r.cacheKey(ip)
+	if result, ok := r.cache.GetIfPresent(key); ok {
+		r.record(geoLookupHit)
+		return result, nil
+	}
+
+	if !r.breaker.allow() {
+		r.record(geoLookupRejected)
+		return GeoResult{}, ErrGeoCircuitOpen
+	}
+
+	result, err := r.resolveWithTimeout(ip)
+	if err != nil {
+		r.breaker.failure()
+		r.record(geoLookupFailure)
+		return GeoResult{}, err
+	}
+	r.breaker.success()
+	r.record(geoLookupMiss)
+
+	r.cache.Set(key, result)
+	return result, nil
+}
+
+// resolveWithTimeout gives up waiting after the configured timeout. The
+// underlying lookup keeps running in the background until it returns on its
+// own, so wrapped resolvers should still enforce their own deadlines.
+func (r *CachedGeoResolver) resolveWithTimeout(ip string) (GeoResult, error) {
+	type lookup struct {
+		result GeoResult
+		err    error
+	}
+
+	done := make(chan lookup, 1)
+	go func() {
+		result, err := r.next.Resolve(ip)
+		done <- lookup{result, err}
+	}()
+
+	timer := time.NewTimer(r.timeout)
+	defer timer.Stop()
+
+	select {
+	case l := <-done:
+		return l.result, l.err
+	case <-timer.C:
+		return GeoResult{}, ErrGeoLookupTimeout
+	}
+}
+
+func (r *CachedGeoResolver) cacheKey(ip string) string {
+	if !r.groupByPrefix {
+		return ip
+	}
+
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return ip
+	}
+
+	if v4 := parsed.To4(); v4 != nil {
+		return v4.Mask(net.CIDRMask(24, 32)).String() + "/24"
+	}
+
+	return parsed.Mask(net.CIDRMask(48, 128)).String() + "/48"
+}
+
+func (r *CachedGeoResolver) record(result string) {
+	if r.lookups == nil {
+		return
+	}
+
+	r.lookups.Add(
+		context.Background(),
+		1,
+		metric.WithAttributes(attribute.String("result", result)),
+	)
+}
+
+type circuitState int
+
+const (
+	circuitClosed circuitState = iota
+	circuitOpen
+	circuitHalfOpen
+)
+
+type circuitBreaker struct {
+	threshold    int
+	openDuration time.Duration
+
+	mu       sync.Mutex
+	state    circuitState
+	failures int
+	openedAt time.Time
+}
+
+func newCircuitBreaker(threshold int, openDuration time.Duration) *circuitBreaker {
+	return &circuitBreaker{
+		threshold:    threshold,
+		openDuration: openDuration,
+	}
+}
+
+// allow reports whether a call may go through. Once the open period has
+// passed exactly one trial call is allowed; its outcome decides whether the
+// circuit closes again or stays open for another period.
+func (b *circuitBreaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case circuitOpen:
+		if time.Since(b.openedAt) < b.openDuration {
+			return false
+		}
+		b.state = circuitHalfOpen
+		return true
+	case circuitHalfOpen:
+		return false
+	default:
+		return true
+	}
+}
+
+func (b *circuitBreaker) success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.state = circuitClosed
+	b.failures = 0
+}
+
+func (b *circuitBreaker) failure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	if b.state == circuitOpen {
+		return
+	}
+
+	if b.state == circuitHalfOpen || b.failures >= b.threshold {
+		slog.Warn("geo lookup circuit opened", "failures", b.failures)
+		b.state = circuitOpen
+		b.openedAt = time.Now()
+	}
+}
@@ -0,0 +1,150 @@
+package services
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeGeoResolver answers every lookup with result, or err when set, after
+// waiting delay.
+type fakeGeoResolver struct {
+	mu     sync.Mutex
+	result GeoResult
+	err    error
+	delay  time.Duration
+	calls  int
+}
+
+func (f *fakeGeoResolver) Resolve(ip string) (GeoResult, error) {
+	f.mu.Lock()
+	f.calls++
+	result, err, delay := f.result, f.err, f.delay
+	f.mu.Unlock()
+
+	time.Sleep(delay)
+	return result, err
+}
+
+func (f *fakeGeoResolver) set(err error) {
+	f.mu.Lock()
+	f.err = err
+	f.mu.Unlock()
+}
+
+func (f *fakeGeoResolver) callCount() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.calls
+}
+
+func TestCachedGeoResolverCache(t *testing.T) {
+	tests := []struct {
+		name          string
+		groupByPrefix bool
+		ips           []string
+		wantCalls     int
+	}{
+		{name: "same address", ips: []string{"192.0.2.1", "192.0.2.1"}, wantCalls: 1},
+		{name: "neighbours per address", ips: []string{"192.0.2.1", "192.0.2.200"}, wantCalls: 2},
+		{name: "neighbours per prefix", groupByPrefix: true, ips: []string{"192.0.2.1", "192.0.2.200"}, wantCalls: 1},
+		{name: "ipv6 per prefix", groupByPrefix: true, ips: []string{"2001:db8:1::1", "2001:db8:1:ff::1"}, wantCalls: 1},
+		{name: "other networks per prefix", groupByPrefix: true, ips: []string{"192.0.2.1", "198.51.100.1"}, wantCalls: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next := &fakeGeoResolver{result: GeoResult{CountryCode: "DK"}}
+			r := NewCachedGeoResolver(next, CachedGeoOptions{GroupByPrefix: tt.groupByPrefix})
+
+			for _, ip := range tt.ips {
+				result, err := r.Resolve(ip)
+				if err != nil {
+					t.Fatalf("Resolve(%s) error = %v", ip, err)
+				}
+				if result.CountryCode != "DK" {
+					t.Errorf("Resolve(%s) = %+v", ip, result)
+				}
+			}
+
+			if got := next.callCount(); got != tt.wantCalls {
+				t.Errorf("upstream called %d times, want %d", got, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestCachedGeoResolverTimeout(t *testing.T) {
+	next := &fakeGeoResolver{delay: 50 * time.Millisecond}
+	r := NewCachedGeoResolver(next, CachedGeoOptions{Timeout: 5 * time.Millisecond})
+
+	if _, err := r.Resolve("192.0.2.1"); !errors.Is(err, ErrGeoLookupTimeout) {
+		t.Fatalf("Resolve() error = %v, want %v", err, ErrGeoLookupTimeout)
+	}
+}
+
+func TestCachedGeoResolverCircuitBreaker(t *testing.T) {
+	errUpstream := errors.New("upstream is down")
+
+	tests := []struct {
+		name       string
+		trialErr   error
+		wantTrial  error
+		wantOpenAs error
+	}{
+		// A successful trial lookup closes the circuit again.
+		{name: "half-open trial succeeds"},
+		// A failed trial lookup opens it for another period.
+		{name: "half-open trial fails", trialErr: errUpstream, wantTrial: errUpstream, wantOpenAs: ErrGeoCircuitOpen},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next := &fakeGeoResolver{err: errUpstream}
+			r := NewCachedGeoResolver(next, CachedGeoOptions{
+				FailureThreshold: 2,
+				OpenDuration:     20 * time.Millisecond,
+			})
+
+			// Closed: failures go through until the threshold is reached.
+			for _, ip := range []string{"192.0.2.1", "192.0.2.2"} {
+				if _, err := r.Resolve(ip); !errors.Is(err, errUpstream) {
+					t.Fatalf("Resolve(%s) error = %v, want %v", ip, err, errUpstream)
+				}
+			}
+
+			// Open: lookups are rejected without reaching the upstream.
+			if _, err := r.Resolve("192.0.2.3"); !errors.Is(err, ErrGeoCircuitOpen) {
+				t.Fatalf("Resolve() error = %v, want %v", err, ErrGeoCircuitOpen)
+			}
+			if got := next.callCount(); got != 2 {
+				t.Fatalf("upstream called %d times while open, want 2", got)
+			}
+
+			// Half-open: a single trial lookup decides what happens next.
+			time.Sleep(30 * time.Millisecond)
+			next.set(tt.trialErr)
+			if _, err := r.Resolve("192.0.2.4"); !errors.Is(err, tt.wantTrial) {
+				t.Fatalf("trial Resolve() error = %v, want %v", err, tt.wantTrial)
+			}
+
+			if _, err := r.Resolve("192.0.2.5"); !errors.Is(err, tt.wantOpenAs) {
+				t.Errorf("Resolve() after trial error = %v, want %v", err, tt.wantOpenAs)
+			}
+		})
+	}
+}
+
+func TestCircuitBreakerHalfOpenAllowsOneTrial(t *testing.T) {
+	b := newCircuitBreaker(1, time.Millisecond)
+	b.failure()
+	time.Sleep(5 * time.Millisecond)
+
+	if !b.allow() {
+		t.Fatal("trial call was rejected")
+	}
+	if b.allow() {
+		t.Error("second call was let through while the trial is running")
+	}
+}
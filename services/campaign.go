@@ -0,0 +1,112 @@
+package services
+
+import (
+	"net/url"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+const maxCampaignLength = 255
+
+// Campaign holds the attribution parameters of a hit URL.
+type Campaign struct {
+	Source  string
+	Medium  string
+	Name    string
+	Term    string
+	Content string
+	// ClickID names the ad click identifier the URL carried, such as gclid.
+	// The identifier itself is not kept.
+	ClickID string
+}
+
+// clickIDParams are the ad click identifiers of the major ad networks.
+var clickIDParams = []string{
+	"gclid", "gbraid", "wbraid", "dclid", "fbclid", "msclkid",
+	"ttclid", "twclid", "li_fat_id", "yclid",
+}
+
+// ParseCampaign extracts the UTM parameters, ref and click identifiers from a
+// hit URL, which may be a path or an absolute URL. It returns the URL with
+// those parameters removed, so one page isn't split up by the campaigns that
+// led to it. ref is used as the source when utm_source is missing.
+func ParseCampaign(rawURL string) (string, Campaign) {
+	base, rawQuery, ok := strings.Cut(rawURL, "?")
+	if !ok {
+		return rawURL, Campaign{}
+	}
+
+	fragment := ""
+	if i := strings.IndexByte(rawQuery, '#'); i >= 0 {
+		rawQuery, fragment = rawQuery[:i], rawQuery[i:]
+	}
+
+	var campaign Campaign
+	var ref string
+	var kept []string
+	for pair := range strings.SplitSeq(rawQuery, "&") {
+		if pair == "" {
+			continue
+		}
+
+		rawKey, rawValue, _ := strings.Cut(pair, "=")
+		key, err := url.QueryUnescape(rawKey)
+		if err != nil {
+			kept = append(kept, pair)
+			continue
+		}
+		value, err := url.QueryUnescape(rawValue)
+		if err != nil {
+			value = rawValue
+		}
+
+		switch key = strings.ToLower(key); key {
+		case "utm_source":
+			campaign.Source = campaignValue(value)
+		case "utm_medium":
+			campaign.Medium = campaignValue(value)
+		case "utm_campaign":
+			campaign.Name = campaignValue(value)
+		case "utm_term":
+			campaign.Term = campaignValue(value)
+		case "utm_content":
+			campaign.Content = campaignValue(value)
+		case "ref":
+			ref = campaignValue(value)
+		default:
+			if slices.Contains(clickIDParams, key) {
+				if campaign.ClickID == "" {
+					campaign.ClickID = key
+				}
+				continue
+			}
+			kept = append(kept, pair)
+		}
+	}
+
+	if campaign.Source == "" {
+		campaign.Source = ref
+	}
+
+	canonical := base
+	if len(kept) > 0 {
+		canonical += "?" + strings.Join(kept, "&")
+	}
+	return canonical + fragment, campaign
+}
+
+// campaignValue trims a parameter value and cuts it to the length of its
+// column.
+func campaignValue(value string) string {
+	value = strings.TrimSpace(value)
+	if len(value) <= maxCampaignLength {
+		return value
+	}
+
+	value = value[:maxCampaignLength]
+	for !utf8.ValidString(value) {
+		value = value[:len(value)-1]
+	}
+	return value
+}
@@ -0,0 +1,57 @@
+package services
+
+import "testing"
+
+func TestParseCampaign(t *testing.T) {
+	tests := []struct {
+		name         string
+		rawURL       string
+		wantURL      string
+		wantCampaign Campaign
+	}{
+		{
+			name:    "no query",
+			rawURL:  "/pricing",
+			wantURL: "/pricing",
+		},
+		{
+			name:    "other parameters are kept in order",
+			rawURL:  "/search?q=go&page=2",
+			wantURL: "/search?q=go&page=2",
+		},
+		{
+			name:    "utm parameters are stripped",
+			rawURL:  "/pricing?utm_source=newsletter&plan=pro&utm_medium=email&utm_campaign=spring+sale#faq",
+			wantURL: "/pricing?plan=pro#faq",
+			wantCampaign: Campaign{
+				Source: "newsletter",
+				Medium: "email",
+				Name:   "spring sale",
+			},
+		},
+		{
+			name:         "ref is the fallback source",
+			rawURL:       "https://example.com/?ref=producthunt",
+			wantURL:      "https://example.com/",
+			wantCampaign: Campaign{Source: "producthunt"},
+		},
+		{
+			name:         "click identifiers are dropped",
+			rawURL:       "/landing?gclid=abc123&UTM_Source=google",
+			wantURL:      "/landing",
+			wantCampaign: Campaign{Source: "google", ClickID: "gclid"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotURL, gotCampaign := ParseCampaign(tt.rawURL)
+			if gotURL != tt.wantURL {
+				t.Errorf("url = %q, want %q", gotURL, tt.wantURL)
+			}
+			if gotCampaign != tt.wantCampaign {
+				t.Errorf("campaign = %+v, want %+v", gotCampaign, tt.wantCampaign)
+			}
+		})
+	}
+}
@@ -12,6 +12,10 @@ type GeoResult struct {
 	CountryName string
 	City        string
 	Region      string
+	// ASN and ASOrganization are only filled in by resolvers that have
+	// access to an ASN database.
+	ASN            uint32
+	ASOrganization string
 }
 
 type GeoResolver interface {
@@ -38,17 +42,17 @@ type ipAPIResponse struct {
 func (r *IPAPIGeoResolver) Resolve(ip string) (GeoResult, error) {
 	resp, err := r.client.Get(fmt.Sprintf("http://ip-api.com/json/%s?fields=countryCode,country,city,regionName", ip))
 	if err != nil {
-		return GeoResult{}, nil
+		return GeoResult{}, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return GeoResult{}, nil
+		return GeoResult{}, fmt.Errorf("ip-api returned status %d", resp.StatusCode)
 	}
 
 	var data ipAPIResponse
 	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
-		return GeoResult{}, nil
+		return GeoResult{}, err
 	}
 
 	return GeoResult{
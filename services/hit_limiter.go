@@ -0,0 +1,117 @@
+package services
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"palantir/models"
+	"palantir/telemetry"
+
+	"github.com/maypok86/otter/v2"
+	"go.opentelemetry.io/otel/metric"
+	"golang.org/x/time/rate"
+)
+
+type HitLimiterOptions struct {
+	// RatePerSecond and Burst apply to websites without their own limits.
+	RatePerSecond float64
+	Burst         int
+	// Size caps the number of visitors tracked at once.
+	Size int
+}
+
+// HitLimiter is a token bucket per website and client IP. Buckets are kept
+// in memory and forgotten once a visitor has been idle for a while.
+type HitLimiter struct {
+	buckets      *otter.Cache[string, *rate.Limiter]
+	defaultRate  rate.Limit
+	defaultBurst int
+	throttled    metric.Int64Counter
+}
+
+func NewHitLimiter(opts HitLimiterOptions) *HitLimiter {
+	if opts.RatePerSecond <= 0 {
+		opts.RatePerSecond = 5
+	}
+	if opts.Burst < 1 {
+		opts.Burst = 20
+	}
+	if opts.Size < 1 {
+		opts.Size = 100_000
+	}
+
+	throttled, err := telemetry.CollectThrottledTotal()
+	if err != nil {
+		slog.Warn("failed to create collect_throttled_total metric", "error", err)
+	}
+
+	return &HitLimiter{
+		buckets: otter.Must(&otter.Options[string, *rate.Limiter]{
+			MaximumSize:      opts.Size,
+			ExpiryCalculator: otter.ExpiryAccessing[string, *rate.Limiter](10 * time.Minute),
+		}),
+		defaultRate:  rate.Limit(opts.RatePerSecond),
+		defaultBurst: opts.Burst,
+		throttled:    throttled,
+	}
+}
+
+// Allow takes a token for a hit from ip to website. When the bucket is empty
+// it returns false and how long the client should wait before retrying.
+func (l *HitLimiter) Allow(website models.Website, ip string) (time.Duration, bool) {
+	allowed, wait := l.AllowN(website, ip, 1)
+	return wait, allowed == 1
+}
+
+// AllowN takes up to n tokens for hits from ip to website and returns how
+// many it got. When it got fewer than n it also returns how long the client
+// should wait for the next token.
+func (l *HitLimiter) AllowN(website models.Website, ip string, n int) (int, time.Duration) {
+	limit, burst := l.defaultRate, l.defaultBurst
+	if website.RateLimitPerSecond > 0 {
+		limit = rate.Limit(website.RateLimitPerSecond)
+	}
+	if website.RateLimitBurst > 0 {
+		burst = int(website.RateLimitBurst)
+	}
+
+	key := website.ID.String() + "|" + ip
+	limiter, _ := l.buckets.SetIfAbsent(key, rate.NewLimiter(limit, burst))
+
+	// Pick up changed website settings without dropping the bucket.
+	if limiter.Limit() != limit {
+		limiter.SetLimit(limit)
+	}
+	if limiter.Burst() != burst {
+		limiter.SetBurst(burst)
+	}
+
+	now := time.Now()
+	allowed := min(n, int(limiter.TokensAt(now)))
+	if allowed > 0 && !limiter.AllowN(now, allowed) {
+		// Another request took the tokens in the meantime.
+		allowed = 0
+	}
+	if allowed == n {
+		return allowed, 0
+	}
+
+	reservation := limiter.ReserveN(now, 1)
+	wait := reservation.DelayFrom(now)
+	reservation.CancelAt(now)
+	l.record(n - allowed)
+
+	return allowed, wait
+}
+
+// record counts throttled hits. The counter has no per website attribute,
+// as that would give it a series for every website; the dropped hits table
+// has the breakdown.
+func (l *HitLimiter) record(hits int) {
+	if l.throttled == nil {
+		return
+	}
+
+	l.throttled.Add(context.Background(), int64(hits))
+}
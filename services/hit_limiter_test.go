@@ -0,0 +1,60 @@
+package services
+
+import (
+	"testing"
+
+	"palantir/models"
+
+	"github.com/google/uuid"
+)
+
+func TestHitLimiterAllow(t *testing.T) {
+	tests := []struct {
+		name    string
+		website models.Website
+		want    int
+	}{
+		{name: "default burst", website: models.Website{ID: uuid.New()}, want: 3},
+		{name: "website burst", website: models.Website{ID: uuid.New(), RateLimitPerSecond: 1, RateLimitBurst: 5}, want: 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := NewHitLimiter(HitLimiterOptions{RatePerSecond: 1, Burst: 3})
+
+			allowed := 0
+			for range 10 {
+				if _, ok := l.Allow(tt.website, "192.0.2.1"); ok {
+					allowed++
+				}
+			}
+			if allowed != tt.want {
+				t.Errorf("allowed %d hits, want %d", allowed, tt.want)
+			}
+
+			wait, ok := l.Allow(tt.website, "192.0.2.1")
+			if ok || wait <= 0 {
+				t.Errorf("Allow() = %v, %v, want a wait", wait, ok)
+			}
+
+			if _, ok := l.Allow(tt.website, "192.0.2.2"); !ok {
+				t.Error("another visitor was throttled")
+			}
+		})
+	}
+}
+
+func TestHitLimiterAllowN(t *testing.T) {
+	l := NewHitLimiter(HitLimiterOptions{RatePerSecond: 1, Burst: 3})
+	website := models.Website{ID: uuid.New()}
+
+	allowed, wait := l.AllowN(website, "192.0.2.1", 5)
+	if allowed != 3 || wait <= 0 {
+		t.Errorf("AllowN() = %d, %v, want 3 and a wait", allowed, wait)
+	}
+
+	allowed, wait = l.AllowN(website, "192.0.2.1", 2)
+	if allowed != 0 || wait <= 0 {
+		t.Errorf("AllowN() = %d, %v, want 0 and a wait", allowed, wait)
+	}
+}
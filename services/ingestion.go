@@ -0,0 +1,460 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"palantir/internal/storage"
+	"palantir/models"
+
+	"github.com/google/uuid"
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	HitTypePageview = "pageview"
+	HitTypeEvent    = "event"
+	// HitTypeEngagement reports time on page and scroll depth for an earlier
+	// pageview rather than being stored on its own.
+	HitTypeEngagement = "engagement"
+)
+
+const (
+	geoLookupConcurrency = 8
+	flushTimeout         = 10 * time.Second
+	// A batch that fails to store is retried this many times in total,
+	// waiting flushRetryBackoff after the first failure and twice as long
+	// after each one that follows, before its hits are counted as lost.
+	flushAttempts     = 3
+	flushRetryBackoff = 250 * time.Millisecond
+	// shutdownTimeout bounds how long Shutdown waits for the buffer to
+	// drain.
+	shutdownTimeout = 30 * time.Second
+)
+
+var (
+	ErrIngestionBufferFull = errors.New("ingestion buffer is full")
+	ErrIngestionStopped    = errors.New("ingestion pipeline is shutting down")
+)
+
+// Hit is a validated tracking hit waiting to be written. Geolocation is
+// resolved when the hit is flushed so the collect request never waits on it.
+type Hit struct {
+	Type string
+	// ReceivedAt becomes the stored creation time. Batch submissions may set
+	// it from a client supplied timestamp.
+	ReceivedAt time.Time
+	WebsiteID  uuid.UUID
+	// URL has its campaign parameters removed; they are kept in Campaign.
+	// The website's URL rules have been applied to it.
+	URL string
+	// PageGroup is the website path pattern URL matched, if any.
+	PageGroup string
+	// Hostname is the host of the page the hit was sent from.
+	Hostname string
+	Referrer string
+	// ParsedReferrer is empty when the referrer is internal to the website.
+	ParsedReferrer Referrer
+	Campaign       Campaign
+	Client
+	Language string
+	// ScreenWidth is the width of the visitor's screen and ViewportWidth
+	// that of the browser window, which ScreenSize is classified from.
+	ScreenWidth   int32
+	ViewportWidth int32
+	PixelRatio    float32
+	ScreenSize    string
+	EventName     string
+	EventData     json.RawMessage
+	VisitorHash   string
+	IP            string
+	// EngagedMs and ScrollDepth are only set on engagement hits.
+	EngagedMs   int32
+	ScrollDepth int16
+}
+
+type IngestionOptions struct {
+	BufferSize    int
+	BatchSize     int
+	FlushInterval time.Duration
+}
+
+// IngestionPipeline buffers hits in memory and writes them to Postgres in
+// batches, flushing whenever a batch fills up or the flush interval elapses.
+type IngestionPipeline struct {
+	db            storage.Pool
+	geo           GeoResolver
+	hits          chan Hit
+	batchSize     int
+	flushInterval time.Duration
+	retryBackoff  time.Duration
+
+	// store and storeDropped write to Postgres. They are fields so the
+	// flush loop can be exercised without a database.
+	store        func(context.Context, []Hit) error
+	storeDropped func(context.Context, []models.DroppedHitCount) error
+
+	mu     sync.RWMutex
+	closed bool
+	done   chan struct{}
+
+	droppedMu sync.Mutex
+	dropped   map[droppedKey]int64
+}
+
+type droppedKey struct {
+	websiteID uuid.UUID
+	day       time.Time
+	reason    string
+}
+
+func NewIngestionPipeline(
+	db storage.Pool,
+	geo GeoResolver,
+	opts IngestionOptions,
+) *IngestionPipeline {
+	if opts.BufferSize < 1 {
+		opts.BufferSize = 10_000
+	}
+	if opts.BatchSize < 1 {
+		opts.BatchSize = 500
+	}
+	if opts.FlushInterval <= 0 {
+		opts.FlushInterval = time.Second
+	}
+
+	p := &IngestionPipeline{
+		db:            db,
+		geo:           geo,
+		hits:          make(chan Hit, opts.BufferSize),
+		batchSize:     opts.BatchSize,
+		flushInterval: opts.FlushInterval,
+		retryBackoff:  flushRetryBackoff,
+		done:          make(chan struct{}),
+		dropped:       make(map[droppedKey]int64),
+	}
+	p.store = p.Store
+	p.storeDropped = func(ctx context.Context, counts []models.DroppedHitCount) error {
+		return models.RecordDroppedHits(ctx, p.db.Conn(), counts)
+	}
+
+	return p
+}
+
+// Start launches the flush loop. Once ctx is cancelled the pipeline stops
+// accepting hits and writes everything buffered, so hits aren't lost when
+// Shutdown is never called.
+func (p *IngestionPipeline) Start(ctx context.Context) {
+	go p.run(ctx)
+}
+
+// Enqueue hands a hit to the pipeline without blocking. It returns
+// ErrIngestionBufferFull when the buffer is at capacity and
+// ErrIngestionStopped once shutdown has begun.
+func (p *IngestionPipeline) Enqueue(hit Hit) error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.closed {
+		return ErrIngestionStopped
+	}
+
+	select {
+	case p.hits <- hit:
+		return nil
+	default:
+		return ErrIngestionBufferFull
+	}
+}
+
+// RecordDropped counts a hit that was rejected before it reached the
+// pipeline. Counts are aggregated in memory and written on the next tick.
+func (p *IngestionPipeline) RecordDropped(websiteID uuid.UUID, reason string) {
+	key := droppedKey{
+		websiteID: websiteID,
+		day:       time.Now().UTC().Truncate(24 * time.Hour),
+		reason:    reason,
+	}
+
+	p.droppedMu.Lock()
+	p.dropped[key]++
+	p.droppedMu.Unlock()
+}
+
+// Shutdown stops accepting hits and waits for everything buffered to be
+// written. It implements server.Shutdowner. The server hands over a context
+// that is already cancelled, so the wait is bounded by shutdownTimeout
+// rather than by ctx.
+func (p *IngestionPipeline) Shutdown(ctx context.Context) error {
+	p.close()
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), shutdownTimeout)
+	defer cancel()
+
+	select {
+	case <-p.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// close stops accepting hits. The flush loop exits once it has drained the
+// buffer.
+func (p *IngestionPipeline) close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if !p.closed {
+		p.closed = true
+		close(p.hits)
+	}
+}
+
+func (p *IngestionPipeline) run(ctx context.Context) {
+	defer close(p.done)
+
+	// Writes must outlive ctx so the final flush still goes through.
+	stop := ctx.Done()
+	ctx = context.WithoutCancel(ctx)
+
+	ticker := time.NewTicker(p.flushInterval)
+	defer ticker.Stop()
+
+	batch := make([]Hit, 0, p.batchSize)
+	for {
+		select {
+		case <-stop:
+			p.close()
+			stop = nil
+		case hit, ok := <-p.hits:
+			if !ok {
+				p.flush(ctx, batch)
+				p.flushDropped(ctx)
+				return
+			}
+
+			batch = append(batch, hit)
+			if len(batch) >= p.batchSize {
+				p.flush(ctx, batch)
+				batch = batch[:0]
+			}
+		case <-ticker.C:
+			if len(batch) > 0 {
+				p.flush(ctx, batch)
+				batch = batch[:0]
+			}
+			p.flushDropped(ctx)
+		}
+	}
+}
+
+// flush writes the batch, retrying with backoff when it fails. Hits that
+// still can't be stored are counted as dropped so the loss shows up next to
+// the website's other dropped hits.
+func (p *IngestionPipeline) flush(ctx context.Context, batch []Hit) {
+	if len(batch) == 0 {
+		return
+	}
+
+	backoff := p.retryBackoff
+	for attempt := 1; ; attempt++ {
+		err := p.storeWithTimeout(ctx, batch)
+		if err == nil {
+			return
+		}
+
+		if attempt == flushAttempts {
+			slog.ErrorContext(ctx, "failed to flush ingestion batch, dropping hits", "error", err, "hits", len(batch))
+			for _, hit := range batch {
+				p.RecordDropped(hit.WebsiteID, models.DroppedReasonStoreFailed)
+			}
+			return
+		}
+
+		slog.WarnContext(ctx, "failed to flush ingestion batch, retrying", "error", err, "hits", len(batch), "attempt", attempt)
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+func (p *IngestionPipeline) storeWithTimeout(ctx context.Context, batch []Hit) error {
+	ctx, cancel := context.WithTimeout(ctx, flushTimeout)
+	defer cancel()
+
+	return p.store(ctx, batch)
+}
+
+// Store resolves geolocation for the hits, assigns them to sessions and
+// writes everything in a single transaction, bypassing the buffer. It is used
+// for batch submissions whose callers need to know the outcome.
+func (p *IngestionPipeline) Store(ctx context.Context, hits []Hit) error {
+	geo := p.resolveGeo(hits)
+
+	tx, err := p.db.BeginTx(ctx)
+	if err != nil {
+		return err
+	}
+
+	sessionIDs, err := sessionize(ctx, txSessionStore{exec: tx}, hits)
+	if err != nil {
+		_ = p.db.RollBackTx(ctx, tx)
+		return err
+	}
+
+	var pageviews []models.CreatePageviewData
+	var events []models.CreateEventData
+	var engagement []models.PageviewEngagement
+	for i, hit := range hits {
+		loc := geo[hit.IP]
+
+		switch hit.Type {
+		case HitTypePageview:
+			pageviews = append(pageviews, models.CreatePageviewData{
+				CreatedAt:      hit.ReceivedAt,
+				WebsiteID:      hit.WebsiteID,
+				URL:            hit.URL,
+				PageGroup:      hit.PageGroup,
+				Hostname:       hit.Hostname,
+				Referrer:       hit.Referrer,
+				ReferrerSource: hit.ParsedReferrer.Source,
+				UTMSource:      hit.Campaign.Source,
+				UTMMedium:      hit.Campaign.Medium,
+				UTMCampaign:    hit.Campaign.Name,
+				UTMTerm:        hit.Campaign.Term,
+				UTMContent:     hit.Campaign.Content,
+				ClickID:        hit.Campaign.ClickID,
+				Browser:        hit.Browser,
+				BrowserVersion: hit.BrowserVersion,
+				OS:             hit.OS,
+				OSVersion:      hit.OSVersion,
+				Device:         hit.Device,
+				DeviceBrand:    hit.DeviceBrand,
+				DeviceModel:    hit.DeviceModel,
+				Language:       hit.Language,
+				ScreenWidth:    hit.ScreenWidth,
+				ViewportWidth:  hit.ViewportWidth,
+				PixelRatio:     hit.PixelRatio,
+				ScreenSize:     hit.ScreenSize,
+				VisitorHash:    hit.VisitorHash,
+				CountryCode:    loc.CountryCode,
+				CountryName:    loc.CountryName,
+				City:           loc.City,
+				Region:         loc.Region,
+				SessionID:      sessionIDs[i],
+			})
+		case HitTypeEvent:
+			events = append(events, models.CreateEventData{
+				CreatedAt:   hit.ReceivedAt,
+				WebsiteID:   hit.WebsiteID,
+				URL:         hit.URL,
+				EventName:   hit.EventName,
+				EventData:   hit.EventData,
+				VisitorHash: hit.VisitorHash,
+				CountryCode: loc.CountryCode,
+				CountryName: loc.CountryName,
+				City:        loc.City,
+				Region:      loc.Region,
+				SessionID:   sessionIDs[i],
+				Hostname:    hit.Hostname,
+				Language:    hit.Language,
+			})
+		case HitTypeEngagement:
+			engagement = append(engagement, models.PageviewEngagement{
+				WebsiteID:   hit.WebsiteID,
+				VisitorHash: hit.VisitorHash,
+				URL:         hit.URL,
+				SentAt:      hit.ReceivedAt,
+				EngagedMs:   hit.EngagedMs,
+				ScrollDepth: hit.ScrollDepth,
+			})
+		}
+	}
+
+	if _, err := models.CreatePageviews(ctx, tx, pageviews); err != nil {
+		_ = p.db.RollBackTx(ctx, tx)
+		return err
+	}
+
+	if _, err := models.CreateEvents(ctx, tx, events); err != nil {
+		_ = p.db.RollBackTx(ctx, tx)
+		return err
+	}
+
+	// Pageviews from the same batch are written first so engagement sent
+	// right after them still finds its pageview.
+	if _, err := models.RecordPageviewEngagement(ctx, tx, engagement); err != nil {
+		_ = p.db.RollBackTx(ctx, tx)
+		return err
+	}
+
+	return p.db.CommitTx(ctx, tx)
+}
+
+func (p *IngestionPipeline) flushDropped(ctx context.Context) {
+	p.droppedMu.Lock()
+	if len(p.dropped) == 0 {
+		p.droppedMu.Unlock()
+		return
+	}
+	dropped := p.dropped
+	p.dropped = make(map[droppedKey]int64)
+	p.droppedMu.Unlock()
+
+	counts := make([]models.DroppedHitCount, 0, len(dropped))
+	for key, hits := range dropped {
+		counts = append(counts, models.DroppedHitCount{
+			WebsiteID: key.websiteID,
+			Day:       key.day,
+			Reason:    key.reason,
+			Hits:      hits,
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, flushTimeout)
+	defer cancel()
+
+	if err := p.storeDropped(ctx, counts); err != nil {
+		slog.ErrorContext(ctx, "failed to record dropped hits", "error", err)
+	}
+}
+
+// resolveGeo looks up every distinct IP in the batch once, with a bounded
+// number of lookups in flight.
+func (p *IngestionPipeline) resolveGeo(batch []Hit) map[string]GeoResult {
+	var ips []string
+	seen := make(map[string]struct{}, len(batch))
+	for _, hit := range batch {
+		if _, ok := seen[hit.IP]; ok || hit.IP == "" {
+			continue
+		}
+		seen[hit.IP] = struct{}{}
+		ips = append(ips, hit.IP)
+	}
+
+	var mu sync.Mutex
+	results := make(map[string]GeoResult, len(ips))
+
+	var eg errgroup.Group
+	eg.SetLimit(geoLookupConcurrency)
+	for _, ip := range ips {
+		eg.Go(func() error {
+			geo, err := p.geo.Resolve(ip)
+			if err != nil {
+				return nil
+			}
+
+			mu.Lock()
+			results[ip] = geo
+			mu.Unlock()
+			return nil
+		})
+	}
+	_ = eg.Wait()
+
+	return results
+}
@@ -0,0 +1,231 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"palantir/models"
+
+	"github.com/google/uuid"
+)
+
+// fakeStore stands in for Postgres, recording what the pipeline writes and
+// failing as many stores as failures asks for.
+type fakeStore struct {
+	mu       sync.Mutex
+	failures int
+	hits     []Hit
+	dropped  []models.DroppedHitCount
+	stored   chan int
+}
+
+func newTestPipeline(opts IngestionOptions) (*IngestionPipeline, *fakeStore) {
+	store := &fakeStore{stored: make(chan int, 100)}
+
+	p := NewIngestionPipeline(nil, nil, opts)
+	p.retryBackoff = time.Millisecond
+	p.store = func(_ context.Context, hits []Hit) error {
+		store.mu.Lock()
+		defer store.mu.Unlock()
+
+		if store.failures > 0 {
+			store.failures--
+			return errors.New("database is down")
+		}
+
+		store.hits = append(store.hits, hits...)
+		store.stored <- len(hits)
+		return nil
+	}
+	p.storeDropped = func(_ context.Context, counts []models.DroppedHitCount) error {
+		store.mu.Lock()
+		defer store.mu.Unlock()
+
+		store.dropped = append(store.dropped, counts...)
+		return nil
+	}
+
+	return p, store
+}
+
+func (s *fakeStore) storedHits() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.hits)
+}
+
+func (s *fakeStore) waitForFlush(t *testing.T) int {
+	t.Helper()
+
+	select {
+	case n := <-s.stored:
+		return n
+	case <-time.After(time.Second):
+		t.Fatal("batch was not flushed")
+		return 0
+	}
+}
+
+func testHit() Hit {
+	return Hit{Type: HitTypePageview, WebsiteID: uuid.New(), URL: "https://example.com/"}
+}
+
+func TestEnqueueBufferFull(t *testing.T) {
+	p, _ := newTestPipeline(IngestionOptions{BufferSize: 2})
+
+	for range 2 {
+		if err := p.Enqueue(testHit()); err != nil {
+			t.Fatalf("Enqueue() error = %v", err)
+		}
+	}
+
+	if err := p.Enqueue(testHit()); !errors.Is(err, ErrIngestionBufferFull) {
+		t.Fatalf("Enqueue() error = %v, want %v", err, ErrIngestionBufferFull)
+	}
+}
+
+func TestEnqueueAfterShutdown(t *testing.T) {
+	p, _ := newTestPipeline(IngestionOptions{})
+	p.Start(t.Context())
+
+	if err := p.Shutdown(t.Context()); err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+
+	if err := p.Enqueue(testHit()); !errors.Is(err, ErrIngestionStopped) {
+		t.Fatalf("Enqueue() error = %v, want %v", err, ErrIngestionStopped)
+	}
+}
+
+func TestShutdownDrainsWithCancelledContext(t *testing.T) {
+	p, store := newTestPipeline(IngestionOptions{BatchSize: 100, FlushInterval: time.Hour})
+
+	ctx, cancel := context.WithCancel(t.Context())
+	p.Start(ctx)
+
+	for range 3 {
+		if err := p.Enqueue(testHit()); err != nil {
+			t.Fatalf("Enqueue() error = %v", err)
+		}
+	}
+
+	// The server shuts components down with a context that has already been
+	// cancelled.
+	cancel()
+	if err := p.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+
+	if got := store.storedHits(); got != 3 {
+		t.Fatalf("stored %d hits, want 3", got)
+	}
+}
+
+func TestFlushWhenBatchFills(t *testing.T) {
+	p, store := newTestPipeline(IngestionOptions{BatchSize: 2, FlushInterval: time.Hour})
+	p.Start(t.Context())
+	t.Cleanup(func() { _ = p.Shutdown(t.Context()) })
+
+	for range 2 {
+		if err := p.Enqueue(testHit()); err != nil {
+			t.Fatalf("Enqueue() error = %v", err)
+		}
+	}
+
+	if n := store.waitForFlush(t); n != 2 {
+		t.Fatalf("flushed %d hits, want 2", n)
+	}
+}
+
+func TestFlushOnInterval(t *testing.T) {
+	p, store := newTestPipeline(IngestionOptions{BatchSize: 100, FlushInterval: 10 * time.Millisecond})
+	p.Start(t.Context())
+	t.Cleanup(func() { _ = p.Shutdown(t.Context()) })
+
+	if err := p.Enqueue(testHit()); err != nil {
+		t.Fatalf("Enqueue() error = %v", err)
+	}
+
+	if n := store.waitForFlush(t); n != 1 {
+		t.Fatalf("flushed %d hits, want 1", n)
+	}
+}
+
+func TestFlushRetries(t *testing.T) {
+	tests := []struct {
+		name        string
+		failures    int
+		wantStored  int
+		wantDropped int64
+	}{
+		{name: "recovers", failures: flushAttempts - 1, wantStored: 2},
+		{name: "gives up", failures: flushAttempts, wantDropped: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, store := newTestPipeline(IngestionOptions{BatchSize: 100, FlushInterval: time.Hour})
+			store.failures = tt.failures
+			p.Start(t.Context())
+
+			websiteID := uuid.New()
+			for range 2 {
+				hit := testHit()
+				hit.WebsiteID = websiteID
+				if err := p.Enqueue(hit); err != nil {
+					t.Fatalf("Enqueue() error = %v", err)
+				}
+			}
+
+			if err := p.Shutdown(t.Context()); err != nil {
+				t.Fatalf("Shutdown() error = %v", err)
+			}
+
+			if got := store.storedHits(); got != tt.wantStored {
+				t.Errorf("stored %d hits, want %d", got, tt.wantStored)
+			}
+
+			var dropped int64
+			for _, count := range store.dropped {
+				if count.WebsiteID == websiteID && count.Reason == models.DroppedReasonStoreFailed {
+					dropped += count.Hits
+				}
+			}
+			if dropped != tt.wantDropped {
+				t.Errorf("dropped %d hits, want %d", dropped, tt.wantDropped)
+			}
+		})
+	}
+}
+
+func TestRunDrainsWhenContextCancelled(t *testing.T) {
+	p, store := newTestPipeline(IngestionOptions{BatchSize: 100, FlushInterval: time.Hour})
+
+	ctx, cancel := context.WithCancel(t.Context())
+	p.Start(ctx)
+
+	for range 3 {
+		if err := p.Enqueue(testHit()); err != nil {
+			t.Fatalf("Enqueue() error = %v", err)
+		}
+	}
+
+	// Shutdown is never called, as when the server stops before reaching
+	// the pipeline.
+	cancel()
+	select {
+	case <-p.done:
+	case <-time.After(time.Second):
+		t.Fatal("pipeline did not stop")
+	}
+
+	if got := store.storedHits(); got != 3 {
+		t.Fatalf("stored %d hits, want 3", got)
+	}
+	if err := p.Enqueue(testHit()); !errors.Is(err, ErrIngestionStopped) {
+		t.Fatalf("Enqueue() error = %v, want %v", err, ErrIngestionStopped)
+	}
+}
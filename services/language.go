@@ -0,0 +1,48 @@
+package services
+
+import "strings"
+
+// NormalizeLanguage turns a language tag as browsers report it into its
+// canonical form, so en-us, en_US and EN-US are all counted as en-US. Only
+// the language, script and region are kept; tags that don't start with a
+// language code are dropped.
+func NormalizeLanguage(tag string) string {
+	tag, _, _ = strings.Cut(tag, ",")
+	tag, _, _ = strings.Cut(tag, ";")
+	subtags := strings.Split(strings.ReplaceAll(strings.TrimSpace(tag), "_", "-"), "-")
+
+	language := strings.ToLower(subtags[0])
+	if len(language) < 2 || len(language) > 3 || !isLetters(language) {
+		return ""
+	}
+
+	normalized := language
+	rest := subtags[1:]
+	if len(rest) > 0 && len(rest[0]) == 4 && isLetters(rest[0]) {
+		normalized += "-" + strings.ToUpper(rest[0][:1]) + strings.ToLower(rest[0][1:])
+		rest = rest[1:]
+	}
+	if len(rest) > 0 && (len(rest[0]) == 2 && isLetters(rest[0]) || len(rest[0]) == 3 && isDigits(rest[0])) {
+		normalized += "-" + strings.ToUpper(rest[0])
+	}
+
+	return normalized
+}
+
+func isLetters(s string) bool {
+	for _, r := range s {
+		if (r < 'a' || r > 'z') && (r < 'A' || r > 'Z') {
+			return false
+		}
+	}
+	return true
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
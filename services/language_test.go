@@ -0,0 +1,29 @@
+package services
+
+import "testing"
+
+func TestNormalizeLanguage(t *testing.T) {
+	tests := []struct {
+		tag  string
+		want string
+	}{
+		{tag: "", want: ""},
+		{tag: "en", want: "en"},
+		{tag: "EN", want: "en"},
+		{tag: "en-US", want: "en-US"},
+		{tag: "en-us", want: "en-US"},
+		{tag: "en_US", want: "en-US"},
+		{tag: "zh-hant-tw", want: "zh-Hant-TW"},
+		{tag: "es-419", want: "es-419"},
+		{tag: "de-DE-u-co-phonebk", want: "de-DE"},
+		{tag: "fr-CH, fr;q=0.9", want: "fr-CH"},
+		{tag: "x-klingon", want: ""},
+		{tag: "english", want: ""},
+	}
+
+	for _, tt := range tests {
+		if got := NormalizeLanguage(tt.tag); got != tt.want {
+			t.Errorf("NormalizeLanguage(%q) = %q, want %q", tt.tag, got, tt.want)
+		}
+	}
+}
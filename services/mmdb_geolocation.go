@@ -0,0 +1,236 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/oschwald/maxminddb-golang"
+)
+
+var ErrInvalidIP = errors.New("invalid ip address")
+
+type MMDBOptions struct {
+	// CityDatabasePath points to a GeoIP2/GeoLite2 or DB-IP city database.
+	CityDatabasePath string
+	// ASNDatabasePath optionally points to a matching ASN database.
+	ASNDatabasePath string
+	// ReloadInterval controls how often the files are checked for changes.
+	ReloadInterval time.Duration
+}
+
+// MMDBGeoResolver resolves IPs against local MaxMind-format databases so no
+// visitor IP ever leaves the server. Databases are reopened whenever their
+// modification time changes, which lets a cron job or geoipupdate swap in new
+// releases without a restart.
+type MMDBGeoResolver struct {
+	city *mmdbFile
+	asn  *mmdbFile
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+func NewMMDBGeoResolver(opts MMDBOptions) (*MMDBGeoResolver, error) {
+	if opts.CityDatabasePath == "" {
+		return nil, errors.New("mmdb geo resolver requires a city database path")
+	}
+	if opts.ReloadInterval <= 0 {
+		opts.ReloadInterval = time.Minute
+	}
+
+	city, err := openMMDBFile(opts.CityDatabasePath)
+	if err != nil {
+		return nil, err
+	}
+
+	var asn *mmdbFile
+	if opts.ASNDatabasePath != "" {
+		asn, err = openMMDBFile(opts.ASNDatabasePath)
+		if err != nil {
+			city.close()
+			return nil, err
+		}
+	}
+
+	r := &MMDBGeoResolver{
+		city: city,
+		asn:  asn,
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+	go r.watch(opts.ReloadInterval)
+
+	return r, nil
+}
+
+type mmdbCityRecord struct {
+	Country struct {
+		ISOCode string            `maxminddb:"iso_code"`
+		Names   map[string]string `maxminddb:"names"`
+	} `maxminddb:"country"`
+	City struct {
+		Names map[string]string `maxminddb:"names"`
+	} `maxminddb:"city"`
+	Subdivisions []struct {
+		Names map[string]string `maxminddb:"names"`
+	} `maxminddb:"subdivisions"`
+}
+
+type mmdbASNRecord struct {
+	Number       uint32 `maxminddb:"autonomous_system_number"`
+	Organization string `maxminddb:"autonomous_system_organization"`
+}
+
+func (r *MMDBGeoResolver) Resolve(ip string) (GeoResult, error) {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return GeoResult{}, ErrInvalidIP
+	}
+
+	var city mmdbCityRecord
+	if err := r.city.lookup(parsed, &city); err != nil {
+		return GeoResult{}, err
+	}
+
+	result := GeoResult{
+		CountryCode: city.Country.ISOCode,
+		CountryName: city.Country.Names["en"],
+		City:        city.City.Names["en"],
+	}
+	if len(city.Subdivisions) > 0 {
+		result.Region = city.Subdivisions[0].Names["en"]
+	}
+
+	if r.asn != nil {
+		var asn mmdbASNRecord
+		if err := r.asn.lookup(parsed, &asn); err != nil {
+			return GeoResult{}, err
+		}
+		result.ASN = asn.Number
+		result.ASOrganization = asn.Organization
+	}
+
+	return result, nil
+}
+
+// Shutdown stops watching the database files and closes them. It implements
+// server.Shutdowner and must run after anything still resolving IPs. The
+// server hands over a context that is already cancelled, so it is ignored:
+// the watcher exits as soon as any reload in progress is done.
+func (r *MMDBGeoResolver) Shutdown(_ context.Context) error {
+	close(r.stop)
+	<-r.done
+
+	r.city.close()
+	if r.asn != nil {
+		r.asn.close()
+	}
+
+	return nil
+}
+
+func (r *MMDBGeoResolver) watch(interval time.Duration) {
+	defer close(r.done)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.stop:
+			return
+		case <-ticker.C:
+			if err := r.city.reloadIfChanged(); err != nil {
+				slog.Error("failed to reload city database", "path", r.city.path, "error", err)
+			}
+			if r.asn != nil {
+				if err := r.asn.reloadIfChanged(); err != nil {
+					slog.Error("failed to reload asn database", "path", r.asn.path, "error", err)
+				}
+			}
+		}
+	}
+}
+
+// mmdbFile guards a memory-mapped reader so it is never closed while a lookup
+// is still reading from it.
+type mmdbFile struct {
+	path string
+
+	mu      sync.RWMutex
+	reader  *maxminddb.Reader
+	modTime time.Time
+}
+
+func openMMDBFile(path string) (*mmdbFile, error) {
+	reader, modTime, err := openMMDBReader(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return &mmdbFile{path: path, reader: reader, modTime: modTime}, nil
+}
+
+func openMMDBReader(path string) (*maxminddb.Reader, time.Time, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("could not stat %s: %w", path, err)
+	}
+
+	reader, err := maxminddb.Open(path)
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("could not open %s: %w", path, err)
+	}
+
+	return reader, info.ModTime(), nil
+}
+
+func (f *mmdbFile) lookup(ip net.IP, result any) error {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	return f.reader.Lookup(ip, result)
+}
+
+func (f *mmdbFile) reloadIfChanged() error {
+	info, err := os.Stat(f.path)
+	if err != nil {
+		return err
+	}
+
+	f.mu.RLock()
+	unchanged := info.ModTime().Equal(f.modTime)
+	f.mu.RUnlock()
+	if unchanged {
+		return nil
+	}
+
+	reader, modTime, err := openMMDBReader(f.path)
+	if err != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	previous := f.reader
+	f.reader = reader
+	f.modTime = modTime
+	f.mu.Unlock()
+
+	slog.Info("reloaded geolocation database", "path", f.path)
+	return previous.Close()
+}
+
+func (f *mmdbFile) close() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.reader.Close(); err != nil {
+		slog.Error("failed to close geolocation database", "path", f.path, "error", err)
+	}
+}
@@ -0,0 +1,145 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeCityDatabase writes a minimal IPv4 city database that places every
+// address in country.
+func writeCityDatabase(t *testing.T, path, isoCode, country string) {
+	t.Helper()
+
+	str := func(s string) []byte { return append([]byte{2<<5 | byte(len(s))}, s...) }
+	uint16v := func(v uint16) []byte { return []byte{5<<5 | 2, byte(v >> 8), byte(v)} }
+	uint32v := func(v uint32) []byte { return []byte{6<<5 | 4, byte(v >> 24), byte(v >> 16), byte(v >> 8), byte(v)} }
+	mapv := func(pairs ...[]byte) []byte {
+		out := []byte{7<<5 | byte(len(pairs)/2)}
+		for _, p := range pairs {
+			out = append(out, p...)
+		}
+		return out
+	}
+
+	// One node whose both records point at the first record of the data
+	// section, which sits node count + 16 past the end of the tree.
+	const nodeCount = 1
+	var db []byte
+	db = append(db, 0, 0, nodeCount+16, 0, 0, nodeCount+16)
+	db = append(db, make([]byte, 16)...)
+	db = append(db, mapv(
+		str("country"), mapv(
+			str("iso_code"), str(isoCode),
+			str("names"), mapv(str("en"), str(country)),
+		),
+	)...)
+	db = append(db, "\xAB\xCD\xEFMaxMind.com"...)
+	db = append(db, mapv(
+		str("node_count"), uint32v(nodeCount),
+		str("record_size"), uint16v(24),
+		str("ip_version"), uint16v(4),
+		str("binary_format_major_version"), uint16v(2),
+		str("database_type"), str("Test-City"),
+	)...)
+
+	// Written next to the target and renamed over it, as geoipupdate does.
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, db, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMMDBGeoResolverResolve(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "city.mmdb")
+	writeCityDatabase(t, path, "DK", "Denmark")
+
+	r, err := NewMMDBGeoResolver(MMDBOptions{CityDatabasePath: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = r.Shutdown(t.Context()) })
+
+	tests := []struct {
+		ip      string
+		want    GeoResult
+		wantErr error
+	}{
+		{ip: "192.0.2.1", want: GeoResult{CountryCode: "DK", CountryName: "Denmark"}},
+		{ip: "not an ip", wantErr: ErrInvalidIP},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ip, func(t *testing.T) {
+			got, err := r.Resolve(tt.ip)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Resolve() error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Resolve() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMMDBGeoResolverReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "city.mmdb")
+	writeCityDatabase(t, path, "DK", "Denmark")
+
+	r, err := NewMMDBGeoResolver(MMDBOptions{CityDatabasePath: path, ReloadInterval: 5 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = r.Shutdown(t.Context()) })
+
+	writeCityDatabase(t, path, "SE", "Sweden")
+	// Some filesystems only keep whole seconds, so the new release is dated
+	// clearly after the first.
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(path, later, later); err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		result, err := r.Resolve("192.0.2.1")
+		if err != nil {
+			t.Fatalf("Resolve() error = %v", err)
+		}
+		if result.CountryCode == "SE" {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Resolve() = %+v after reload, want the new database", result)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestMMDBGeoResolverShutdownWithCancelledContext(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "city.mmdb")
+	writeCityDatabase(t, path, "DK", "Denmark")
+
+	r, err := NewMMDBGeoResolver(MMDBOptions{CityDatabasePath: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The server shuts components down with a context that has already been
+	// cancelled.
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+	if err := r.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+
+	if _, err := r.Resolve("192.0.2.1"); err == nil {
+		t.Fatal("Resolve() succeeded after the database was closed")
+	}
+}
@@ -0,0 +1,169 @@
+package services
+
+import (
+	"net/url"
+	"strings"
+)
+
+// Channels a visit is attributed to.
+const (
+	ChannelDirect        = "Direct"
+	ChannelOrganicSearch = "Organic Search"
+	ChannelSocial        = "Social"
+	ChannelEmail         = "Email"
+	ChannelPaid          = "Paid"
+	ChannelReferral      = "Referral"
+)
+
+// Referrer is a parsed referrer. Source is a known name such as "Google" or
+// else the host without "www.". All fields are empty for direct and internal
+// traffic.
+type Referrer struct {
+	Host    string
+	Source  string
+	Channel string
+}
+
+type knownSource struct {
+	name    string
+	channel string
+}
+
+// knownSources maps referrer hosts to the source they belong to, including
+// their subdomains. Entries without a dot match any public suffix, so google
+// covers google.de and google.co.uk alike.
+var knownSources = map[string]knownSource{
+	"google":               {"Google", ChannelOrganicSearch},
+	"bing.com":             {"Bing", ChannelOrganicSearch},
+	"duckduckgo.com":       {"DuckDuckGo", ChannelOrganicSearch},
+	"yahoo":                {"Yahoo", ChannelOrganicSearch},
+	"yandex":               {"Yandex", ChannelOrganicSearch},
+	"baidu.com":            {"Baidu", ChannelOrganicSearch},
+	"ecosia.org":           {"Ecosia", ChannelOrganicSearch},
+	"search.brave.com":     {"Brave Search", ChannelOrganicSearch},
+	"startpage.com":        {"Startpage", ChannelOrganicSearch},
+	"qwant.com":            {"Qwant", ChannelOrganicSearch},
+	"kagi.com":             {"Kagi", ChannelOrganicSearch},
+	"perplexity.ai":        {"Perplexity", ChannelOrganicSearch},
+	"chatgpt.com":          {"ChatGPT", ChannelOrganicSearch},
+	"facebook.com":         {"Facebook", ChannelSocial},
+	"fb.me":                {"Facebook", ChannelSocial},
+	"instagram.com":        {"Instagram", ChannelSocial},
+	"t.co":                 {"X", ChannelSocial},
+	"twitter.com":          {"X", ChannelSocial},
+	"x.com":                {"X", ChannelSocial},
+	"linkedin.com":         {"LinkedIn", ChannelSocial},
+	"lnkd.in":              {"LinkedIn", ChannelSocial},
+	"reddit.com":           {"Reddit", ChannelSocial},
+	"news.ycombinator.com": {"Hacker News", ChannelSocial},
+	"youtube.com":          {"YouTube", ChannelSocial},
+	"pinterest.com":        {"Pinterest", ChannelSocial},
+	"tiktok.com":           {"TikTok", ChannelSocial},
+	"threads.net":          {"Threads", ChannelSocial},
+	"bsky.app":             {"Bluesky", ChannelSocial},
+	"mastodon.social":      {"Mastodon", ChannelSocial},
+	"mail.google.com":      {"Gmail", ChannelEmail},
+	"outlook.live.com":     {"Outlook", ChannelEmail},
+	"outlook.office.com":   {"Outlook", ChannelEmail},
+	"mail.yahoo.com":       {"Yahoo Mail", ChannelEmail},
+	"mail.proton.me":       {"Proton Mail", ChannelEmail},
+	"app.fastmail.com":     {"Fastmail", ChannelEmail},
+}
+
+// paidMediums are utm_medium values used for paid campaigns.
+var paidMediums = map[string]bool{
+	"cpc": true, "ppc": true, "cpm": true, "cpv": true, "paid": true,
+	"paidsearch": true, "paid_search": true, "paidsocial": true, "paid_social": true,
+	"display": true, "banner": true, "retargeting": true,
+}
+
+// ParseReferrer parses a raw referrer. internal reports whether a hostname
+// belongs to the tracked website; such referrers are dropped, as they are
+// navigation within the site rather than a source of traffic.
+func ParseReferrer(raw string, internal func(hostname string) bool) Referrer {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return Referrer{}
+	}
+
+	host := raw
+	if u, err := url.Parse(raw); err == nil && u.Host != "" {
+		host = u.Hostname()
+	} else if i := strings.IndexAny(host, "/?#"); i >= 0 {
+		host = host[:i]
+	}
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	if host == "" || internal(host) {
+		return Referrer{}
+	}
+
+	host = strings.TrimPrefix(host, "www.")
+	if known, ok := lookupSource(host); ok {
+		return Referrer{Host: host, Source: known.name, Channel: known.channel}
+	}
+
+	return Referrer{Host: host, Source: host, Channel: ChannelReferral}
+}
+
+// lookupSource matches host and its parent domains against knownSources,
+// trying each name followed by a public suffix as a wildcard as well.
+func lookupSource(host string) (knownSource, bool) {
+	for domain := host; domain != ""; {
+		if known, ok := knownSources[domain]; ok {
+			return known, true
+		}
+
+		// google.co.uk matches "google" by dropping the public suffix.
+		if name, suffix, ok := strings.Cut(domain, "."); ok && isPublicSuffix(suffix) {
+			if known, ok := knownSources[name]; ok {
+				return known, true
+			}
+		}
+
+		_, parent, ok := strings.Cut(domain, ".")
+		if !ok {
+			break
+		}
+		domain = parent
+	}
+
+	return knownSource{}, false
+}
+
+// isPublicSuffix approximates public suffixes such as "de", "com" or "co.uk"
+// by their shape: at most two short labels.
+func isPublicSuffix(suffix string) bool {
+	labels := strings.Split(suffix, ".")
+	if len(labels) > 2 {
+		return false
+	}
+	for _, label := range labels {
+		if label == "" || len(label) > 3 {
+			return false
+		}
+	}
+	return true
+}
+
+// Channel attributes a visit to a channel from its campaign and referrer.
+// Campaign parameters win over the referrer, as they are set deliberately.
+func Channel(campaign Campaign, referrer Referrer) string {
+	medium := strings.ToLower(campaign.Medium)
+
+	switch {
+	case campaign.ClickID != "" || paidMediums[medium]:
+		return ChannelPaid
+	case medium == "email" || medium == "newsletter":
+		return ChannelEmail
+	case medium == "social" || medium == "social-network" || medium == "social_media":
+		return ChannelSocial
+	case medium == "organic":
+		return ChannelOrganicSearch
+	case referrer.Channel != "":
+		return referrer.Channel
+	case campaign.Source != "" || medium != "":
+		return ChannelReferral
+	default:
+		return ChannelDirect
+	}
+}
@@ -0,0 +1,82 @@
+package services
+
+import "testing"
+
+func TestParseReferrer(t *testing.T) {
+	internal := func(hostname string) bool {
+		return hostname == "example.com" || hostname == "www.example.com"
+	}
+
+	tests := []struct {
+		name string
+		raw  string
+		want Referrer
+	}{
+		{name: "direct", raw: "", want: Referrer{}},
+		{name: "internal", raw: "https://www.example.com/pricing", want: Referrer{}},
+		{
+			name: "search engine with query",
+			raw:  "https://www.google.com/search?q=analytics",
+			want: Referrer{Host: "google.com", Source: "Google", Channel: ChannelOrganicSearch},
+		},
+		{
+			name: "search engine country domain",
+			raw:  "https://google.co.uk/",
+			want: Referrer{Host: "google.co.uk", Source: "Google", Channel: ChannelOrganicSearch},
+		},
+		{
+			name: "social subdomain",
+			raw:  "https://l.facebook.com/l.php?u=x",
+			want: Referrer{Host: "l.facebook.com", Source: "Facebook", Channel: ChannelSocial},
+		},
+		{
+			name: "email client before its parent",
+			raw:  "https://mail.google.com/",
+			want: Referrer{Host: "mail.google.com", Source: "Gmail", Channel: ChannelEmail},
+		},
+		{
+			name: "lookalike is a referral",
+			raw:  "https://google.evil.com/",
+			want: Referrer{Host: "google.evil.com", Source: "google.evil.com", Channel: ChannelReferral},
+		},
+		{
+			name: "unknown site",
+			raw:  "https://blog.example.org/post",
+			want: Referrer{Host: "blog.example.org", Source: "blog.example.org", Channel: ChannelReferral},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParseReferrer(tt.raw, internal); got != tt.want {
+				t.Errorf("ParseReferrer(%q) = %+v, want %+v", tt.raw, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestChannel(t *testing.T) {
+	google := Referrer{Host: "google.com", Source: "Google", Channel: ChannelOrganicSearch}
+
+	tests := []struct {
+		name     string
+		campaign Campaign
+		referrer Referrer
+		want     string
+	}{
+		{name: "direct", want: ChannelDirect},
+		{name: "referrer channel", referrer: google, want: ChannelOrganicSearch},
+		{name: "click id is paid", campaign: Campaign{ClickID: "gclid"}, referrer: google, want: ChannelPaid},
+		{name: "paid medium", campaign: Campaign{Source: "google", Medium: "CPC"}, want: ChannelPaid},
+		{name: "email medium", campaign: Campaign{Source: "newsletter", Medium: "email"}, want: ChannelEmail},
+		{name: "source only", campaign: Campaign{Source: "partner"}, want: ChannelReferral},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Channel(tt.campaign, tt.referrer); got != tt.want {
+				t.Errorf("Channel() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
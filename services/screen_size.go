@@ -0,0 +1,38 @@
+package services
+
+// Screen size classes.
+const (
+	ScreenMobile    = "mobile"
+	ScreenTablet    = "tablet"
+	ScreenLaptop    = "laptop"
+	ScreenDesktop   = "desktop"
+	ScreenUltrawide = "ultrawide"
+)
+
+// ScreenBreakpoints are the minimum widths, in CSS pixels, of each screen
+// size class above mobile.
+type ScreenBreakpoints struct {
+	Tablet    int32
+	Laptop    int32
+	Desktop   int32
+	Ultrawide int32
+}
+
+// Classify returns the screen size class of a width, or an empty string
+// when the width is unknown.
+func (b ScreenBreakpoints) Classify(width int32) string {
+	switch {
+	case width <= 0:
+		return ""
+	case width >= b.Ultrawide:
+		return ScreenUltrawide
+	case width >= b.Desktop:
+		return ScreenDesktop
+	case width >= b.Laptop:
+		return ScreenLaptop
+	case width >= b.Tablet:
+		return ScreenTablet
+	}
+
+	return ScreenMobile
+}
@@ -0,0 +1,26 @@
+package services
+
+import "testing"
+
+func TestScreenBreakpointsClassify(t *testing.T) {
+	breakpoints := ScreenBreakpoints{Tablet: 576, Laptop: 992, Desktop: 1440, Ultrawide: 2560}
+
+	tests := []struct {
+		width int32
+		want  string
+	}{
+		{width: 0, want: ""},
+		{width: 375, want: ScreenMobile},
+		{width: 576, want: ScreenTablet},
+		{width: 991, want: ScreenTablet},
+		{width: 1280, want: ScreenLaptop},
+		{width: 1920, want: ScreenDesktop},
+		{width: 3440, want: ScreenUltrawide},
+	}
+
+	for _, tt := range tests {
+		if got := breakpoints.Classify(tt.width); got != tt.want {
+			t.Errorf("Classify(%d) = %q, want %q", tt.width, got, tt.want)
+		}
+	}
+}
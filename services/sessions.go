@@ -0,0 +1,240 @@
+package services
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+
+	"palantir/internal/storage"
+	"palantir/models"
+)
+
+// pendingSession collects the hits of a batch that belong to one session.
+// session holds what is written: the bounds and counts of the batch's hits
+// only, which UpsertSessions merges into an existing row. start and end are
+// the bounds including hits stored earlier and decide which hits belong.
+type pendingSession struct {
+	session    models.Session
+	start      time.Time
+	end        time.Time
+	touched    bool
+	entryAt    time.Time
+	exitAt     time.Time
+	attributed bool
+}
+
+// sessionStore is the part of a transaction sessionize works with. Locks
+// taken by lockVisitors are held until the transaction ends.
+type sessionStore interface {
+	lockVisitors(ctx context.Context, visitors []models.SessionVisitor) error
+	findRecent(ctx context.Context, visitors []models.SessionVisitor, since time.Time) ([]models.Session, error)
+	upsert(ctx context.Context, sessions []models.Session) error
+}
+
+type txSessionStore struct {
+	exec storage.Executor
+}
+
+func (s txSessionStore) lockVisitors(ctx context.Context, visitors []models.SessionVisitor) error {
+	return models.LockSessionVisitors(ctx, s.exec, visitors)
+}
+
+func (s txSessionStore) findRecent(ctx context.Context, visitors []models.SessionVisitor, since time.Time) ([]models.Session, error) {
+	return models.FindRecentSessions(ctx, s.exec, visitors, since)
+}
+
+func (s txSessionStore) upsert(ctx context.Context, sessions []models.Session) error {
+	return models.UpsertSessions(ctx, s.exec, sessions)
+}
+
+// sessionize assigns every hit to a session, continuing a visitor's recent
+// session when the hit falls within SessionTimeout of it and starting a new
+// one otherwise. It writes the sessions and returns their IDs in the order of
+// hits; hits without a visitor hash get uuid.Nil.
+//
+// The visitors are locked before their recent sessions are looked up, as the
+// pipeline and batch submissions store concurrently and would otherwise each
+// start a session for the same visitor.
+func sessionize(ctx context.Context, store sessionStore, hits []Hit) ([]uuid.UUID, error) {
+	order := sessionOrder(hits)
+	if len(order) == 0 {
+		return make([]uuid.UUID, len(hits)), nil
+	}
+
+	var visitors []models.SessionVisitor
+	seen := make(map[models.SessionVisitor]struct{})
+	for _, i := range order {
+		visitor := models.SessionVisitor{WebsiteID: hits[i].WebsiteID, VisitorHash: hits[i].VisitorHash}
+		if _, ok := seen[visitor]; !ok {
+			seen[visitor] = struct{}{}
+			visitors = append(visitors, visitor)
+		}
+	}
+
+	if err := store.lockVisitors(ctx, visitors); err != nil {
+		return nil, err
+	}
+
+	since := hits[order[0]].ReceivedAt.Add(-models.SessionTimeout)
+	recent, err := store.findRecent(ctx, visitors, since)
+	if err != nil {
+		return nil, err
+	}
+
+	ids, sessions := assignSessions(hits, order, recent)
+	if err := store.upsert(ctx, sessions); err != nil {
+		return nil, err
+	}
+
+	return ids, nil
+}
+
+// sessionOrder returns the indexes of the hits that have a visitor hash in
+// time order, as batch submissions and buffered hits don't necessarily
+// arrive that way.
+func sessionOrder(hits []Hit) []int {
+	order := make([]int, 0, len(hits))
+	for i, hit := range hits {
+		if hit.VisitorHash != "" {
+			order = append(order, i)
+		}
+	}
+
+	slices.SortStableFunc(order, func(a, b int) int {
+		return hits[a].ReceivedAt.Compare(hits[b].ReceivedAt)
+	})
+
+	return order
+}
+
+// assignSessions does the work of sessionize without the database. It
+// assigns the hits at order to the visitors' recent sessions or to new ones,
+// and returns the session ID of every hit along with the sessions to upsert.
+func assignSessions(hits []Hit, order []int, recent []models.Session) ([]uuid.UUID, []models.Session) {
+	ids := make([]uuid.UUID, len(hits))
+
+	current := make(map[models.SessionVisitor]*pendingSession, len(recent))
+	for _, s := range recent {
+		visitor := models.SessionVisitor{WebsiteID: s.WebsiteID, VisitorHash: s.VisitorHash}
+		current[visitor] = &pendingSession{
+			session: models.Session{
+				ID:          s.ID,
+				WebsiteID:   s.WebsiteID,
+				VisitorHash: s.VisitorHash,
+			},
+			start: s.StartedAt,
+			end:   s.EndedAt,
+			// Referrer and UTM parameters of existing sessions are kept.
+			attributed: true,
+		}
+	}
+
+	var pending []*pendingSession
+	for _, i := range order {
+		hit := hits[i]
+		visitor := models.SessionVisitor{WebsiteID: hit.WebsiteID, VisitorHash: hit.VisitorHash}
+
+		p, ok := current[visitor]
+		if hit.Type == HitTypeEngagement {
+			// Engagement is sent when the visitor leaves a page, so it
+			// extends the visit it belongs to but never starts one.
+			if ok && p.includes(hit.ReceivedAt) {
+				if !p.touched {
+					pending = append(pending, p)
+				}
+				p.extend(hit.ReceivedAt)
+				ids[i] = p.session.ID
+			}
+			continue
+		}
+
+		if !ok || !p.includes(hit.ReceivedAt) {
+			p = &pendingSession{
+				session: models.Session{
+					ID:          uuid.New(),
+					WebsiteID:   hit.WebsiteID,
+					VisitorHash: hit.VisitorHash,
+				},
+				start: hit.ReceivedAt,
+				end:   hit.ReceivedAt,
+			}
+			current[visitor] = p
+		}
+
+		if !p.touched {
+			pending = append(pending, p)
+		}
+		p.add(hit)
+		ids[i] = p.session.ID
+	}
+
+	sessions := make([]models.Session, len(pending))
+	for i, p := range pending {
+		sessions[i] = p.session
+	}
+
+	return ids, sessions
+}
+
+func (p *pendingSession) includes(at time.Time) bool {
+	return !at.Before(p.start.Add(-models.SessionTimeout)) &&
+		!at.After(p.end.Add(models.SessionTimeout))
+}
+
+func (p *pendingSession) add(hit Hit) {
+	at := hit.ReceivedAt
+	p.extend(at)
+
+	if hit.Type == HitTypeEvent {
+		p.session.Events++
+		return
+	}
+
+	p.session.Pageviews++
+	if p.session.EntryPage == "" || at.Before(p.entryAt) {
+		p.session.EntryPage = hit.URL
+		p.session.Hostname = hit.Hostname
+		p.session.Language = hit.Language
+		p.entryAt = at
+	}
+	if p.session.ExitPage == "" || !at.Before(p.exitAt) {
+		p.session.ExitPage = hit.URL
+		p.exitAt = at
+	}
+
+	if !p.attributed {
+		p.attributed = true
+		p.session.Referrer = hit.Referrer
+		p.session.UTMSource = hit.Campaign.Source
+		p.session.UTMMedium = hit.Campaign.Medium
+		p.session.UTMCampaign = hit.Campaign.Name
+		p.session.UTMTerm = hit.Campaign.Term
+		p.session.UTMContent = hit.Campaign.Content
+		p.session.ClickID = hit.Campaign.ClickID
+		p.session.ReferrerSource = hit.ParsedReferrer.Source
+		p.session.Channel = Channel(hit.Campaign, hit.ParsedReferrer)
+	}
+}
+
+// extend moves the session boundaries to include at.
+func (p *pendingSession) extend(at time.Time) {
+	if !p.touched {
+		p.touched = true
+		p.session.StartedAt = at
+		p.session.EndedAt = at
+	}
+	if at.Before(p.session.StartedAt) {
+		p.session.StartedAt = at
+	}
+	if at.After(p.session.EndedAt) {
+		p.session.EndedAt = at
+	}
+	if at.Before(p.start) {
+		p.start = at
+	}
+	if at.After(p.end) {
+		p.end = at
+	}
+}
@@ -0,0 +1,307 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"palantir/models"
+
+	"github.com/google/uuid"
+)
+
+func TestAssignSessions(t *testing.T) {
+	websiteID := uuid.New()
+	base := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	recentID := uuid.New()
+	recent := models.Session{
+		ID:          recentID,
+		WebsiteID:   websiteID,
+		VisitorHash: "visitor",
+		StartedAt:   base.Add(-time.Hour),
+		EndedAt:     base.Add(-10 * time.Minute),
+	}
+
+	hit := func(typ string, offset time.Duration, url string) Hit {
+		return Hit{
+			Type:        typ,
+			ReceivedAt:  base.Add(offset),
+			WebsiteID:   websiteID,
+			URL:         url,
+			VisitorHash: "visitor",
+			Referrer:    "https://news.example.org" + url,
+		}
+	}
+
+	tests := []struct {
+		name   string
+		hits   []Hit
+		recent []models.Session
+		// session lists, per hit, which session it belongs to: -1 for none,
+		// 0 for the recent session and n for the nth new one.
+		session       []int
+		wantSessions  int
+		wantEntry     string
+		wantExit      string
+		wantReferrer  string
+		wantPageviews int32
+	}{
+		{
+			name:          "continues a recent session",
+			hits:          []Hit{hit(HitTypePageview, 0, "/a")},
+			recent:        []models.Session{recent},
+			session:       []int{0},
+			wantSessions:  1,
+			wantEntry:     "/a",
+			wantExit:      "/a",
+			wantReferrer:  "",
+			wantPageviews: 1,
+		},
+		{
+			name:          "starts a new session after the timeout",
+			hits:          []Hit{hit(HitTypePageview, models.SessionTimeout, "/a")},
+			recent:        []models.Session{recent},
+			session:       []int{1},
+			wantSessions:  1,
+			wantEntry:     "/a",
+			wantExit:      "/a",
+			wantReferrer:  "https://news.example.org/a",
+			wantPageviews: 1,
+		},
+		{
+			name: "engagement never starts a session",
+			hits: []Hit{
+				hit(HitTypeEngagement, 0, "/a"),
+				hit(HitTypePageview, time.Minute, "/b"),
+			},
+			session:       []int{-1, 1},
+			wantSessions:  1,
+			wantEntry:     "/b",
+			wantExit:      "/b",
+			wantReferrer:  "https://news.example.org/b",
+			wantPageviews: 1,
+		},
+		{
+			name: "orders hits by time",
+			hits: []Hit{
+				hit(HitTypePageview, 2*time.Minute, "/c"),
+				hit(HitTypeEvent, time.Minute, "/b"),
+				hit(HitTypePageview, 0, "/a"),
+			},
+			session:       []int{1, 1, 1},
+			wantSessions:  1,
+			wantEntry:     "/a",
+			wantExit:      "/c",
+			wantReferrer:  "https://news.example.org/a",
+			wantPageviews: 2,
+		},
+		{
+			name: "splits on a gap within the batch",
+			hits: []Hit{
+				hit(HitTypePageview, 0, "/a"),
+				hit(HitTypePageview, time.Hour, "/b"),
+			},
+			session:       []int{1, 2},
+			wantSessions:  2,
+			wantEntry:     "/a",
+			wantExit:      "/a",
+			wantReferrer:  "https://news.example.org/a",
+			wantPageviews: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ids, sessions := assignSessions(tt.hits, sessionOrder(tt.hits), tt.recent)
+
+			if len(sessions) != tt.wantSessions {
+				t.Fatalf("got %d sessions, want %d", len(sessions), tt.wantSessions)
+			}
+
+			// New sessions are numbered in the order they were first seen.
+			numbers := map[uuid.UUID]int{uuid.Nil: -1, recentID: 0}
+			for _, s := range sessions {
+				if _, ok := numbers[s.ID]; !ok {
+					numbers[s.ID] = len(numbers) - 1
+				}
+			}
+			for i, id := range ids {
+				if got := numbers[id]; got != tt.session[i] {
+					t.Errorf("hit %d in session %d, want %d", i, got, tt.session[i])
+				}
+			}
+
+			first := sessions[0]
+			if first.EntryPage != tt.wantEntry || first.ExitPage != tt.wantExit {
+				t.Errorf("pages = %q to %q, want %q to %q", first.EntryPage, first.ExitPage, tt.wantEntry, tt.wantExit)
+			}
+			if first.Referrer != tt.wantReferrer {
+				t.Errorf("Referrer = %q, want %q", first.Referrer, tt.wantReferrer)
+			}
+			if first.Pageviews != tt.wantPageviews {
+				t.Errorf("Pageviews = %d, want %d", first.Pageviews, tt.wantPageviews)
+			}
+		})
+	}
+}
+
+func TestAssignSessionsSkipsAnonymousHits(t *testing.T) {
+	hits := []Hit{{Type: HitTypePageview, ReceivedAt: time.Now(), WebsiteID: uuid.New()}}
+
+	ids, sessions := assignSessions(hits, sessionOrder(hits), nil)
+	if ids[0] != uuid.Nil || len(sessions) != 0 {
+		t.Fatalf("got session %v and %d sessions, want none", ids[0], len(sessions))
+	}
+}
+
+// fakeSessionDB keeps committed sessions in memory and emulates the visitor
+// locks Postgres holds until a transaction ends. waiting receives a value
+// whenever a transaction has to wait for one.
+type fakeSessionDB struct {
+	mu       sync.Mutex
+	sessions map[uuid.UUID]models.Session
+	locks    map[models.SessionVisitor]chan struct{}
+	waiting  chan struct{}
+}
+
+type fakeSessionTx struct {
+	db      *fakeSessionDB
+	held    []chan struct{}
+	pending []models.Session
+}
+
+func newFakeSessionDB() *fakeSessionDB {
+	return &fakeSessionDB{
+		sessions: make(map[uuid.UUID]models.Session),
+		locks:    make(map[models.SessionVisitor]chan struct{}),
+		waiting:  make(chan struct{}, 10),
+	}
+}
+
+func (db *fakeSessionDB) begin() *fakeSessionTx {
+	return &fakeSessionTx{db: db}
+}
+
+func (tx *fakeSessionTx) lockVisitors(_ context.Context, visitors []models.SessionVisitor) error {
+	for _, visitor := range visitors {
+		tx.db.mu.Lock()
+		lock, ok := tx.db.locks[visitor]
+		if !ok {
+			lock = make(chan struct{}, 1)
+			tx.db.locks[visitor] = lock
+		}
+		tx.db.mu.Unlock()
+
+		select {
+		case lock <- struct{}{}:
+		default:
+			tx.db.waiting <- struct{}{}
+			lock <- struct{}{}
+		}
+		tx.held = append(tx.held, lock)
+	}
+	return nil
+}
+
+func (tx *fakeSessionTx) findRecent(_ context.Context, visitors []models.SessionVisitor, since time.Time) ([]models.Session, error) {
+	tx.db.mu.Lock()
+	defer tx.db.mu.Unlock()
+
+	var recent []models.Session
+	for _, visitor := range visitors {
+		var latest *models.Session
+		for _, s := range tx.db.sessions {
+			if s.WebsiteID != visitor.WebsiteID || s.VisitorHash != visitor.VisitorHash || s.EndedAt.Before(since) {
+				continue
+			}
+			if latest == nil || s.EndedAt.After(latest.EndedAt) {
+				latest = &s
+			}
+		}
+		if latest != nil {
+			recent = append(recent, *latest)
+		}
+	}
+	return recent, nil
+}
+
+func (tx *fakeSessionTx) upsert(_ context.Context, sessions []models.Session) error {
+	tx.pending = append(tx.pending, sessions...)
+	return nil
+}
+
+func (tx *fakeSessionTx) commit() {
+	tx.db.mu.Lock()
+	for _, s := range tx.pending {
+		if existing, ok := tx.db.sessions[s.ID]; ok {
+			if existing.StartedAt.Before(s.StartedAt) {
+				s.StartedAt = existing.StartedAt
+			}
+			if existing.EndedAt.After(s.EndedAt) {
+				s.EndedAt = existing.EndedAt
+			}
+			s.Pageviews += existing.Pageviews
+			s.Events += existing.Events
+		}
+		tx.db.sessions[s.ID] = s
+	}
+	tx.db.mu.Unlock()
+
+	for _, lock := range tx.held {
+		<-lock
+	}
+}
+
+func TestSessionizeConcurrentStores(t *testing.T) {
+	db := newFakeSessionDB()
+	websiteID := uuid.New()
+	start := time.Now()
+
+	hits := func(offset time.Duration) []Hit {
+		return []Hit{{
+			Type:        HitTypePageview,
+			ReceivedAt:  start.Add(offset),
+			WebsiteID:   websiteID,
+			URL:         "/",
+			VisitorHash: "visitor",
+		}}
+	}
+
+	first := db.begin()
+	firstIDs, err := sessionize(t.Context(), first, hits(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	secondIDs := make(chan []uuid.UUID, 1)
+	go func() {
+		second := db.begin()
+		ids, err := sessionize(t.Context(), second, hits(time.Second))
+		if err != nil {
+			t.Error(err)
+		}
+		second.commit()
+		secondIDs <- ids
+	}()
+
+	// The second store must not look for the visitor's session until the
+	// first has committed it.
+	select {
+	case <-db.waiting:
+	case <-time.After(time.Second):
+		t.Fatal("second store did not wait for the visitor lock")
+	}
+	first.commit()
+
+	ids := <-secondIDs
+	if ids[0] != firstIDs[0] {
+		t.Errorf("stores assigned sessions %v and %v, want the same", firstIDs[0], ids[0])
+	}
+	if len(db.sessions) != 1 {
+		t.Errorf("got %d sessions, want 1", len(db.sessions))
+	}
+	if got := db.sessions[firstIDs[0]].Pageviews; got != 2 {
+		t.Errorf("Pageviews = %d, want 2", got)
+	}
+}
@@ -0,0 +1,324 @@
+package services
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/mssola/useragent"
+)
+
+// Device types.
+const (
+	DeviceDesktop = "desktop"
+	DeviceMobile  = "mobile"
+	DeviceTablet  = "tablet"
+	DeviceBot     = "bot"
+)
+
+// maxClientLength is the length of the narrowest client column.
+const maxClientLength = 64
+
+// Client is the browser, operating system and device of a visitor. Versions
+// are kept coarse, major for browsers and major.minor for Apple systems, so
+// the breakdowns stay readable.
+type Client struct {
+	Browser        string
+	BrowserVersion string
+	OS             string
+	OSVersion      string
+	Device         string
+	DeviceBrand    string
+	DeviceModel    string
+}
+
+// ClientHints are the User-Agent Client Hints headers sent with a request.
+// Browsers send Brands, Mobile and Platform by default; the rest only when
+// the website opted in with Accept-CH and delegated them to Palantir.
+type ClientHints struct {
+	Brands          string
+	FullVersionList string
+	Mobile          string
+	Platform        string
+	PlatformVersion string
+	Model           string
+}
+
+// ClientHintsFromHeader reads the client hints of a request.
+func ClientHintsFromHeader(header http.Header) ClientHints {
+	return ClientHints{
+		Brands:          header.Get("Sec-CH-UA"),
+		FullVersionList: header.Get("Sec-CH-UA-Full-Version-List"),
+		Mobile:          header.Get("Sec-CH-UA-Mobile"),
+		Platform:        header.Get("Sec-CH-UA-Platform"),
+		PlatformVersion: header.Get("Sec-CH-UA-Platform-Version"),
+		Model:           header.Get("Sec-CH-UA-Model"),
+	}
+}
+
+// ParseClient describes the visitor behind a user agent. Client hints take
+// precedence where present, as Chromium browsers freeze the user agent
+// string: it reports Windows 10 on Windows 11 and "K" as the model of every
+// Android phone.
+func ParseClient(ua *useragent.UserAgent, hints ClientHints) Client {
+	raw := ua.UA()
+	name, version := ua.Browser()
+	client := Client{Browser: name, BrowserVersion: majorVersion(version)}
+	if v, ok := productVersion(raw, "SamsungBrowser"); ok {
+		client.Browser, client.BrowserVersion = "Samsung Internet", majorVersion(v)
+	}
+
+	client.OS, client.OSVersion = parseOS(ua)
+	client.Device = parseDevice(ua)
+	client.DeviceBrand, client.DeviceModel = parseModel(ua)
+
+	applyClientHints(&client, hints)
+
+	client.Browser = clientValue(client.Browser)
+	client.BrowserVersion = clientValue(client.BrowserVersion)
+	client.OS = clientValue(client.OS)
+	client.OSVersion = clientValue(client.OSVersion)
+	client.DeviceBrand = clientValue(client.DeviceBrand)
+	client.DeviceModel = clientValue(client.DeviceModel)
+	return client
+}
+
+func parseOS(ua *useragent.UserAgent) (string, string) {
+	info := ua.OSInfo()
+
+	switch {
+	case ua.Platform() == "iPad":
+		return "iPadOS", minorVersion(info.Version)
+	case ua.Platform() == "iPhone" || ua.Platform() == "iPod" || ua.Platform() == "iPod touch":
+		return "iOS", minorVersion(info.Version)
+	case strings.HasPrefix(info.Name, "Mac OS"):
+		return "macOS", minorVersion(info.Version)
+	case info.Name == "Android":
+		return "Android", majorVersion(info.Version)
+	case strings.HasPrefix(info.Name, "Windows"):
+		return "Windows", strings.TrimPrefix(info.FullName, "Windows ")
+	case strings.HasPrefix(info.Name, "CrOS"):
+		return "Chrome OS", ""
+	case strings.HasPrefix(info.FullName, "Linux"):
+		return "Linux", ""
+	}
+
+	return info.Name, info.Version
+}
+
+func parseDevice(ua *useragent.UserAgent) string {
+	raw := ua.UA()
+	platform := strings.ToLower(ua.Platform())
+
+	switch {
+	case ua.Bot():
+		return DeviceBot
+	case platform == "ipad" || strings.Contains(platform, "tablet") || strings.Contains(raw, "Tablet"):
+		return DeviceTablet
+	// Android phones say Mobile, tablets don't.
+	case ua.OSInfo().Name == "Android" && !strings.Contains(raw, "Mobile"):
+		return DeviceTablet
+	case ua.Mobile():
+		return DeviceMobile
+	}
+
+	return DeviceDesktop
+}
+
+// modelBrands maps model name prefixes of Android devices to their brand.
+var modelBrands = []struct {
+	prefix string
+	brand  string
+}{
+	{"SM-", "Samsung"},
+	{"GT-", "Samsung"},
+	{"Galaxy", "Samsung"},
+	{"Pixel", "Google"},
+	{"Nexus", "Google"},
+	{"Redmi", "Xiaomi"},
+	{"POCO", "Xiaomi"},
+	{"Mi ", "Xiaomi"},
+	{"moto", "Motorola"},
+	{"Moto", "Motorola"},
+	{"XT", "Motorola"},
+	{"ONEPLUS", "OnePlus"},
+	{"OnePlus", "OnePlus"},
+	{"CPH", "Oppo"},
+	{"HUAWEI", "Huawei"},
+	{"Nokia", "Nokia"},
+	{"LM-", "LG"},
+	{"XQ-", "Sony"},
+	{"vivo", "Vivo"},
+	{"V2", "Vivo"},
+	{"RMX", "Realme"},
+	{"KFT", "Amazon"},
+	{"KFM", "Amazon"},
+}
+
+func parseModel(ua *useragent.UserAgent) (string, string) {
+	switch ua.Platform() {
+	case "iPhone", "iPad", "iPod", "iPod touch":
+		return "Apple", ua.Platform()
+	case "Macintosh":
+		return "Apple", "Mac"
+	}
+
+	return modelBrand(ua.Model())
+}
+
+// modelBrand returns the brand and the model of an Android model name.
+// Reduced user agents carry "K" instead of a model, which is dropped.
+func modelBrand(model string) (string, string) {
+	model = strings.TrimSpace(model)
+	if model == "" || model == "K" {
+		return "", ""
+	}
+
+	for _, mb := range modelBrands {
+		if strings.HasPrefix(model, mb.prefix) {
+			return mb.brand, model
+		}
+	}
+	return "", model
+}
+
+// hintBrands maps client hint brands to the browser names the user agent
+// parser uses.
+var hintBrands = map[string]string{
+	"Google Chrome":    "Chrome",
+	"Microsoft Edge":   "Edge",
+	"Opera":            "Opera",
+	"Brave":            "Brave",
+	"Vivaldi":          "Vivaldi",
+	"Samsung Internet": "Samsung Internet",
+	"YaBrowser":        "Yandex Browser",
+}
+
+func applyClientHints(client *Client, hints ClientHints) {
+	brands := parseBrandList(hints.FullVersionList)
+	if len(brands) == 0 {
+		brands = parseBrandList(hints.Brands)
+	}
+
+	// Brand lists also hold "Chromium" and a made up brand, which the user
+	// agent string already covers.
+	for _, brand := range brands {
+		if name, ok := hintBrands[brand.name]; ok {
+			client.Browser, client.BrowserVersion = name, majorVersion(brand.version)
+			break
+		}
+	}
+
+	switch hints.Mobile {
+	case "?1":
+		if client.Device == DeviceDesktop {
+			client.Device = DeviceMobile
+		}
+	case "?0":
+		if client.Device == DeviceMobile {
+			client.Device = DeviceDesktop
+		}
+	}
+
+	platform := unquote(hints.Platform)
+	platformVersion := unquote(hints.PlatformVersion)
+	switch platform {
+	case "":
+	case "Windows":
+		client.OS = "Windows"
+		if major, err := strconv.Atoi(majorVersion(platformVersion)); err == nil {
+			// Platform versions 13 and up are Windows 11, lower ones 10.
+			switch {
+			case major >= 13:
+				client.OSVersion = "11"
+			case major > 0:
+				client.OSVersion = "10"
+			}
+		}
+	case "macOS":
+		client.OS = "macOS"
+		if platformVersion != "" {
+			client.OSVersion = minorVersion(platformVersion)
+		}
+	case "Android":
+		client.OS = "Android"
+		if platformVersion != "" {
+			client.OSVersion = majorVersion(platformVersion)
+		}
+	case "Chrome OS", "Chromium OS":
+		client.OS = "Chrome OS"
+	default:
+		client.OS = platform
+		if platformVersion != "" {
+			client.OSVersion = majorVersion(platformVersion)
+		}
+	}
+
+	if brand, model := modelBrand(unquote(hints.Model)); model != "" {
+		client.DeviceBrand, client.DeviceModel = brand, model
+	}
+}
+
+type brandVersion struct {
+	name    string
+	version string
+}
+
+// parseBrandList parses a Sec-CH-UA style list such as
+// `"Chromium";v="120", "Google Chrome";v="120", "Not_A Brand";v="8"`.
+func parseBrandList(value string) []brandVersion {
+	var brands []brandVersion
+	for entry := range strings.SplitSeq(value, ",") {
+		name, params, _ := strings.Cut(entry, ";")
+		brand := brandVersion{name: unquote(name)}
+		for param := range strings.SplitSeq(params, ";") {
+			if v, ok := strings.CutPrefix(strings.TrimSpace(param), "v="); ok {
+				brand.version = unquote(v)
+			}
+		}
+		if brand.name != "" {
+			brands = append(brands, brand)
+		}
+	}
+	return brands
+}
+
+// productVersion finds the version of a product token like
+// "SamsungBrowser/23.0" in a user agent.
+func productVersion(ua, product string) (string, bool) {
+	_, rest, ok := strings.Cut(ua, product+"/")
+	if !ok {
+		return "", false
+	}
+
+	if i := strings.IndexAny(rest, " ;)"); i >= 0 {
+		rest = rest[:i]
+	}
+	return rest, rest != ""
+}
+
+func majorVersion(version string) string {
+	major, _, _ := strings.Cut(version, ".")
+	return major
+}
+
+func minorVersion(version string) string {
+	parts := strings.SplitN(version, ".", 3)
+	if len(parts) < 2 {
+		return version
+	}
+	return parts[0] + "." + parts[1]
+}
+
+func unquote(value string) string {
+	return strings.Trim(strings.TrimSpace(value), `"`)
+}
+
+// clientValue cuts a value to the length of its column.
+func clientValue(value string) string {
+	value = strings.TrimSpace(value)
+	if len(value) > maxClientLength {
+		value = value[:maxClientLength]
+	}
+	return strings.ToValidUTF8(value, "")
+}
@@ -0,0 +1,89 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/mssola/useragent"
+)
+
+func TestParseClient(t *testing.T) {
+	tests := []struct {
+		name  string
+		ua    string
+		hints ClientHints
+		want  Client
+	}{
+		{
+			name: "chrome on windows",
+			ua:   "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36",
+			want: Client{Browser: "Chrome", BrowserVersion: "120", OS: "Windows", OSVersion: "10", Device: DeviceDesktop},
+		},
+		{
+			name: "windows 11 from client hints",
+			ua:   "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36 Edg/120.0.2210.91",
+			hints: ClientHints{
+				Brands:          `"Not_A Brand";v="8", "Chromium";v="120", "Microsoft Edge";v="120"`,
+				Mobile:          "?0",
+				Platform:        `"Windows"`,
+				PlatformVersion: `"15.0.0"`,
+			},
+			want: Client{Browser: "Edge", BrowserVersion: "120", OS: "Windows", OSVersion: "11", Device: DeviceDesktop},
+		},
+		{
+			name: "safari on mac",
+			ua:   "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.1 Safari/605.1.15",
+			want: Client{Browser: "Safari", BrowserVersion: "17", OS: "macOS", OSVersion: "10.15", Device: DeviceDesktop, DeviceBrand: "Apple", DeviceModel: "Mac"},
+		},
+		{
+			name: "iphone",
+			ua:   "Mozilla/5.0 (iPhone; CPU iPhone OS 17_1_2 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.1.2 Mobile/15E148 Safari/604.1",
+			want: Client{Browser: "Safari", BrowserVersion: "17", OS: "iOS", OSVersion: "17.1", Device: DeviceMobile, DeviceBrand: "Apple", DeviceModel: "iPhone"},
+		},
+		{
+			name: "ipad is a tablet",
+			ua:   "Mozilla/5.0 (iPad; CPU OS 16_6 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/16.6 Mobile/15E148 Safari/604.1",
+			want: Client{Browser: "Safari", BrowserVersion: "16", OS: "iPadOS", OSVersion: "16.6", Device: DeviceTablet, DeviceBrand: "Apple", DeviceModel: "iPad"},
+		},
+		{
+			name: "android phone",
+			ua:   "Mozilla/5.0 (Linux; Android 14; SM-S918B) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.6099.144 Mobile Safari/537.36",
+			want: Client{Browser: "Chrome", BrowserVersion: "120", OS: "Android", OSVersion: "14", Device: DeviceMobile, DeviceBrand: "Samsung", DeviceModel: "SM-S918B"},
+		},
+		{
+			name: "android tablet has no mobile token",
+			ua:   "Mozilla/5.0 (Linux; Android 13; SM-X700) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/119.0.0.0 Safari/537.36",
+			want: Client{Browser: "Chrome", BrowserVersion: "119", OS: "Android", OSVersion: "13", Device: DeviceTablet, DeviceBrand: "Samsung", DeviceModel: "SM-X700"},
+		},
+		{
+			name: "reduced android user agent with model hint",
+			ua:   "Mozilla/5.0 (Linux; Android 10; K) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Mobile Safari/537.36",
+			hints: ClientHints{
+				FullVersionList: `"Google Chrome";v="120.0.6099.144", "Chromium";v="120.0.6099.144"`,
+				Mobile:          "?1",
+				Platform:        `"Android"`,
+				PlatformVersion: `"14.0.0"`,
+				Model:           `"Pixel 8"`,
+			},
+			want: Client{Browser: "Chrome", BrowserVersion: "120", OS: "Android", OSVersion: "14", Device: DeviceMobile, DeviceBrand: "Google", DeviceModel: "Pixel 8"},
+		},
+		{
+			name: "samsung internet",
+			ua:   "Mozilla/5.0 (Linux; Android 14; SM-S918B) AppleWebKit/537.36 (KHTML, like Gecko) SamsungBrowser/23.0 Chrome/115.0.0.0 Mobile Safari/537.36",
+			want: Client{Browser: "Samsung Internet", BrowserVersion: "23", OS: "Android", OSVersion: "14", Device: DeviceMobile, DeviceBrand: "Samsung", DeviceModel: "SM-S918B"},
+		},
+		{
+			name: "firefox on linux",
+			ua:   "Mozilla/5.0 (X11; Linux x86_64; rv:121.0) Gecko/20100101 Firefox/121.0",
+			want: Client{Browser: "Firefox", BrowserVersion: "121", OS: "Linux", Device: DeviceDesktop},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ParseClient(useragent.New(tt.ua), tt.hints)
+			if got != tt.want {
+				t.Errorf("ParseClient() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"palantir/internal/storage"
+	"palantir/models"
+)
+
+var ErrVisitorSaltExpired = errors.New("visitor salt has expired")
+
+// VisitorSalts hashes visitors with a random salt per UTC day. Only the salts
+// of today and yesterday exist, so hits can still be hashed for the day they
+// happened on, and a periodic job deletes anything older.
+type VisitorSalts struct {
+	db storage.Pool
+
+	mu    sync.Mutex
+	salts map[time.Time][]byte
+}
+
+func NewVisitorSalts(db storage.Pool) *VisitorSalts {
+	return &VisitorSalts{
+		db:    db,
+		salts: make(map[time.Time][]byte),
+	}
+}
+
+// Hash identifies a visitor of a website on the day of at without storing
+// anything that can be traced back to them once the day's salt is gone.
+func (v *VisitorSalts) Hash(
+	ctx context.Context,
+	websiteID uuid.UUID,
+	ip string,
+	userAgent string,
+	at time.Time,
+) (string, error) {
+	salt, err := v.salt(ctx, at)
+	if err != nil {
+		return "", err
+	}
+
+	m := hmac.New(sha256.New, salt)
+	m.Write([]byte(websiteID.String()))
+	m.Write([]byte(ip))
+	m.Write([]byte(userAgent))
+
+	return hex.EncodeToString(m.Sum(nil)), nil
+}
+
+func (v *VisitorSalts) salt(ctx context.Context, at time.Time) ([]byte, error) {
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	yesterday := today.AddDate(0, 0, -1)
+
+	day := at.UTC().Truncate(24 * time.Hour)
+	if day.After(today) {
+		// Hits timestamped slightly ahead of the server clock belong to today.
+		day = today
+	}
+	if day.Before(yesterday) {
+		return nil, ErrVisitorSaltExpired
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if salt, ok := v.salts[day]; ok {
+		return salt, nil
+	}
+
+	salt, err := models.FindOrCreateVisitorSalt(ctx, v.db.Conn(), day)
+	if err != nil {
+		return nil, err
+	}
+
+	for cached := range v.salts {
+		if cached.Before(yesterday) {
+			delete(v.salts, cached)
+		}
+	}
+	v.salts[day] = salt.Salt
+
+	return salt.Salt, nil
+}
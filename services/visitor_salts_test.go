@@ -0,0 +1,56 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestVisitorSaltsHash(t *testing.T) {
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	yesterday := today.AddDate(0, 0, -1)
+
+	// Salts are seeded so the database is never asked for one.
+	v := NewVisitorSalts(nil)
+	v.salts[today] = []byte("today")
+	v.salts[yesterday] = []byte("yesterday")
+
+	websiteID := uuid.New()
+	hash := func(websiteID uuid.UUID, ip string, at time.Time) string {
+		t.Helper()
+
+		h, err := v.Hash(t.Context(), websiteID, ip, "Mozilla/5.0", at)
+		if err != nil {
+			t.Fatalf("Hash() error = %v", err)
+		}
+		return h
+	}
+
+	visitor := hash(websiteID, "192.0.2.1", today.Add(time.Hour))
+
+	tests := []struct {
+		name string
+		got  string
+		same bool
+	}{
+		{name: "later the same day", got: hash(websiteID, "192.0.2.1", today.Add(20*time.Hour)), same: true},
+		{name: "slightly in the future", got: hash(websiteID, "192.0.2.1", today.Add(24*time.Hour+time.Minute)), same: true},
+		{name: "another address", got: hash(websiteID, "192.0.2.2", today.Add(time.Hour)), same: false},
+		{name: "another website", got: hash(uuid.New(), "192.0.2.1", today.Add(time.Hour)), same: false},
+		{name: "yesterday", got: hash(websiteID, "192.0.2.1", yesterday.Add(time.Hour)), same: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if (tt.got == visitor) != tt.same {
+				t.Errorf("Hash() = %s, first visit %s, want same = %v", tt.got, visitor, tt.same)
+			}
+		})
+	}
+
+	if _, err := v.Hash(t.Context(), websiteID, "192.0.2.1", "Mozilla/5.0", yesterday.Add(-time.Hour)); !errors.Is(err, ErrVisitorSaltExpired) {
+		t.Errorf("Hash() error = %v, want %v", err, ErrVisitorSaltExpired)
+	}
+}
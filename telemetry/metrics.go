@@ -87,6 +87,30 @@ func HTTPResponseSize() (metric.Float64Histogram, error) {
 	return histogram, nil
 }
 
+func GeoLookupsTotal() (metric.Int64Counter, error) {
+	counter, err := GetMeter(config.ServiceName).Int64Counter(
+		"geo_lookups_total",
+		metric.WithDescription("Total number of geolocation lookups by result"),
+		metric.WithUnit("1"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create geo_lookups_total counter: %w", err)
+	}
+	return counter, nil
+}
+
+func CollectThrottledTotal() (metric.Int64Counter, error) {
+	counter, err := GetMeter(config.ServiceName).Int64Counter(
+		"collect_throttled_total",
+		metric.WithDescription("Total number of collect hits rejected by rate limiting"),
+		metric.WithUnit("1"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create collect_throttled_total counter: %w", err)
+	}
+	return counter, nil
+}
+
 func SetupRuntimeMetricsInCallback(meter metric.Meter) error {
 	_, err := meter.Int64ObservableGauge(
 		"go_goroutines",